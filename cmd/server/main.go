@@ -1,16 +1,23 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"skh_app/internal/config"
 	"skh_app/internal/handler"
+	"skh_app/internal/model"
 	"skh_app/internal/repository"
 	"skh_app/internal/service" // <-- Pastikan import ini ada
 	"skh_app/web"
-	"time"
+	"syscall"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -18,76 +25,308 @@ import (
 )
 
 func main() {
-	db, err := repository.ConnectDatabase()
+	// Subperintah ditulis sebelum flag: skh migrate [flag] status
+	args, perintah := os.Args[1:], ""
+	if len(args) > 0 && args[0] == "migrate" {
+		args, perintah = args[1:], args[0]
+	}
+	cfg, err := config.Load(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
 	if err != nil {
-		log.Fatalf("Gagal koneksi ke database: %v", err)
+		log.Fatalf("Konfigurasi tidak valid: %v", err)
 	}
-	defer db.Close()
+
+	// log.Printf yang tersisa di kode hanya dipakai untuk kegagalan, jadi dicatat sebagai error
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: cfg.Level})))
+	slog.SetLogLoggerLevel(slog.LevelError)
+	slog.Debug("Konfigurasi dimuat", "addr", cfg.Addr, "db", cfg.DBPath, "uploads", cfg.UploadsDir,
+		"timezone", cfg.Timezone, "open_browser", cfg.BukaBrowser, "log_level", cfg.LogLevel)
+
+	if perintah == "migrate" {
+		if err := jalankanMigrate(cfg, cfg.Args); err != nil {
+			slog.Error(err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+	if len(cfg.Args) > 0 {
+		log.Fatalf("Perintah %q tidak dikenal, yang tersedia: migrate", cfg.Args[0])
+	}
+
+	// Ctrl+C, SIGTERM atau jendela konsol yang ditutup (Windows) menghentikan server dengan rapi
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, stop, cfg); err != nil {
+		slog.Error(err.Error())
+		os.Exit(1)
+	}
+	slog.Info("Aplikasi berhenti")
+}
+
+// run menyiapkan database, service dan router lalu melayani request sampai ctx selesai.
+// Database selalu ditutup sebelum run kembali.
+func run(ctx context.Context, stop context.CancelFunc, cfg *config.Config) (err error) {
+	db, err := repository.ConnectDatabase(cfg.DBPath, cfg.Lokasi)
+	if err != nil {
+		return fmt.Errorf("gagal koneksi ke database: %w", err)
+	}
+	defer func() {
+		if errTutup := repository.CloseDatabase(db); errTutup != nil {
+			slog.Error("Gagal menutup database", "error", errTutup)
+		} else {
+			slog.Info("Database ditutup")
+		}
+	}()
 
 	// --- BAGIAN INISIALISASI FINAL ---
-	suratRepo := repository.NewSuratRepository(db)
-	
+	suratRepo := repository.NewSuratRepository(db, cfg.Lokasi)
+
+	uploadsPath, err := filepath.Abs(cfg.UploadsDir)
+	if err != nil {
+		return fmt.Errorf("folder uploads tidak valid: %w", err)
+	}
+	if err := os.MkdirAll(uploadsPath, 0o755); err != nil {
+		return fmt.Errorf("gagal menyiapkan folder uploads: %w", err)
+	}
+
 	// Inisialisasi kedua service dengan repository yang sama
-	suratService := service.NewSuratService(suratRepo)
-	pengaturanService := service.NewPengaturanService(suratRepo)
+	suratService := service.NewSuratService(suratRepo, cfg.Lokasi)
+	pengaturanService := service.NewPengaturanService(suratRepo, cfg.Lokasi, uploadsPath)
+	authService := service.NewAuthService(suratRepo)
+	barangService := service.NewBarangService(suratRepo)
+	exportService := service.NewExportService(suratRepo, cfg.Lokasi)
+	laporanService := service.NewLaporanService(suratRepo, cfg.Lokasi)
+
+	backupPath, err := filepath.Abs(cfg.BackupDir)
+	if err != nil {
+		return fmt.Errorf("folder cadangan tidak valid: %w", err)
+	}
+	backupService := service.NewBackupService(suratRepo, cfg.Lokasi, backupPath, uploadsPath, service.JadwalBackup{
+		Interval: cfg.IntervalBackup,
+		Harian:   cfg.BackupHarian,
+		Mingguan: cfg.BackupMingguan,
+		Bulanan:  cfg.BackupBulanan,
+	})
+	// Cadangan otomatis berhenti bersama server; database baru ditutup setelah cadangan yang
+	// sedang dibuat selesai
+	jadwalSelesai := make(chan struct{})
+	go func() {
+		defer close(jadwalSelesai)
+		backupService.Jadwalkan(ctx)
+	}()
+	defer func() {
+		stop()
+		<-jadwalSelesai
+	}()
+
+	if err := authService.EnsureDefaultAdmin(); err != nil {
+		return fmt.Errorf("gagal menyiapkan akun admin: %w", err)
+	}
+
+	pdfService, err := service.NewPDFService(web.Files, uploadsPath)
+	if err != nil {
+		return fmt.Errorf("gagal menyiapkan pembuat PDF: %w", err)
+	}
+
+	verifikasiService, err := service.NewVerifikasiService(suratRepo)
+	if err != nil {
+		return fmt.Errorf("gagal menyiapkan verifikasi surat: %w", err)
+	}
+	if cfg.PublicURL == "" {
+		slog.Warn("Alamat publik (public_url) belum diatur; surat dicetak tanpa QR code verifikasi")
+	}
 
 	// Suntikkan semua dependensi ke Handler
-	h := handler.NewHandler(suratRepo, suratService, pengaturanService)
+	h := handler.NewHandler(suratRepo, suratService, pengaturanService, authService, pdfService, verifikasiService, barangService, exportService, laporanService, backupService, cfg.Lokasi, cfg.PublicURL)
 	// --- AKHIR BAGIAN INISIALISASI FINAL ---
 
 	r := chi.NewRouter()
-	r.Use(middleware.Logger)
+	if slog.Default().Enabled(context.Background(), slog.LevelInfo) {
+		r.Use(middleware.Logger)
+	}
 	r.Use(middleware.Recoverer)
 
-	wd, err := os.Getwd()
-	if err != nil {
-		log.Fatalf("Gagal mendapatkan working directory: %v", err)
-	}
-	uploadsDir := http.Dir(filepath.Join(wd, "web", "static", "uploads"))
+	uploadsDir := http.Dir(uploadsPath)
 
 	r.Handle("/static/uploads/*", http.StripPrefix("/static/uploads/", http.FileServer(uploadsDir)))
 	r.Handle("/static/*", http.FileServer(http.FS(web.Files)))
+	r.Get("/healthz", h.Healthz)
 
-	r.Get("/", h.Dashboard)
+	// REST API JSON untuk kiosk dan aplikasi pelaporan. Autentikasi memakai token dari
+	// POST /api/v1/login (header Authorization: Bearer) atau cookie sesi yang sama dengan halaman web.
+	r.Route("/api/v1", func(r chi.Router) {
+		r.NotFound(h.APINotFound)
+		r.MethodNotAllowed(h.APIMethodNotAllowed)
+		r.Get("/openapi.yaml", h.APIOpenAPI)
 
-	r.Route("/surat", func(r chi.Router) {
-		r.Get("/", h.SuratList)
-		r.Get("/baru", h.SuratFormNew)
-		r.Post("/baru", h.SuratCreate)
-		r.Get("/print/{id}", h.SuratPrint)
-		r.Get("/edit/{id}", h.SuratFormEdit)
-		r.Post("/edit/{id}", h.SuratUpdate)
-		r.Get("/hapus/{id}", h.SuratDelete)
-	})
+		r.Group(func(r chi.Router) {
+			r.Use(h.LoadUser)
+			r.Post("/login", h.APILogin)
+
+			r.Group(func(r chi.Router) {
+				r.Use(h.APIRequireLogin)
 
-	r.Route("/petugas", func(r chi.Router) {
-		r.Get("/", h.PetugasList)
-		r.Get("/baru", h.PetugasFormNew)
-		r.Post("/baru", h.PetugasCreate)
-		r.Get("/edit/{id}", h.PetugasFormEdit)
-		r.Post("/edit/{id}", h.PetugasUpdate)
-		r.Get("/hapus/{id}", h.PetugasDelete)
+				r.Post("/logout", h.APILogout)
+				r.Get("/me", h.APIMe)
+				r.Get("/dashboard", h.APIDashboard)
+
+				r.Route("/surat", func(r chi.Router) {
+					r.Get("/", h.APISuratList)
+					r.Post("/", h.APISuratCreate)
+					// Surat kantor lain dianggap tidak ada bagi pengguna yang dibatasi ke satu kantor
+					r.Group(func(r chi.Router) {
+						r.Use(h.APIRequireKantorSurat)
+						r.Get("/{id}", h.APISuratGet)
+						r.Post("/{id}/perpanjang", h.APISuratPerpanjang)
+						r.With(h.APIRequireRole(model.RoleSupervisor)).Post("/{id}/batal", h.APISuratCancel)
+					})
+				})
+
+				r.Route("/petugas", func(r chi.Router) {
+					r.Get("/", h.APIPetugasList)
+					r.Get("/{id}", h.APIPetugasGet)
+					r.Group(func(r chi.Router) {
+						r.Use(h.APIRequireRole(model.RoleAdmin))
+						r.Post("/", h.APIPetugasCreate)
+						r.Put("/{id}", h.APIPetugasUpdate)
+						r.Delete("/{id}", h.APIPetugasDelete)
+					})
+				})
+
+				r.Get("/jenis-barang", h.APIJenisBarangList)
+				r.Get("/pengaturan", h.APIPengaturanGet)
+				r.With(h.APIRequireRole(model.RoleAdmin)).Patch("/pengaturan", h.APIPengaturanUpdate)
+			})
+		})
 	})
 
-	r.Route("/pengaturan", func(r chi.Router) {
-		r.Get("/", h.PengaturanForm)
-		r.Post("/", h.PengaturanUpdate)
+	// Halaman verifikasi QR code terbuka untuk umum (bank, dukcapil, dll.)
+	r.Get("/verifikasi/{token}", h.Verifikasi)
+
+	r.Group(func(r chi.Router) {
+		r.Use(h.LoadUser)
+
+		r.Get("/login", h.LoginForm)
+		r.Post("/login", h.Login)
+
+		// Semua route di bawah ini wajib login
+		r.Group(func(r chi.Router) {
+			r.Use(h.RequireLogin)
+
+			r.Get("/", h.Dashboard)
+			r.Post("/logout", h.Logout)
+			r.Get("/akun/password", h.GantiPasswordForm)
+			r.Post("/akun/password", h.GantiPassword)
+
+			r.Route("/surat", func(r chi.Router) {
+				r.Get("/", h.SuratList)
+				r.Get("/export", h.SuratExport)
+				r.Get("/baru", h.SuratFormNew)
+				r.Post("/baru", h.SuratCreate)
+
+				// Surat kantor lain dianggap tidak ada bagi pengguna yang dibatasi ke satu kantor
+				r.Group(func(r chi.Router) {
+					r.Use(h.RequireKantorSurat)
+					r.Get("/detail/{id}", h.SuratDetail)
+					// GET hanya menampilkan konfirmasi; cetakan dibuat dan dicatat lewat POST
+					r.Get("/print/{id}", h.SuratPrintForm)
+					r.Post("/print/{id}", h.SuratPrint)
+					r.Get("/pdf/{id}", h.SuratPDFForm)
+					r.Post("/pdf/{id}", h.SuratPDF)
+					r.Get("/revisi/{id}", h.SuratRevisi)
+					r.Get("/perpanjang/{id}", h.SuratPerpanjangForm)
+					r.Post("/perpanjang/{id}", h.SuratPerpanjang)
+
+					// Mengubah dan membatalkan surat yang sudah terbit hanya untuk supervisor
+					r.Group(func(r chi.Router) {
+						r.Use(h.RequireRole(model.RoleSupervisor))
+						r.Get("/edit/{id}", h.SuratFormEdit)
+						r.Post("/edit/{id}", h.SuratUpdate)
+						r.Get("/batal/{id}", h.SuratCancelForm)
+						r.Post("/batal/{id}", h.SuratCancel)
+					})
+				})
+			})
+
+			r.Route("/pelapor", func(r chi.Router) {
+				r.Get("/", h.PelaporList)
+				r.Get("/cari", h.PelaporCari)
+				r.Get("/{id}", h.PelaporDetail)
+			})
+
+			r.With(h.RequireRole(model.RoleSupervisor)).Get("/audit", h.AuditList)
+
+			r.Route("/laporan", func(r chi.Router) {
+				r.Use(h.RequireRole(model.RoleSupervisor))
+				r.Get("/", h.Laporan)
+				r.Get("/cetak", h.LaporanCetak)
+				r.Get("/pdf", h.LaporanPDF)
+				r.Get("/xlsx", h.LaporanXLSX)
+			})
+
+			// Menu administrasi hanya untuk admin
+			r.Group(func(r chi.Router) {
+				r.Use(h.RequireRole(model.RoleAdmin))
+
+				r.Route("/petugas", func(r chi.Router) {
+					r.Get("/", h.PetugasList)
+					r.Get("/baru", h.PetugasFormNew)
+					r.Post("/baru", h.PetugasCreate)
+					r.Get("/edit/{id}", h.PetugasFormEdit)
+					r.Post("/edit/{id}", h.PetugasUpdate)
+					r.Post("/hapus/{id}", h.PetugasDelete)
+				})
+
+				r.Route("/jenis-barang", func(r chi.Router) {
+					r.Get("/", h.JenisBarangList)
+					r.Get("/baru", h.JenisBarangFormNew)
+					r.Post("/baru", h.JenisBarangCreate)
+					r.Get("/edit/{id}", h.JenisBarangFormEdit)
+					r.Post("/edit/{id}", h.JenisBarangUpdate)
+				})
+
+				r.Route("/pengguna", func(r chi.Router) {
+					r.Get("/", h.UserList)
+					r.Get("/baru", h.UserFormNew)
+					r.Post("/baru", h.UserCreate)
+					r.Get("/edit/{id}", h.UserFormEdit)
+					r.Post("/edit/{id}", h.UserUpdate)
+					r.Post("/hapus/{id}", h.UserDelete)
+				})
+
+				r.Route("/pengaturan", func(r chi.Router) {
+					r.Get("/", h.PengaturanForm)
+					r.Post("/", h.PengaturanUpdate)
+					r.Get("/preview-nomor", h.PengaturanPreviewNomor)
+					r.Get("/riwayat", h.PengaturanRiwayat)
+					r.Post("/kantor", h.PengaturanKantorCreate)
+
+					// Cadangan berisi data semua kantor, jadi hanya untuk admin semua kantor
+					r.Group(func(r chi.Router) {
+						r.Use(h.RequireSemuaKantor)
+						r.Post("/backup", h.PengaturanBackup)
+						r.Get("/backup/{nama}", h.PengaturanBackupDownload)
+						r.Post("/restore", h.PengaturanRestore)
+					})
+				})
+			})
+		})
 	})
 
-	go func() {
-		fmt.Println("Server berjalan di http://localhost:8080")
-		if err := http.ListenAndServe(":8080", r); err != nil {
-			log.Fatalf("Gagal menjalankan server: %v", err)
+	var siap func(url string)
+	if cfg.BukaBrowser {
+		siap = func(url string) {
+			slog.Info("Membuka browser...")
+			if err := browser.OpenURL(url); err != nil {
+				slog.Warn("Gagal membuka browser secara otomatis, silakan buka "+url+" secara manual di browser Anda.", "error", err)
+			}
 		}
-	}()
-
-	time.Sleep(1 * time.Second)
-	fmt.Println("Membuka browser...")
-	err = browser.OpenURL("http://localhost:8080")
-	if err != nil {
-		log.Printf("Gagal membuka browser secara otomatis: %v", err)
-		fmt.Println("Silakan buka http://localhost:8080 secara manual di browser Anda.")
 	}
-
-	select {}
+	err = jalankanServer(ctx, cfg.Addr, r, siap)
+	// Sinyal berikutnya langsung menghentikan aplikasi tanpa menunggu database ditutup
+	stop()
+	return err
 }
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"skh_app/internal/config"
+	"skh_app/internal/repository"
+	"skh_app/migrations"
+	"strconv"
+	"text/tabwriter"
+)
+
+const penggunaanMigrate = "penggunaan: skh migrate [flag konfigurasi] status|up|down|to N"
+
+// jalankanMigrate menjalankan subperintah migrate terhadap database di konfigurasi:
+//
+//	status  menampilkan migrasi yang sudah dan belum diterapkan
+//	up      menerapkan semua migrasi yang belum diterapkan
+//	down    membatalkan satu migrasi terakhir (butuh skrip .down.sql)
+//	to N    menaikkan atau menurunkan database sampai versi N
+func jalankanMigrate(cfg *config.Config, args []string) (err error) {
+	if len(args) == 0 {
+		return errors.New(penggunaanMigrate)
+	}
+	db, err := repository.BukaDatabase(cfg.DBPath, cfg.Lokasi)
+	if err != nil {
+		return fmt.Errorf("gagal membuka database: %w", err)
+	}
+	defer func() {
+		if errTutup := repository.CloseDatabase(db); err == nil {
+			err = errTutup
+		}
+	}()
+
+	m, err := repository.NewMigrator(db, migrations.Files)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "status":
+		if len(args) != 1 {
+			return errors.New(penggunaanMigrate)
+		}
+		return tulisStatusMigrasi(os.Stdout, m)
+	case "up", "down":
+		if len(args) != 1 {
+			return errors.New(penggunaanMigrate)
+		}
+		if err := m.Periksa(); err != nil {
+			return err
+		}
+		if args[0] == "up" {
+			err = m.Naik()
+		} else {
+			err = m.Turun()
+		}
+	case "to":
+		if len(args) != 2 {
+			return errors.New(penggunaanMigrate)
+		}
+		target, errAngka := strconv.Atoi(args[1])
+		if errAngka != nil {
+			return fmt.Errorf("versi tujuan %q bukan angka", args[1])
+		}
+		if err := m.Periksa(); err != nil {
+			return err
+		}
+		err = m.Ke(target)
+	default:
+		return fmt.Errorf("perintah migrate %q tidak dikenal; %s", args[0], penggunaanMigrate)
+	}
+	if err != nil {
+		return err
+	}
+
+	versi, err := m.Versi()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Database sekarang di versi %d (terbaru %d)\n", versi, m.Terbaru())
+	return nil
+}
+
+// tulisStatusMigrasi menulis tabel status setiap migrasi
+func tulisStatusMigrasi(w io.Writer, m *repository.Migrator) error {
+	status, err := m.Status()
+	if err != nil {
+		return err
+	}
+	versi, err := m.Versi()
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSI\tNAMA\tSTATUS\tDOWN\tDITERAPKAN")
+	for _, st := range status {
+		keadaan, pada, turun := "belum", "", "-"
+		if st.Diterapkan {
+			keadaan = "diterapkan"
+			if !st.DiterapkanPada.IsZero() {
+				pada = st.DiterapkanPada.Format("2006-01-02 15:04")
+			}
+		}
+		if st.Berubah {
+			keadaan = "DIUBAH"
+		}
+		if st.Turun != "" {
+			turun = "ya"
+		}
+		fmt.Fprintf(tw, "%03d\t%s\t%s\t%s\t%s\n", st.Versi, st.Nama, keadaan, turun, pada)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "\nVersi database: %d, versi terbaru: %d\n", versi, m.Terbaru())
+	return err
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"skh_app/internal/config"
+	"time"
+)
+
+// Batas waktu server HTTP. WriteTimeout cukup longgar untuk ekspor register dan PDF yang besar.
+const (
+	readHeaderTimeout = 10 * time.Second
+	readTimeout       = 30 * time.Second
+	writeTimeout      = 2 * time.Minute
+	idleTimeout       = 2 * time.Minute
+	shutdownTimeout   = 15 * time.Second
+	siapTimeout       = 10 * time.Second
+)
+
+// jalankanServer melayani handler di addr sampai ctx selesai, lalu menolak koneksi baru dan
+// menunggu request yang sedang berjalan selesai (paling lama shutdownTimeout). siap dipanggil
+// di goroutine terpisah dengan URL server setelah server benar-benar menjawab request.
+func jalankanServer(ctx context.Context, addr string, handler http.Handler, siap func(url string)) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("gagal membuka alamat %s: %w", addr, err)
+	}
+	srv := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: readHeaderTimeout,
+		ReadTimeout:       readTimeout,
+		WriteTimeout:      writeTimeout,
+		IdleTimeout:       idleTimeout,
+	}
+
+	gagal := make(chan error, 1)
+	go func() { gagal <- srv.Serve(ln) }()
+
+	url := config.URL(ln.Addr().String())
+	slog.Info("Server berjalan di " + url)
+	if siap != nil {
+		go func() {
+			if err := tungguSiap(ctx, url); err != nil {
+				slog.Warn("Server belum siap", "error", err)
+				return
+			}
+			siap(url)
+		}()
+	}
+
+	select {
+	case err := <-gagal:
+		return fmt.Errorf("server berhenti: %w", err)
+	case <-ctx.Done():
+	}
+
+	slog.Info("Menghentikan server, menunggu request yang sedang berjalan selesai...")
+	tutup, batal := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer batal()
+	if err := srv.Shutdown(tutup); err != nil {
+		srv.Close()
+		return fmt.Errorf("request belum selesai setelah %s dan diputus: %w", shutdownTimeout, err)
+	}
+	return nil
+}
+
+// tungguSiap memanggil /healthz sampai server menjawab 200 atau siapTimeout habis
+func tungguSiap(ctx context.Context, url string) error {
+	ctx, batal := context.WithTimeout(ctx, siapTimeout)
+	defer batal()
+	client := &http.Client{Timeout: time.Second}
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url+"/healthz", nil)
+		if err != nil {
+			return err
+		}
+		if resp, err := client.Do(req); err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
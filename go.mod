@@ -3,8 +3,11 @@ module skh_app
 go 1.24.5
 
 require (
-	github.com/go-chi/chi/v5 v5.2.2 // indirect
-	github.com/mattn/go-sqlite3 v1.14.30 // indirect
-	github.com/pkg/browser v0.0.0-20240102092130-5ac0b6a4141c // indirect
-	golang.org/x/sys v0.1.0 // indirect
+	github.com/go-chi/chi/v5 v5.2.2
+	github.com/jung-kurt/gofpdf v1.16.2
+	github.com/mattn/go-sqlite3 v1.14.30
+	github.com/pkg/browser v0.0.0-20240102092130-5ac0b6a4141c
+	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
 )
+
+require golang.org/x/sys v0.1.0 // indirect
@@ -0,0 +1,263 @@
+// Package config memuat pengaturan server dari nilai bawaan, file konfigurasi JSON,
+// variabel lingkungan dan flag baris perintah (urutan prioritas dari terendah ke tertinggi).
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	// Database zona waktu ikut di-embed karena Windows tidak menyediakannya
+	_ "time/tzdata"
+)
+
+// FileBawaan adalah file konfigurasi yang dibaca bila ada, jika -config dan SKH_CONFIG tidak diisi
+const FileBawaan = "skh.json"
+
+// Config adalah pengaturan server yang tidak disimpan di database
+type Config struct {
+	Addr        string `json:"addr"`         // alamat listen HTTP, mis. ":8080" atau "127.0.0.1:8080"
+	DBPath      string `json:"db"`           // lokasi file database SQLite
+	UploadsDir  string `json:"uploads"`      // folder penyimpanan logo yang diunggah
+	Timezone    string `json:"timezone"`     // nama zona waktu IANA kantor, mis. "Asia/Makassar"
+	BukaBrowser bool   `json:"open_browser"` // buka browser otomatis setelah server berjalan
+	LogLevel    string `json:"log_level"`    // debug, info, warn atau error
+
+	// PublicURL adalah alamat aplikasi yang dibuka pemindai QR code verifikasi, mis.
+	// "https://skh.polres.go.id". Kosong berarti surat dicetak tanpa QR code verifikasi.
+	PublicURL string `json:"public_url"`
+
+	// Cadangan otomatis: folder tujuan, selang waktu ("24h", "0" untuk mematikan) dan jumlah
+	// cadangan harian, mingguan dan bulanan yang disimpan
+	BackupDir      string `json:"backup_dir"`
+	BackupInterval string `json:"backup_interval"`
+	BackupHarian   int    `json:"backup_keep_daily"`
+	BackupMingguan int    `json:"backup_keep_weekly"`
+	BackupBulanan  int    `json:"backup_keep_monthly"`
+
+	// Diisi oleh Load dari Timezone, LogLevel dan BackupInterval
+	Lokasi         *time.Location `json:"-"`
+	Level          slog.Level     `json:"-"`
+	IntervalBackup time.Duration  `json:"-"`
+	// Args adalah argumen sisa setelah flag, mis. "status" pada "skh migrate status"
+	Args []string `json:"-"`
+}
+
+// Bawaan mengembalikan pengaturan yang dipakai bila tidak ada yang diubah
+func Bawaan() Config {
+	return Config{
+		Addr:        ":8080",
+		DBPath:      "skh.db",
+		UploadsDir:  filepath.Join("web", "static", "uploads"),
+		Timezone:    "Asia/Makassar",
+		BukaBrowser: true,
+		LogLevel:    "info",
+
+		BackupDir:      "backup",
+		BackupInterval: "24h",
+		BackupHarian:   7,
+		BackupMingguan: 4,
+		BackupBulanan:  12,
+	}
+}
+
+// Load menyusun Config dari args (tanpa nama program). Nilai bawaan ditimpa isi file konfigurasi,
+// lalu variabel lingkungan SKH_*, lalu flag yang diberikan.
+func Load(args []string) (*Config, error) {
+	cfg := Bawaan()
+
+	fl := flag.NewFlagSet("skh_app", flag.ContinueOnError)
+	var flagCfg Config
+	file := fl.String("config", "", "file konfigurasi JSON (bawaan: "+FileBawaan+" bila ada, atau SKH_CONFIG)")
+	fl.StringVar(&flagCfg.Addr, "addr", cfg.Addr, "alamat listen HTTP (SKH_ADDR)")
+	fl.StringVar(&flagCfg.DBPath, "db", cfg.DBPath, "lokasi file database SQLite (SKH_DB)")
+	fl.StringVar(&flagCfg.UploadsDir, "uploads", cfg.UploadsDir, "folder logo yang diunggah (SKH_UPLOADS)")
+	fl.StringVar(&flagCfg.Timezone, "timezone", cfg.Timezone, "zona waktu kantor (SKH_TIMEZONE)")
+	fl.BoolVar(&flagCfg.BukaBrowser, "open-browser", cfg.BukaBrowser, "buka browser otomatis (SKH_OPEN_BROWSER)")
+	fl.StringVar(&flagCfg.LogLevel, "log-level", cfg.LogLevel, "tingkat log: debug, info, warn, error (SKH_LOG_LEVEL)")
+	fl.StringVar(&flagCfg.PublicURL, "public-url", cfg.PublicURL, "alamat publik untuk QR code verifikasi, mis. https://skh.example.go.id (SKH_PUBLIC_URL)")
+	fl.StringVar(&flagCfg.BackupDir, "backup-dir", cfg.BackupDir, "folder cadangan database dan uploads (SKH_BACKUP_DIR)")
+	fl.StringVar(&flagCfg.BackupInterval, "backup-interval", cfg.BackupInterval, "selang cadangan otomatis, 0 untuk mematikan (SKH_BACKUP_INTERVAL)")
+	fl.IntVar(&flagCfg.BackupHarian, "backup-keep-daily", cfg.BackupHarian, "jumlah cadangan harian yang disimpan (SKH_BACKUP_KEEP_DAILY)")
+	fl.IntVar(&flagCfg.BackupMingguan, "backup-keep-weekly", cfg.BackupMingguan, "jumlah cadangan mingguan yang disimpan (SKH_BACKUP_KEEP_WEEKLY)")
+	fl.IntVar(&flagCfg.BackupBulanan, "backup-keep-monthly", cfg.BackupBulanan, "jumlah cadangan bulanan yang disimpan (SKH_BACKUP_KEEP_MONTHLY)")
+	if err := fl.Parse(args); err != nil {
+		return nil, err
+	}
+
+	// 1. File konfigurasi. File bawaan boleh tidak ada, file yang disebut eksplisit wajib ada.
+	path, wajib := *file, true
+	if path == "" {
+		path = os.Getenv("SKH_CONFIG")
+	}
+	if path == "" {
+		path, wajib = FileBawaan, false
+	}
+	if err := cfg.bacaFile(path, wajib); err != nil {
+		return nil, err
+	}
+
+	// 2. Variabel lingkungan
+	if err := cfg.bacaEnv(); err != nil {
+		return nil, err
+	}
+
+	// 3. Flag yang benar-benar diberikan
+	fl.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "addr":
+			cfg.Addr = flagCfg.Addr
+		case "db":
+			cfg.DBPath = flagCfg.DBPath
+		case "uploads":
+			cfg.UploadsDir = flagCfg.UploadsDir
+		case "timezone":
+			cfg.Timezone = flagCfg.Timezone
+		case "open-browser":
+			cfg.BukaBrowser = flagCfg.BukaBrowser
+		case "log-level":
+			cfg.LogLevel = flagCfg.LogLevel
+		case "public-url":
+			cfg.PublicURL = flagCfg.PublicURL
+		case "backup-dir":
+			cfg.BackupDir = flagCfg.BackupDir
+		case "backup-interval":
+			cfg.BackupInterval = flagCfg.BackupInterval
+		case "backup-keep-daily":
+			cfg.BackupHarian = flagCfg.BackupHarian
+		case "backup-keep-weekly":
+			cfg.BackupMingguan = flagCfg.BackupMingguan
+		case "backup-keep-monthly":
+			cfg.BackupBulanan = flagCfg.BackupBulanan
+		}
+	})
+
+	if err := cfg.lengkapi(); err != nil {
+		return nil, err
+	}
+	cfg.Args = fl.Args()
+	return &cfg, nil
+}
+
+func (c *Config) bacaFile(path string, wajib bool) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && !wajib {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("gagal membaca file konfigurasi: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(c); err != nil {
+		return fmt.Errorf("file konfigurasi %s tidak valid: %w", path, err)
+	}
+	return nil
+}
+
+func (c *Config) bacaEnv() error {
+	teks := map[string]*string{
+		"SKH_ADDR":       &c.Addr,
+		"SKH_DB":         &c.DBPath,
+		"SKH_UPLOADS":    &c.UploadsDir,
+		"SKH_TIMEZONE":   &c.Timezone,
+		"SKH_LOG_LEVEL":  &c.LogLevel,
+		"SKH_PUBLIC_URL": &c.PublicURL,
+
+		"SKH_BACKUP_DIR":      &c.BackupDir,
+		"SKH_BACKUP_INTERVAL": &c.BackupInterval,
+	}
+	for nama, tujuan := range teks {
+		if v, ok := os.LookupEnv(nama); ok {
+			*tujuan = v
+		}
+	}
+	if v, ok := os.LookupEnv("SKH_OPEN_BROWSER"); ok {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("SKH_OPEN_BROWSER harus true atau false: %q", v)
+		}
+		c.BukaBrowser = b
+	}
+	angka := map[string]*int{
+		"SKH_BACKUP_KEEP_DAILY":   &c.BackupHarian,
+		"SKH_BACKUP_KEEP_WEEKLY":  &c.BackupMingguan,
+		"SKH_BACKUP_KEEP_MONTHLY": &c.BackupBulanan,
+	}
+	for nama, tujuan := range angka {
+		if v, ok := os.LookupEnv(nama); ok {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return fmt.Errorf("%s harus berupa angka: %q", nama, v)
+			}
+			*tujuan = n
+		}
+	}
+	return nil
+}
+
+// lengkapi memeriksa nilai akhir lalu mengisi Lokasi dan Level
+func (c *Config) lengkapi() error {
+	if strings.TrimSpace(c.Addr) == "" {
+		return errors.New("alamat listen (addr) tidak boleh kosong")
+	}
+	if strings.TrimSpace(c.DBPath) == "" {
+		return errors.New("lokasi database (db) tidak boleh kosong")
+	}
+	if strings.TrimSpace(c.UploadsDir) == "" {
+		return errors.New("folder uploads tidak boleh kosong")
+	}
+
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return fmt.Errorf("zona waktu %q tidak dikenal: %w", c.Timezone, err)
+	}
+	c.Lokasi = loc
+
+	if err := c.Level.UnmarshalText([]byte(c.LogLevel)); err != nil {
+		return fmt.Errorf("tingkat log %q tidak dikenal, pilih debug, info, warn atau error", c.LogLevel)
+	}
+
+	c.PublicURL = strings.TrimRight(strings.TrimSpace(c.PublicURL), "/")
+	if c.PublicURL != "" {
+		u, err := url.Parse(c.PublicURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+			return fmt.Errorf("alamat publik %q tidak valid, gunakan mis. \"https://skh.example.go.id\"", c.PublicURL)
+		}
+	}
+
+	if strings.TrimSpace(c.BackupDir) == "" {
+		return errors.New("folder cadangan (backup_dir) tidak boleh kosong")
+	}
+	if c.BackupInterval == "0" {
+		c.IntervalBackup = 0
+	} else if c.IntervalBackup, err = time.ParseDuration(c.BackupInterval); err != nil || c.IntervalBackup < time.Minute {
+		return fmt.Errorf("selang cadangan %q tidak valid, gunakan mis. \"24h\", \"6h\" atau \"0\" untuk mematikan (minimal 1m)", c.BackupInterval)
+	}
+	if c.BackupHarian < 0 || c.BackupMingguan < 0 || c.BackupBulanan < 0 {
+		return errors.New("jumlah cadangan yang disimpan tidak boleh negatif")
+	}
+	return nil
+}
+
+// URL adalah alamat yang dibuka di browser untuk alamat listen addr, mis. "[::]:8080"
+func URL(addr string) string {
+	host, port := addr, ""
+	if i := strings.LastIndex(addr, ":"); i >= 0 {
+		host, port = addr[:i], addr[i:]
+	}
+	if host == "" || host == "0.0.0.0" || host == "[::]" {
+		host = "localhost"
+	}
+	return "http://" + host + port
+}
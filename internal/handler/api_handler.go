@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"mime"
+	"net/http"
+	"skh_app/internal/model"
+	"skh_app/internal/service"
+	"skh_app/internal/validasi"
+	"skh_app/web"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Kode error yang dikembalikan API di field error.code
+const (
+	apiErrTidakValid          = "tidak_valid"
+	apiErrTidakTerautentikasi = "tidak_terautentikasi"
+	apiErrAksesDitolak        = "akses_ditolak"
+	apiErrTidakDitemukan      = "tidak_ditemukan"
+	apiErrKesalahanServer     = "kesalahan_server"
+)
+
+// apiError adalah bentuk body untuk semua respons error API: {"error": {"code": ..., "message": ...}}.
+// Fields berisi pesan per field bila kesalahan berasal dari validasi isian.
+type apiError struct {
+	Code    string            `json:"code"`
+	Message string            `json:"message"`
+	Fields  map[string]string `json:"fields,omitempty"`
+}
+
+// writeJSON menulis v sebagai JSON dengan status code tertentu
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Gagal menulis respons JSON: %v", err)
+	}
+}
+
+// writeJSONError menulis body error API yang seragam
+func writeJSONError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, map[string]apiError{"error": {Code: code, Message: message}})
+}
+
+// writeServiceError menulis error dari service sebagai 400; error validasi disertai pesan per field
+func writeServiceError(w http.ResponseWriter, err error) {
+	e := apiError{Code: apiErrTidakValid, Message: err.Error()}
+	var fe validasi.Errors
+	if errors.As(err, &fe) {
+		e.Fields = fe
+	}
+	writeJSON(w, http.StatusBadRequest, map[string]apiError{"error": e})
+}
+
+// decodeJSON membaca body JSON ke v. Content-Type wajib application/json, sehingga form lintas situs
+// (CSRF) tidak dapat memanggil endpoint API yang memakai cookie sesi.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mt != "application/json" {
+		writeJSONError(w, http.StatusUnsupportedMediaType, apiErrTidakValid, "Content-Type harus application/json")
+		return false
+	}
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		writeJSONError(w, http.StatusBadRequest, apiErrTidakValid, "Body JSON tidak valid: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// apiID membaca parameter {id} dari URL
+func apiID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		writeJSONError(w, http.StatusBadRequest, apiErrTidakValid, "ID tidak valid")
+		return 0, false
+	}
+	return id, true
+}
+
+// APIRequireLogin sama dengan RequireLogin tetapi menjawab 401 JSON, bukan redirect ke halaman login.
+// User yang wajib mengganti password hanya boleh memanggil /me dan /logout sampai password-nya
+// diganti lewat halaman web.
+func (h *Handler) APIRequireLogin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := CurrentUser(r)
+		if user == nil {
+			writeJSONError(w, http.StatusUnauthorized, apiErrTidakTerautentikasi, "Silakan login terlebih dahulu")
+			return
+		}
+		if user.WajibGantiPassword && r.URL.Path != "/api/v1/me" && r.URL.Path != "/api/v1/logout" {
+			writeJSONError(w, http.StatusForbidden, apiErrAksesDitolak, "Password wajib diganti terlebih dahulu di halaman "+jalurGantiPassword)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// APIRequireRole sama dengan RequireRole tetapi menjawab 403 JSON
+func (h *Handler) APIRequireRole(role string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !CurrentUser(r).HasRole(role) {
+				writeJSONError(w, http.StatusForbidden, apiErrAksesDitolak, "Endpoint ini membutuhkan peran "+role)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// APINotFound menjawab route /api yang tidak dikenal dengan error JSON
+func (h *Handler) APINotFound(w http.ResponseWriter, r *http.Request) {
+	writeJSONError(w, http.StatusNotFound, apiErrTidakDitemukan, "Endpoint tidak ditemukan")
+}
+
+// APIMethodNotAllowed menjawab method yang tidak didukung dengan error JSON
+func (h *Handler) APIMethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	writeJSONError(w, http.StatusMethodNotAllowed, apiErrTidakValid, "Method tidak didukung untuk endpoint ini")
+}
+
+// APIOpenAPI mengirim dokumen OpenAPI yang ikut dikompilasi ke dalam binary
+func (h *Handler) APIOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/yaml; charset=utf-8")
+	w.Write(web.OpenAPI)
+}
+
+// --- AUTENTIKASI ---
+
+type apiLoginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type apiUser struct {
+	ID          int    `json:"id"`
+	Username    string `json:"username"`
+	NamaLengkap string `json:"nama_lengkap"`
+	Role        string `json:"role"`
+	KantorID    int    `json:"kantor_id"` // 0 berarti dapat mengakses semua kantor
+
+	WajibGantiPassword bool `json:"wajib_ganti_password"`
+}
+
+func toAPIUser(u *model.User) apiUser {
+	return apiUser{ID: u.ID, Username: u.Username, NamaLengkap: u.NamaLengkap, Role: u.Role, KantorID: u.KantorID,
+		WajibGantiPassword: u.WajibGantiPassword}
+}
+
+// APILogin menukar username dan password dengan token sesi untuk header Authorization: Bearer
+func (h *Handler) APILogin(w http.ResponseWriter, r *http.Request) {
+	var req apiLoginRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	token, user, err := h.AuthService.Login(req.Username, req.Password)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidLogin) {
+			writeJSONError(w, http.StatusUnauthorized, apiErrTidakTerautentikasi, err.Error())
+			return
+		}
+		log.Printf("Gagal login API: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, apiErrKesalahanServer, "Gagal login")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"token":      token,
+		"expires_at": time.Now().Add(service.SessionDuration),
+		"user":       toAPIUser(user),
+	})
+}
+
+// APILogout mengakhiri sesi milik token yang dipakai request ini
+func (h *Handler) APILogout(w http.ResponseWriter, r *http.Request) {
+	if err := h.AuthService.Logout(sessionToken(r)); err != nil {
+		log.Printf("Gagal menghapus sesi: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, apiErrKesalahanServer, "Gagal logout")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// APIMe mengembalikan user pemilik token
+func (h *Handler) APIMe(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, toAPIUser(CurrentUser(r)))
+}
@@ -0,0 +1,393 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"skh_app/internal/model"
+	"strings"
+)
+
+// --- PETUGAS ---
+
+type apiPetugas struct {
+	ID       int    `json:"id"`
+	Nama     string `json:"nama"`
+	Pangkat  string `json:"pangkat"`
+	NRP      string `json:"nrp"`
+	Jabatan  string `json:"jabatan"`
+	Tipe     string `json:"tipe"`
+	KantorID int    `json:"kantor_id"` // 0 berarti bertugas di semua kantor
+}
+
+func toAPIPetugas(p *model.Petugas) apiPetugas {
+	return apiPetugas{ID: p.ID, Nama: p.Nama, Pangkat: p.Pangkat, NRP: p.NRP, Jabatan: p.Jabatan, Tipe: p.Tipe, KantorID: p.KantorID}
+}
+
+// validatePetugas memeriksa field wajib petugas dari body API
+func validatePetugas(p *apiPetugas) string {
+	p.Nama = strings.TrimSpace(p.Nama)
+	if p.Nama == "" {
+		return "nama wajib diisi"
+	}
+	if p.Tipe != "Pejabat" && p.Tipe != "Penerima" {
+		return "tipe harus Pejabat atau Penerima"
+	}
+	return ""
+}
+
+// getPetugasOr404 mengambil petugas yang terlihat oleh pengguna (lihat petugasPengguna) dan menulis
+// error 403/404/500 jika gagal. ubah dipakai untuk request yang mengubah atau menghapus petugas.
+func (h *Handler) getPetugasOr404(w http.ResponseWriter, r *http.Request, id int, ubah bool) (*model.Petugas, bool) {
+	p, err := h.petugasPengguna(r, id, ubah)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeJSONError(w, http.StatusNotFound, apiErrTidakDitemukan, "Petugas tidak ditemukan")
+		return nil, false
+	}
+	if errors.Is(err, errPetugasSemuaKantor) {
+		writeJSONError(w, http.StatusForbidden, apiErrAksesDitolak, "Akses ditolak: "+err.Error())
+		return nil, false
+	}
+	if err != nil {
+		log.Printf("Gagal mengambil petugas %d: %v", id, err)
+		writeJSONError(w, http.StatusInternalServerError, apiErrKesalahanServer, "Gagal mengambil petugas")
+		return nil, false
+	}
+	return p, true
+}
+
+// APIPetugasList mengembalikan semua petugas, atau petugas kantor ?kantor= beserta petugas semua kantor
+func (h *Handler) APIPetugasList(w http.ResponseWriter, r *http.Request) {
+	kantorID, err := kantorDipilih(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, apiErrTidakValid, err.Error())
+		return
+	}
+	list, err := h.Repo.GetAllPetugas(kantorID)
+	if err != nil {
+		log.Printf("Gagal mengambil petugas: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, apiErrKesalahanServer, "Gagal mengambil data petugas")
+		return
+	}
+	out := make([]apiPetugas, 0, len(list))
+	for i := range list {
+		out = append(out, toAPIPetugas(&list[i]))
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": out})
+}
+
+// APIPetugasGet mengembalikan satu petugas
+func (h *Handler) APIPetugasGet(w http.ResponseWriter, r *http.Request) {
+	id, ok := apiID(w, r)
+	if !ok {
+		return
+	}
+	p, ok := h.getPetugasOr404(w, r, id, false)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, toAPIPetugas(p))
+}
+
+// APIPetugasCreate menambah petugas (admin)
+func (h *Handler) APIPetugasCreate(w http.ResponseWriter, r *http.Request) {
+	var req apiPetugas
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if msg := validatePetugas(&req); msg != "" {
+		writeJSONError(w, http.StatusBadRequest, apiErrTidakValid, msg)
+		return
+	}
+	p := &model.Petugas{Nama: req.Nama, Pangkat: req.Pangkat, NRP: req.NRP, Jabatan: req.Jabatan, Tipe: req.Tipe, KantorID: kantorIsian(r, req.KantorID)}
+	if err := h.Repo.CreatePetugas(p, actorName(r)); err != nil {
+		log.Printf("Gagal menyimpan petugas: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, apiErrKesalahanServer, "Gagal menyimpan data petugas")
+		return
+	}
+	writeJSON(w, http.StatusCreated, toAPIPetugas(p))
+}
+
+// APIPetugasUpdate mengganti data petugas (admin)
+func (h *Handler) APIPetugasUpdate(w http.ResponseWriter, r *http.Request) {
+	id, ok := apiID(w, r)
+	if !ok {
+		return
+	}
+	var req apiPetugas
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if msg := validatePetugas(&req); msg != "" {
+		writeJSONError(w, http.StatusBadRequest, apiErrTidakValid, msg)
+		return
+	}
+	if _, ok := h.getPetugasOr404(w, r, id, true); !ok {
+		return
+	}
+	p := &model.Petugas{ID: id, Nama: req.Nama, Pangkat: req.Pangkat, NRP: req.NRP, Jabatan: req.Jabatan, Tipe: req.Tipe, KantorID: kantorIsian(r, req.KantorID)}
+	if err := h.Repo.UpdatePetugas(p, actorName(r)); err != nil {
+		log.Printf("Gagal mengupdate petugas %d: %v", id, err)
+		writeJSONError(w, http.StatusInternalServerError, apiErrKesalahanServer, "Gagal mengupdate data petugas")
+		return
+	}
+	writeJSON(w, http.StatusOK, toAPIPetugas(p))
+}
+
+// APIPetugasDelete menghapus petugas (admin)
+func (h *Handler) APIPetugasDelete(w http.ResponseWriter, r *http.Request) {
+	id, ok := apiID(w, r)
+	if !ok {
+		return
+	}
+	if _, ok := h.getPetugasOr404(w, r, id, true); !ok {
+		return
+	}
+	if err := h.Repo.DeletePetugas(id, actorName(r)); err != nil {
+		log.Printf("Gagal menghapus petugas %d: %v", id, err)
+		writeJSONError(w, http.StatusConflict, apiErrTidakValid, "Petugas tidak dapat dihapus, kemungkinan masih dipakai di pengaturan")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// --- JENIS BARANG ---
+
+type apiFieldBarang struct {
+	Kunci       string   `json:"kunci"`
+	Label       string   `json:"label"`
+	Tipe        string   `json:"tipe"`
+	Wajib       bool     `json:"wajib"`
+	Validasi    string   `json:"validasi,omitempty"`
+	Pola        string   `json:"pola,omitempty"`
+	Pilihan     []string `json:"pilihan,omitempty"`
+	MaksPanjang int      `json:"maks_panjang,omitempty"`
+}
+
+type apiJenisBarang struct {
+	Nama       string           `json:"nama"`
+	Fields     []apiFieldBarang `json:"fields"`
+	FrasaCetak string           `json:"frasa_cetak"`
+}
+
+// APIJenisBarangList mengembalikan jenis barang aktif beserta isiannya, agar klien dapat menyusun
+// field data untuk barang_hilang
+func (h *Handler) APIJenisBarangList(w http.ResponseWriter, r *http.Request) {
+	list, err := h.BarangService.JenisUntukForm(nil)
+	if err != nil {
+		log.Printf("Gagal mengambil jenis barang: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, apiErrKesalahanServer, "Gagal mengambil jenis barang")
+		return
+	}
+	out := make([]apiJenisBarang, 0, len(list))
+	for _, j := range list {
+		item := apiJenisBarang{Nama: j.Nama, FrasaCetak: j.FrasaCetak, Fields: make([]apiFieldBarang, 0, len(j.Fields))}
+		for _, f := range j.Fields {
+			item.Fields = append(item.Fields, apiFieldBarang{
+				Kunci: f.Kunci, Label: f.Label, Tipe: f.Tipe, Wajib: f.Wajib,
+				Validasi: f.Validasi, Pola: f.Pola, Pilihan: f.Pilihan, MaksPanjang: f.MaksPanjang,
+			})
+		}
+		out = append(out, item)
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": out})
+}
+
+// --- PENGATURAN ---
+
+type apiPengaturan struct {
+	KantorID         int         `json:"kantor_id"`
+	KopSurat1        string      `json:"kop_surat_1"`
+	KopSurat2        string      `json:"kop_surat_2"`
+	KopSurat3        string      `json:"kop_surat_3"`
+	LogoPath         string      `json:"logo_path"`
+	Wilayah          string      `json:"wilayah"`
+	NamaKantor       string      `json:"nama_kantor"`
+	KodeKantor       string      `json:"kode_kantor"`
+	FormatNomorSurat string      `json:"format_nomor_surat"`
+	ResetNomor       string      `json:"reset_nomor"`
+	NomorTerakhir    int         `json:"nomor_terakhir"`
+	NomorBerikutnya  string      `json:"nomor_berikutnya,omitempty"`
+	MasaBerlakuHari  int         `json:"masa_berlaku_hari"`
+	BatasBerulang    int         `json:"batas_laporan_berulang"`
+	PeriodeBerulang  int         `json:"periode_laporan_berulang"`
+	BatasUbahJam     int         `json:"batas_ubah_jam"`
+	Pejabat          *apiPetugas `json:"pejabat"`
+	Penerima         *apiPetugas `json:"penerima"`
+}
+
+// apiPengaturanRequest memakai pointer agar hanya field yang dikirim yang diubah
+type apiPengaturanRequest struct {
+	KopSurat1        *string `json:"kop_surat_1"`
+	KopSurat2        *string `json:"kop_surat_2"`
+	KopSurat3        *string `json:"kop_surat_3"`
+	Wilayah          *string `json:"wilayah"`
+	NamaKantor       *string `json:"nama_kantor"`
+	KodeKantor       *string `json:"kode_kantor"`
+	FormatNomorSurat *string `json:"format_nomor_surat"`
+	ResetNomor       *string `json:"reset_nomor"`
+	NomorTerakhir    *int    `json:"nomor_terakhir"`
+	MasaBerlakuHari  *int    `json:"masa_berlaku_hari"`
+	BatasBerulang    *int    `json:"batas_laporan_berulang"`
+	PeriodeBerulang  *int    `json:"periode_laporan_berulang"`
+	BatasUbahJam     *int    `json:"batas_ubah_jam"`
+	PejabatID        *int    `json:"pejabat_id"`
+	PenerimaID       *int    `json:"penerima_id"`
+}
+
+func (h *Handler) toAPIPengaturan(p *model.Pengaturan) apiPengaturan {
+	out := apiPengaturan{
+		KantorID:         p.ID,
+		KopSurat1:        p.KopSurat1,
+		KopSurat2:        p.KopSurat2,
+		KopSurat3:        p.KopSurat3,
+		LogoPath:         p.LogoPath,
+		Wilayah:          p.Wilayah,
+		NamaKantor:       p.NamaKantor,
+		KodeKantor:       p.KodeKantor,
+		FormatNomorSurat: p.FormatNomorSurat,
+		ResetNomor:       p.ResetNomor,
+		MasaBerlakuHari:  p.MasaBerlakuHari,
+		BatasBerulang:    p.BatasLaporanBerulang,
+		PeriodeBerulang:  p.PeriodeLaporanBerulang,
+		BatasUbahJam:     p.BatasUbahJam,
+	}
+	out.NomorTerakhir, _ = h.PengaturanService.NomorTerakhir(p)
+	out.NomorBerikutnya, _ = h.PengaturanService.PreviewNomor(p.ID, p.FormatNomorSurat, p.ResetNomor, p.KodeKantor)
+	if p.PejabatID != 0 && p.PejabatDetails != nil {
+		pejabat := toAPIPetugas(p.PejabatDetails)
+		out.Pejabat = &pejabat
+	}
+	if p.PenerimaID != 0 && p.PenerimaDetails != nil {
+		penerima := toAPIPetugas(p.PenerimaDetails)
+		out.Penerima = &penerima
+	}
+	return out
+}
+
+// pengaturanAPI mengambil pengaturan kantor ?kantor= (bawaan kantor pengguna atau kantor utama) dan
+// menulis error 400/404/500 jika gagal
+func (h *Handler) pengaturanAPI(w http.ResponseWriter, r *http.Request) (*model.Pengaturan, bool) {
+	kantorID, err := kantorDipilih(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, apiErrTidakValid, err.Error())
+		return nil, false
+	}
+	if kantorID == 0 {
+		kantorID = model.KantorUtama
+	}
+	p, err := h.Repo.GetPengaturan(kantorID)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeJSONError(w, http.StatusNotFound, apiErrTidakDitemukan, "Kantor tidak ditemukan")
+		return nil, false
+	}
+	if err != nil {
+		log.Printf("Gagal mengambil pengaturan kantor %d: %v", kantorID, err)
+		writeJSONError(w, http.StatusInternalServerError, apiErrKesalahanServer, "Gagal mengambil pengaturan")
+		return nil, false
+	}
+	return p, true
+}
+
+// APIPengaturanGet mengembalikan pengaturan kantor ?kantor=
+func (h *Handler) APIPengaturanGet(w http.ResponseWriter, r *http.Request) {
+	p, ok := h.pengaturanAPI(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, h.toAPIPengaturan(p))
+}
+
+// APIPengaturanUpdate mengubah sebagian pengaturan (admin). Logo hanya dapat diganti lewat halaman pengaturan.
+func (h *Handler) APIPengaturanUpdate(w http.ResponseWriter, r *http.Request) {
+	var req apiPengaturanRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	p, ok := h.pengaturanAPI(w, r)
+	if !ok {
+		return
+	}
+
+	setString := func(dst *string, src *string) {
+		if src != nil {
+			*dst = *src
+		}
+	}
+	setString(&p.KopSurat1, req.KopSurat1)
+	setString(&p.KopSurat2, req.KopSurat2)
+	setString(&p.KopSurat3, req.KopSurat3)
+	setString(&p.Wilayah, req.Wilayah)
+	setString(&p.NamaKantor, req.NamaKantor)
+	setString(&p.KodeKantor, req.KodeKantor)
+	setString(&p.FormatNomorSurat, req.FormatNomorSurat)
+	setString(&p.ResetNomor, req.ResetNomor)
+	if req.MasaBerlakuHari != nil {
+		p.MasaBerlakuHari = *req.MasaBerlakuHari
+	}
+	if req.BatasBerulang != nil {
+		p.BatasLaporanBerulang = *req.BatasBerulang
+	}
+	if req.PeriodeBerulang != nil {
+		p.PeriodeLaporanBerulang = *req.PeriodeBerulang
+	}
+	if req.BatasUbahJam != nil {
+		p.BatasUbahJam = *req.BatasUbahJam
+	}
+	if req.PejabatID != nil {
+		p.PejabatID = *req.PejabatID
+	}
+	if req.PenerimaID != nil {
+		p.PenerimaID = *req.PenerimaID
+	}
+	if req.NomorTerakhir != nil {
+		p.LastNomorSurat = *req.NomorTerakhir
+		p.UbahNomorTerakhir = true
+	}
+
+	if _, err := h.PengaturanService.UpdatePengaturan(p, nil, nil, actorName(r)); err != nil {
+		writeJSONError(w, http.StatusBadRequest, apiErrTidakValid, err.Error())
+		return
+	}
+	h.APIPengaturanGet(w, r)
+}
+
+// --- DASHBOARD ---
+
+type apiJumlah struct {
+	Label string `json:"label"`
+	Total int    `json:"total"`
+}
+
+// APIDashboard mengembalikan statistik yang sama dengan halaman dashboard untuk kantor ?kantor=
+func (h *Handler) APIDashboard(w http.ResponseWriter, r *http.Request) {
+	kantorID, err := kantorDipilih(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, apiErrTidakValid, err.Error())
+		return
+	}
+	d, err := h.SuratService.GetDashboardData(kantorID)
+	if err != nil {
+		log.Printf("Gagal memuat dashboard: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, apiErrKesalahanServer, "Gagal memuat data dashboard")
+		return
+	}
+	toJumlah := func(labels []string, data []int) []apiJumlah {
+		out := make([]apiJumlah, 0, len(labels))
+		for i, l := range labels {
+			if i < len(data) {
+				out = append(out, apiJumlah{Label: l, Total: data[i]})
+			}
+		}
+		return out
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"total_surat":       d.TotalSurat,
+		"total_bulan_ini":   d.TotalBulanIni,
+		"total_berlaku":     d.TotalBerlaku,
+		"total_kedaluwarsa": d.TotalKedaluwarsa,
+		"barang_hilang":     toJumlah(d.StatLabels, d.StatData),
+		"surat_harian":      toJumlah(d.HarianLabels, d.HarianData),
+	})
+}
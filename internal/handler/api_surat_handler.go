@@ -0,0 +1,285 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"skh_app/internal/model"
+	"skh_app/internal/repository"
+	"strconv"
+	"time"
+)
+
+// apiBarang adalah bentuk JSON satu barang hilang. Data berisi field sesuai jenis barang.
+type apiBarang struct {
+	JenisBarang string          `json:"jenis_barang"`
+	Data        json.RawMessage `json:"data"`
+}
+
+// apiSurat adalah bentuk JSON surat di API. Dibuat terpisah dari model agar nama field di
+// audit log tidak ikut berubah dan data yang dikirim ke klien bisa dibatasi.
+type apiSurat struct {
+	ID               int         `json:"id"`
+	NomorSurat       string      `json:"nomor_surat"`
+	KantorID         int         `json:"kantor_id"`
+	Revisi           int         `json:"revisi"`
+	TanggalSurat     time.Time   `json:"tanggal_surat"`
+	BerlakuSampai    string      `json:"berlaku_sampai,omitempty"` // YYYY-MM-DD, hari terakhir berlaku
+	Status           string      `json:"status"`
+	Kedaluwarsa      bool        `json:"kedaluwarsa"`
+	PelaporID        int         `json:"pelapor_id,omitempty"`
+	PelaporNIK       string      `json:"pelapor_nik,omitempty"`
+	PelaporNama      string      `json:"pelapor_nama"`
+	PelaporTTL       string      `json:"pelapor_ttl,omitempty"`
+	PelaporAgama     string      `json:"pelapor_agama,omitempty"`
+	PelaporKelamin   string      `json:"pelapor_kelamin,omitempty"`
+	PelaporPekerjaan string      `json:"pelapor_pekerjaan,omitempty"`
+	PelaporAlamat    string      `json:"pelapor_alamat,omitempty"`
+	LokasiHilang     string      `json:"lokasi_hilang,omitempty"`
+	BarangHilang     []apiBarang `json:"barang_hilang,omitempty"`
+	SuratAsalID      int         `json:"surat_asal_id,omitempty"`
+	PerpanjanganID   int         `json:"perpanjangan_id,omitempty"`
+	TandaBerulang    string      `json:"tanda_berulang,omitempty"`
+	Pejabat          *apiPetugas `json:"pejabat,omitempty"`
+	Penerima         *apiPetugas `json:"penerima,omitempty"`
+	AlasanBatal      string      `json:"alasan_batal,omitempty"`
+	DibatalkanOleh   string      `json:"dibatalkan_oleh,omitempty"`
+	DibatalkanPada   *time.Time  `json:"dibatalkan_pada,omitempty"`
+	VerifikasiURL    string      `json:"verifikasi_url,omitempty"`
+}
+
+func toAPISurat(s *model.SuratKeteranganHilang) apiSurat {
+	out := apiSurat{
+		ID:               s.ID,
+		NomorSurat:       s.NomorSurat,
+		KantorID:         s.KantorID,
+		Revisi:           s.Revisi,
+		TanggalSurat:     s.TanggalSurat,
+		Status:           s.Status,
+		Kedaluwarsa:      s.Kedaluwarsa,
+		PelaporID:        s.PelaporID,
+		PelaporNIK:       s.PelaporNIK,
+		PelaporNama:      s.PelaporNama,
+		PelaporTTL:       s.PelaporTTL,
+		PelaporAgama:     s.PelaporAgama,
+		PelaporKelamin:   s.PelaporKelamin,
+		PelaporPekerjaan: s.PelaporPekerjaan,
+		PelaporAlamat:    s.PelaporAlamat,
+		LokasiHilang:     s.LokasiHilang,
+		SuratAsalID:      s.SuratAsalID,
+		PerpanjanganID:   s.PerpanjanganID,
+		TandaBerulang:    s.TandaBerulang,
+		AlasanBatal:      s.AlasanBatal,
+		DibatalkanOleh:   s.DibatalkanOleh,
+	}
+	// Petugas seperti tercatat saat surat terbit, bukan data petugas saat ini
+	if s.Pejabat.Nama != "" {
+		pejabat := toAPIPetugas(&s.Pejabat)
+		pejabat.Tipe = "Pejabat"
+		out.Pejabat = &pejabat
+	}
+	if s.Penerima.Nama != "" {
+		penerima := toAPIPetugas(&s.Penerima)
+		penerima.Tipe = "Penerima"
+		out.Penerima = &penerima
+	}
+	if !s.BerlakuSampai.IsZero() {
+		out.BerlakuSampai = s.BerlakuSampai.Format("2006-01-02")
+	}
+	if s.IsDibatalkan() {
+		pada := s.DibatalkanPada
+		out.DibatalkanPada = &pada
+	}
+	for _, b := range s.BarangHilang {
+		data := json.RawMessage(b.Data)
+		if !json.Valid(data) {
+			data = json.RawMessage("{}")
+		}
+		out.BarangHilang = append(out.BarangHilang, apiBarang{JenisBarang: b.JenisBarang, Data: data})
+	}
+	return out
+}
+
+// apiSuratRequest adalah body untuk membuat surat baru
+type apiSuratRequest struct {
+	PelaporNIK       string      `json:"pelapor_nik"`
+	PelaporNama      string      `json:"pelapor_nama"`
+	PelaporTTL       string      `json:"pelapor_ttl"`
+	PelaporAgama     string      `json:"pelapor_agama"`
+	PelaporKelamin   string      `json:"pelapor_kelamin"`
+	PelaporPekerjaan string      `json:"pelapor_pekerjaan"`
+	PelaporAlamat    string      `json:"pelapor_alamat"`
+	LokasiHilang     string      `json:"lokasi_hilang"`
+	BarangHilang     []apiBarang `json:"barang_hilang"`
+	PejabatID        int         `json:"pejabat_id"`  // 0 berarti pejabat dari pengaturan
+	PenerimaID       int         `json:"penerima_id"` // 0 berarti penerima dari pengaturan
+	KantorID         int         `json:"kantor_id"`   // 0 berarti kantor pengguna atau kantor utama
+}
+
+type apiCancelRequest struct {
+	Alasan    string `json:"alasan"`
+	PetugasID int    `json:"petugas_id"`
+}
+
+// getSuratOr404 mengambil surat dan menulis error 404/500 jika gagal
+func (h *Handler) getSuratOr404(w http.ResponseWriter, id int) (*model.SuratKeteranganHilang, bool) {
+	surat, err := h.Repo.GetSuratByID(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeJSONError(w, http.StatusNotFound, apiErrTidakDitemukan, "Surat tidak ditemukan")
+		return nil, false
+	}
+	if err != nil {
+		log.Printf("Gagal mengambil surat %d: %v", id, err)
+		writeJSONError(w, http.StatusInternalServerError, apiErrKesalahanServer, "Gagal mengambil surat")
+		return nil, false
+	}
+	return surat, true
+}
+
+// APISuratList mengembalikan satu halaman daftar surat. Filter: q, status (aktif, dibatalkan, berlaku,
+// kedaluwarsa), dari dan sampai (YYYY-MM-DD, inklusif), jenis, penerima, kantor; urutan: urut dan arah;
+// paginasi: batas (bawaan 50, maksimal 200) serta kursor setelah/sebelum dari respons sebelumnya.
+func (h *Handler) APISuratList(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter, err := h.parseSuratFilter(r, "status")
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, apiErrTidakValid, err.Error())
+		return
+	}
+	filter.Batas = 50
+	if v := q.Get("batas"); v != "" {
+		batas, err := strconv.Atoi(v)
+		if err != nil || batas < 1 || batas > 200 {
+			writeJSONError(w, http.StatusBadRequest, apiErrTidakValid, "batas harus angka 1-200")
+			return
+		}
+		filter.Batas = batas
+	}
+
+	halaman, err := h.Repo.CariSuratHalaman(filter)
+	if errors.Is(err, repository.ErrKursorTidakValid) {
+		writeJSONError(w, http.StatusBadRequest, apiErrTidakValid, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("Gagal mengambil daftar surat: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, apiErrKesalahanServer, "Gagal mengambil daftar surat")
+		return
+	}
+	out := make([]apiSurat, 0, len(halaman.Surats))
+	for i := range halaman.Surats {
+		out = append(out, toAPISurat(&halaman.Surats[i]))
+	}
+	writeJSON(w, http.StatusOK, apiHalamanSurat{Data: out, Berikutnya: halaman.Berikutnya, Sebelumnya: halaman.Sebelumnya})
+}
+
+// apiHalamanSurat adalah respons daftar surat; kursor kosong tidak dikirim
+type apiHalamanSurat struct {
+	Data       []apiSurat `json:"data"`
+	Berikutnya string     `json:"berikutnya,omitempty"`
+	Sebelumnya string     `json:"sebelumnya,omitempty"`
+}
+
+// APISuratGet mengembalikan detail satu surat beserta barang hilang dan URL verifikasinya
+func (h *Handler) APISuratGet(w http.ResponseWriter, r *http.Request) {
+	id, ok := apiID(w, r)
+	if !ok {
+		return
+	}
+	surat, ok := h.getSuratOr404(w, id)
+	if !ok {
+		return
+	}
+	out := toAPISurat(surat)
+	out.VerifikasiURL = h.verifikasiURL(surat)
+	writeJSON(w, http.StatusOK, out)
+}
+
+// APISuratCreate membuat surat baru lewat SuratService, sama seperti form di halaman surat baru
+func (h *Handler) APISuratCreate(w http.ResponseWriter, r *http.Request) {
+	var req apiSuratRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	surat := &model.SuratKeteranganHilang{
+		PelaporNIK:       req.PelaporNIK,
+		PelaporNama:      req.PelaporNama,
+		PelaporTTL:       req.PelaporTTL,
+		PelaporAgama:     req.PelaporAgama,
+		PelaporKelamin:   req.PelaporKelamin,
+		PelaporPekerjaan: req.PelaporPekerjaan,
+		PelaporAlamat:    req.PelaporAlamat,
+		LokasiHilang:     req.LokasiHilang,
+		PejabatID:        req.PejabatID,
+		PenerimaID:       req.PenerimaID,
+		KantorID:         kantorIsian(r, req.KantorID),
+	}
+	for _, b := range req.BarangHilang {
+		if b.JenisBarang == "" {
+			writeJSONError(w, http.StatusBadRequest, apiErrTidakValid, "jenis_barang wajib diisi untuk setiap barang")
+			return
+		}
+		data := string(b.Data)
+		if len(b.Data) == 0 {
+			data = "{}"
+		}
+		surat.BarangHilang = append(surat.BarangHilang, model.Barang{JenisBarang: b.JenisBarang, Data: data})
+	}
+
+	created, err := h.SuratService.CreateNewSurat(surat, actorName(r))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	h.writeSurat(w, r, created.ID, http.StatusCreated)
+}
+
+// APISuratCancel membatalkan surat (supervisor)
+func (h *Handler) APISuratCancel(w http.ResponseWriter, r *http.Request) {
+	id, ok := apiID(w, r)
+	if !ok {
+		return
+	}
+	var req apiCancelRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if _, ok := h.getSuratOr404(w, id); !ok {
+		return
+	}
+	if err := h.SuratService.CancelSurat(id, req.Alasan, req.PetugasID, actorName(r)); err != nil {
+		writeJSONError(w, http.StatusBadRequest, apiErrTidakValid, err.Error())
+		return
+	}
+	h.writeSurat(w, r, id, http.StatusOK)
+}
+
+// APISuratPerpanjang menerbitkan surat perpanjangan dari surat {id}
+func (h *Handler) APISuratPerpanjang(w http.ResponseWriter, r *http.Request) {
+	id, ok := apiID(w, r)
+	if !ok {
+		return
+	}
+	if _, ok := h.getSuratOr404(w, id); !ok {
+		return
+	}
+	baru, err := h.SuratService.PerpanjangSurat(id, actorName(r))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	h.writeSurat(w, r, baru.ID, http.StatusCreated)
+}
+
+// writeSurat membaca ulang surat dari database agar respons sama persis dengan GET /surat/{id}
+func (h *Handler) writeSurat(w http.ResponseWriter, r *http.Request, id int, status int) {
+	surat, ok := h.getSuratOr404(w, id)
+	if !ok {
+		return
+	}
+	out := toAPISurat(surat)
+	out.VerifikasiURL = h.verifikasiURL(surat)
+	writeJSON(w, status, out)
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"net/http"
+	"skh_app/internal/model"
+	"time"
+)
+
+// auditPageLimit membatasi jumlah baris yang ditampilkan di halaman audit
+const auditPageLimit = 500
+
+// AuditList menampilkan jejak audit dengan filter entitas, pelaku dan rentang tanggal
+func (h *Handler) AuditList(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	loc := h.Lokasi
+
+	// Pengguna yang dibatasi ke satu kantor hanya melihat jejak audit data kantornya
+	kantorID, err := kantorDipilih(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter := model.AuditFilter{
+		KantorID: kantorID,
+		Entity:   q.Get("entity"),
+		Actor:    q.Get("actor"),
+		Limit:    auditPageLimit,
+	}
+	if dari, err := time.ParseInLocation("2006-01-02", q.Get("dari"), loc); err == nil {
+		filter.From = dari
+	}
+	if sampai, err := time.ParseInLocation("2006-01-02", q.Get("sampai"), loc); err == nil {
+		// Tanggal "sampai" ikut dihitung penuh satu hari
+		filter.To = sampai.AddDate(0, 0, 1)
+	}
+
+	logs, err := h.Repo.GetAuditLogs(filter)
+	if err != nil {
+		http.Error(w, "Gagal mengambil audit log", http.StatusInternalServerError)
+		return
+	}
+
+	data := map[string]interface{}{
+		"Logs":     logs,
+		"Entity":   filter.Entity,
+		"Actor":    filter.Actor,
+		"Dari":     q.Get("dari"),
+		"Sampai":   q.Get("sampai"),
+		"Entities": []string{model.EntitySurat, model.EntityPetugas, model.EntityPengaturan, model.EntityJenisBarang, model.EntityBackup},
+		"Limit":    auditPageLimit,
+	}
+	h.render(w, r, "audit_list.html", data)
+}
@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"skh_app/internal/model"
+	"skh_app/internal/service"
+	"strings"
+	"time"
+)
+
+const sessionCookieName = "skh_session"
+
+// jalurGantiPassword adalah halaman yang tetap boleh dibuka user yang wajib mengganti password
+const jalurGantiPassword = "/akun/password"
+
+type contextKey string
+
+const userContextKey contextKey = "user"
+
+// CurrentUser mengambil user yang sedang login dari context request
+func CurrentUser(r *http.Request) *model.User {
+	user, _ := r.Context().Value(userContextKey).(*model.User)
+	return user
+}
+
+// actorName mengembalikan username yang dicatat di audit log untuk request ini
+func actorName(r *http.Request) string {
+	if user := CurrentUser(r); user != nil {
+		return user.Username
+	}
+	return ""
+}
+
+// sessionToken mengambil token sesi dari cookie, atau dari header "Authorization: Bearer" untuk klien API
+func sessionToken(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+	}
+	if cookie, err := r.Cookie(sessionCookieName); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
+
+// LoadUser adalah middleware yang membaca token sesi dan menyimpan user ke context
+func (h *Handler) LoadUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token := sessionToken(r); token != "" {
+			user, err := h.AuthService.UserFromSession(token)
+			if err != nil {
+				log.Printf("Gagal membaca sesi: %v", err)
+			}
+			if user != nil {
+				r = r.WithContext(context.WithValue(r.Context(), userContextKey, user))
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireLogin mengalihkan ke halaman login jika belum ada user di context, dan ke halaman ganti
+// password jika user wajib mengganti password-nya (selain untuk logout)
+func (h *Handler) RequireLogin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := CurrentUser(r)
+		if user == nil {
+			http.Redirect(w, r, "/login?next="+url.QueryEscape(r.URL.RequestURI()), http.StatusSeeOther)
+			return
+		}
+		if user.WajibGantiPassword && r.URL.Path != jalurGantiPassword && r.URL.Path != "/logout" {
+			http.Redirect(w, r, jalurGantiPassword, http.StatusSeeOther)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireRole hanya meneruskan request jika peran user minimal setara role
+func (h *Handler) RequireRole(role string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !CurrentUser(r).HasRole(role) {
+				http.Error(w, "Akses ditolak: halaman ini membutuhkan peran "+role, http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireSemuaKantor hanya meneruskan request dari pengguna yang tidak dibatasi ke satu kantor, untuk
+// data yang mencakup semua kantor seperti cadangan database
+func (h *Handler) RequireSemuaKantor(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user := CurrentUser(r); user == nil || user.KantorID != 0 {
+			http.Error(w, "Akses ditolak: halaman ini hanya untuk pengguna semua kantor", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// LoginForm menampilkan halaman login
+func (h *Handler) LoginForm(w http.ResponseWriter, r *http.Request) {
+	if CurrentUser(r) != nil {
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+	data := map[string]interface{}{
+		"Next": r.URL.Query().Get("next"),
+	}
+	h.renderPrint(w, r, "login.html", data)
+}
+
+// Login memeriksa kredensial dan memasang cookie sesi
+func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Gagal mem-parsing form", http.StatusBadRequest)
+		return
+	}
+
+	username := r.FormValue("username")
+	next := r.FormValue("next")
+	token, _, err := h.AuthService.Login(username, r.FormValue("password"))
+	if err != nil {
+		if !errors.Is(err, service.ErrInvalidLogin) {
+			log.Printf("Gagal login: %v", err)
+		}
+		data := map[string]interface{}{
+			"Next":     next,
+			"Username": username,
+			"Error":    err.Error(),
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+		h.renderPrint(w, r, "login.html", data)
+		return
+	}
+
+	setSessionCookie(w, token)
+
+	http.Redirect(w, r, service.RedirectLokal(next), http.StatusSeeOther)
+}
+
+// setSessionCookie memasang cookie sesi milik token
+func setSessionCookie(w http.ResponseWriter, token string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    token,
+		Path:     "/",
+		Expires:  time.Now().Add(service.SessionDuration),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// GantiPasswordForm menampilkan form ganti password milik user yang sedang login
+func (h *Handler) GantiPasswordForm(w http.ResponseWriter, r *http.Request) {
+	h.render(w, r, "akun_password.html", map[string]interface{}{})
+}
+
+// GantiPassword menyimpan password baru user yang sedang login. Sesi lain milik user berakhir,
+// sedangkan browser ini mendapat cookie sesi baru.
+func (h *Handler) GantiPassword(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Gagal mem-parsing form", http.StatusBadRequest)
+		return
+	}
+
+	baru := r.FormValue("password_baru")
+	var token string
+	err := errors.New("konfirmasi password baru tidak sama")
+	if baru == r.FormValue("konfirmasi_password") {
+		token, err = h.AuthService.GantiPassword(CurrentUser(r).ID, r.FormValue("password_lama"), baru)
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		h.render(w, r, "akun_password.html", map[string]interface{}{"Error": err.Error()})
+		return
+	}
+
+	setSessionCookie(w, token)
+	http.Redirect(w, r, "/?status=success_password", http.StatusSeeOther)
+}
+
+// Logout menghapus sesi dan cookie
+func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie(sessionCookieName); err == nil {
+		if err := h.AuthService.Logout(cookie.Value); err != nil {
+			log.Printf("Gagal menghapus sesi: %v", err)
+		}
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, "/login", http.StatusSeeOther)
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"skh_app/internal/model"
+	"skh_app/internal/service"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// maksUkuranArsip adalah batas ukuran arsip cadangan yang boleh diunggah untuk dipulihkan
+const maksUkuranArsip = 1 << 30 // 1 GB
+
+// renderBackupError menampilkan kembali halaman pengaturan dengan pesan kesalahan di kartu cadangan
+func (h *Handler) renderBackupError(w http.ResponseWriter, r *http.Request, status int, msg string) {
+	pengaturan, err := h.Repo.GetPengaturan(model.KantorUtama)
+	if err != nil {
+		http.Error(w, msg, status)
+		return
+	}
+	w.WriteHeader(status)
+	h.renderPengaturanForm(w, r, pengaturan, "", msg, "")
+}
+
+// PengaturanBackup membuat cadangan database dan uploads saat itu juga
+func (h *Handler) PengaturanBackup(w http.ResponseWriter, r *http.Request) {
+	if _, err := h.BackupService.Buat(model.BackupManual, actorName(r)); err != nil {
+		log.Printf("Gagal membuat cadangan: %v", err)
+		h.renderBackupError(w, r, http.StatusInternalServerError, "Gagal membuat cadangan: "+err.Error())
+		return
+	}
+	http.Redirect(w, r, "/pengaturan?status=success_backup#cadangan", http.StatusSeeOther)
+}
+
+// PengaturanBackupDownload mengunduh satu arsip cadangan dari folder cadangan
+func (h *Handler) PengaturanBackupDownload(w http.ResponseWriter, r *http.Request) {
+	nama := chi.URLParam(r, "nama")
+	path, err := h.BackupService.Path(nama)
+	if errors.Is(err, service.ErrBackupTidakDitemukan) {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", nama))
+	http.ServeFile(w, r, path)
+}
+
+// PengaturanRestore memulihkan database dan uploads dari arsip cadangan yang diunggah
+func (h *Handler) PengaturanRestore(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maksUkuranArsip)
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		h.renderBackupError(w, r, http.StatusBadRequest, "Gagal membaca arsip yang diunggah (maksimal 1 GB)")
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("arsip")
+	if err != nil {
+		h.renderBackupError(w, r, http.StatusBadRequest, "Pilih file arsip cadangan (.zip) yang akan dipulihkan")
+		return
+	}
+	defer file.Close()
+
+	if _, err := h.BackupService.Pulihkan(file, header.Size, header.Filename, actorName(r)); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrArsipTidakValid) {
+			status = http.StatusBadRequest
+		} else {
+			log.Printf("Gagal memulihkan cadangan %s: %v", header.Filename, err)
+		}
+		h.renderBackupError(w, r, status, "Pemulihan gagal: "+err.Error())
+		return
+	}
+	// Sesi login ikut terganti dengan isi cadangan, sehingga pengguna mungkin diminta login ulang
+	http.Redirect(w, r, "/pengaturan?status=success_restore#cadangan", http.StatusSeeOther)
+}
@@ -4,8 +4,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"html/template"
+	"io"
 	"log"
 	"net/http"
+	"skh_app/internal/model"
 	"skh_app/internal/repository"
 	"skh_app/internal/service" // <-- Pastikan import ini ada
 	"skh_app/web"
@@ -18,15 +20,41 @@ type Handler struct {
 	Repo              *repository.SuratRepository
 	SuratService      *service.SuratService
 	PengaturanService *service.PengaturanService
+	AuthService       *service.AuthService
+	PDFService        *service.PDFService
+	VerifikasiService *service.VerifikasiService
+	BarangService     *service.BarangService
+	ExportService     *service.ExportService
+	LaporanService    *service.LaporanService
+	BackupService     *service.BackupService
+	Lokasi            *time.Location // zona waktu kantor untuk menampilkan dan membaca tanggal
+	PublicURL         string         // alamat publik aplikasi untuk QR code verifikasi, kosong bila belum diatur
 	Templates         map[string]*template.Template
 }
 
+// standaloneTemplates adalah halaman yang tidak memakai layout.html
+var standaloneTemplates = map[string]bool{
+	"surat_print.html":   true,
+	"login.html":         true,
+	"verifikasi.html":    true,
+	"laporan_print.html": true,
+}
+
 // NewHandler menerima semua dependensi yang dibutuhkan
-func NewHandler(repo *repository.SuratRepository, suratSrv *service.SuratService, pengaturanSrv *service.PengaturanService) *Handler {
+func NewHandler(repo *repository.SuratRepository, suratSrv *service.SuratService, pengaturanSrv *service.PengaturanService, authSrv *service.AuthService, pdfSrv *service.PDFService, verifikasiSrv *service.VerifikasiService, barangSrv *service.BarangService, exportSrv *service.ExportService, laporanSrv *service.LaporanService, backupSrv *service.BackupService, loc *time.Location, publicURL string) *Handler {
 	h := &Handler{
 		Repo:              repo,
 		SuratService:      suratSrv,
 		PengaturanService: pengaturanSrv,
+		AuthService:       authSrv,
+		PDFService:        pdfSrv,
+		VerifikasiService: verifikasiSrv,
+		BarangService:     barangSrv,
+		ExportService:     exportSrv,
+		LaporanService:    laporanSrv,
+		BackupService:     backupSrv,
+		Lokasi:            loc,
+		PublicURL:         publicURL,
 		Templates:         make(map[string]*template.Template),
 	}
 	h.loadTemplates()
@@ -43,10 +71,11 @@ func (h *Handler) loadTemplates() {
 		"split":   strings.Split,
 		"ToUpper": strings.ToUpper,
 		"FormatTanggalIndo": func(t time.Time) string {
-			bulan := []string{"", "Januari", "Februari", "Maret", "April", "Mei", "Juni", "Juli", "Agustus", "September", "Oktober", "November", "Desember"}
-			loc, _ := time.LoadLocation("Asia/Makassar")
-			t = t.In(loc)
-			return fmt.Sprintf("%d %s %d", t.Day(), bulan[t.Month()], t.Year())
+			return service.FormatTanggalIndo(t.In(h.Lokasi))
+		},
+		"Terbilang": service.Terbilang,
+		"FormatWaktu": func(t time.Time) string {
+			return t.In(h.Lokasi).Format("02-01-2006 15:04:05")
 		},
 		"UnmarshalJson": func(jsonString string) (map[string]interface{}, error) {
 			var result map[string]interface{}
@@ -57,13 +86,26 @@ func (h *Handler) loadTemplates() {
 			a, _ := json.Marshal(v)
 			return template.JS(a)
 		},
+		// Sorot meng-escape cuplikan hasil pencarian lalu menandai kata yang cocok dengan <mark>
+		"Sorot": func(cuplikan string) template.HTML {
+			html := template.HTMLEscapeString(cuplikan)
+			html = strings.ReplaceAll(html, model.SorotAwal, "<mark>")
+			html = strings.ReplaceAll(html, model.SorotAkhir, "</mark>")
+			return template.HTML(html)
+		},
+		// CurrentUser, HasRole dan BolehAksesKantor diganti per-request oleh render
+		"CurrentUser":      func() *model.User { return nil },
+		"HasRole":          func(role string) bool { return false },
+		"BolehAksesKantor": func(kantorID int) bool { return false },
 	}
 
-	printTmpl, err := template.New("surat_print.html").Funcs(funcMap).ParseFS(web.Files, "templates/surat_print.html")
-	if err != nil {
-		log.Fatalf("Gagal memuat template print: %v", err)
+	for name := range standaloneTemplates {
+		tmpl, err := template.New(name).Funcs(funcMap).ParseFS(web.Files, "templates/"+name)
+		if err != nil {
+			log.Fatalf("Gagal memuat template %s: %v", name, err)
+		}
+		h.Templates[name] = tmpl
 	}
-	h.Templates["surat_print.html"] = printTmpl
 
 	layout, err := template.New("layout.html").Funcs(funcMap).ParseFS(web.Files, "templates/layout.html")
 	if err != nil {
@@ -77,7 +119,7 @@ func (h *Handler) loadTemplates() {
 
 	for _, page := range pages {
 		name := page.Name()
-		if name == "layout.html" || name == "surat_print.html" {
+		if name == "layout.html" || standaloneTemplates[name] {
 			continue
 		}
 
@@ -90,32 +132,68 @@ func (h *Handler) loadTemplates() {
 	}
 }
 
-// render adalah helper untuk merender template (tidak ada perubahan di sini)
-func (h *Handler) render(w http.ResponseWriter, name string, data interface{}) {
+// render adalah helper untuk merender template beserta data user yang sedang login
+func (h *Handler) render(w http.ResponseWriter, r *http.Request, name string, data interface{}) {
 	tmpl, ok := h.Templates[name]
 	if !ok {
 		http.Error(w, "Template tidak ditemukan: "+name, http.StatusInternalServerError)
 		return
 	}
 
-	err := tmpl.ExecuteTemplate(w, "layout", data)
+	tmpl, err := h.withUser(tmpl, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = tmpl.ExecuteTemplate(w, "layout", data)
 	if err != nil {
 		log.Printf("Error executing template %s: %v", name, err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
 }
 
-// renderPrint adalah helper khusus untuk halaman print (tidak ada perubahan di sini)
-func (h *Handler) renderPrint(w http.ResponseWriter, name string, data interface{}) {
+// renderPrint adalah helper khusus untuk halaman tanpa layout (print, login)
+func (h *Handler) renderPrint(w http.ResponseWriter, r *http.Request, name string, data interface{}) {
+	if err := h.executePrint(w, r, name, data); err != nil {
+		log.Printf("Error executing print template %s: %v", name, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// executePrint menulis template tanpa layout ke w dan mengembalikan errornya ke pemanggil
+func (h *Handler) executePrint(w io.Writer, r *http.Request, name string, data interface{}) error {
 	tmpl, ok := h.Templates[name]
 	if !ok {
-		http.Error(w, "Template tidak ditemukan: "+name, http.StatusInternalServerError)
-		return
+		return fmt.Errorf("template tidak ditemukan: %s", name)
+	}
+	tmpl, err := h.withUser(tmpl, r)
+	if err != nil {
+		return err
 	}
+	return tmpl.Execute(w, data)
+}
 
-	err := tmpl.Execute(w, data)
+// withUser menyalin template dan mengisi fungsi CurrentUser/HasRole untuk request ini
+func (h *Handler) withUser(tmpl *template.Template, r *http.Request) (*template.Template, error) {
+	clone, err := tmpl.Clone()
 	if err != nil {
-		log.Printf("Error executing print template %s: %v", name, err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, err
+	}
+	user := CurrentUser(r)
+	return clone.Funcs(template.FuncMap{
+		"CurrentUser":      func() *model.User { return user },
+		"HasRole":          func(role string) bool { return user.HasRole(role) },
+		"BolehAksesKantor": func(kantorID int) bool { return user.BolehAksesKantor(kantorID) },
+	}), nil
+}
+
+// Healthz menjawab 200 bila server siap melayani dan database bisa dihubungi.
+// Dipakai saat start untuk menunggu server siap sebelum membuka browser.
+func (h *Handler) Healthz(w http.ResponseWriter, r *http.Request) {
+	if err := h.Repo.DB.PingContext(r.Context()); err != nil {
+		http.Error(w, "database tidak bisa dihubungi", http.StatusServiceUnavailable)
+		return
 	}
+	w.Write([]byte("ok"))
 }
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"net/http"
+	"skh_app/internal/model"
+	"skh_app/internal/validasi"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (h *Handler) JenisBarangList(w http.ResponseWriter, r *http.Request) {
+	list, err := h.BarangService.GetAllJenis()
+	if err != nil {
+		http.Error(w, "Gagal mengambil data jenis barang", http.StatusInternalServerError)
+		return
+	}
+	h.render(w, r, "jenis_barang_list.html", list)
+}
+
+func (h *Handler) JenisBarangFormNew(w http.ResponseWriter, r *http.Request) {
+	j := &model.JenisBarang{
+		Aktif:  true,
+		Fields: []model.FieldBarang{{Tipe: model.FieldTeks}},
+	}
+	h.renderJenisBarangForm(w, r, http.StatusOK, j, "")
+}
+
+// renderJenisBarangForm merender jenis_barang_form.html beserta daftar aturan validasi yang dapat dipilih
+func (h *Handler) renderJenisBarangForm(w http.ResponseWriter, r *http.Request, status int, j *model.JenisBarang, errMsg string) {
+	data := map[string]interface{}{
+		"Jenis":  j,
+		"Aturan": validasi.DaftarAturan,
+		"Error":  errMsg,
+	}
+	if status != http.StatusOK {
+		w.WriteHeader(status)
+	}
+	h.render(w, r, "jenis_barang_form.html", data)
+}
+
+func (h *Handler) JenisBarangCreate(w http.ResponseWriter, r *http.Request) {
+	h.saveJenisBarang(w, r, &model.JenisBarang{})
+}
+
+func (h *Handler) JenisBarangFormEdit(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	j, err := h.Repo.GetJenisBarangByID(id)
+	if err != nil {
+		http.Error(w, "Jenis barang tidak ditemukan", http.StatusNotFound)
+		return
+	}
+	h.renderJenisBarangForm(w, r, http.StatusOK, j, "")
+}
+
+func (h *Handler) JenisBarangUpdate(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	j, err := h.Repo.GetJenisBarangByID(id)
+	if err != nil {
+		http.Error(w, "Jenis barang tidak ditemukan", http.StatusNotFound)
+		return
+	}
+	h.saveJenisBarang(w, r, j)
+}
+
+// saveJenisBarang dipakai bersama oleh JenisBarangCreate dan JenisBarangUpdate.
+// Setiap baris isian dikirim sebagai array field_*[] dengan urutan yang sama.
+func (h *Handler) saveJenisBarang(w http.ResponseWriter, r *http.Request, j *model.JenisBarang) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Gagal parsing form", http.StatusBadRequest)
+		return
+	}
+	if j.ID == 0 {
+		j.Nama = r.FormValue("nama")
+	}
+	j.FrasaCetak = r.FormValue("frasa_cetak")
+	j.Urutan, _ = strconv.Atoi(r.FormValue("urutan"))
+	j.Aktif = r.FormValue("aktif") == "1"
+
+	kunci := r.Form["field_kunci[]"]
+	nilai := func(name string, i int) string {
+		if v := r.Form[name]; i < len(v) {
+			return v[i]
+		}
+		return ""
+	}
+	j.Fields = nil
+	for i := range kunci {
+		if strings.TrimSpace(kunci[i]) == "" && strings.TrimSpace(nilai("field_label[]", i)) == "" {
+			continue // baris kosong diabaikan
+		}
+		maks, _ := strconv.Atoi(nilai("field_maks[]", i))
+		j.Fields = append(j.Fields, model.FieldBarang{
+			Kunci:       kunci[i],
+			Label:       nilai("field_label[]", i),
+			Tipe:        nilai("field_tipe[]", i),
+			Wajib:       nilai("field_wajib[]", i) == "1",
+			Pola:        nilai("field_pola[]", i),
+			Validasi:    nilai("field_validasi[]", i),
+			Pilihan:     strings.Split(nilai("field_pilihan[]", i), ","),
+			MaksPanjang: maks,
+		})
+	}
+
+	if err := h.BarangService.SimpanJenis(j, actorName(r)); err != nil {
+		if len(j.Fields) == 0 {
+			j.Fields = []model.FieldBarang{{Tipe: model.FieldTeks}}
+		}
+		h.renderJenisBarangForm(w, r, http.StatusBadRequest, j, err.Error())
+		return
+	}
+	http.Redirect(w, r, "/jenis-barang?status=success_update", http.StatusSeeOther)
+}
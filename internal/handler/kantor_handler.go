@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"skh_app/internal/model"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// kantorDipilih mengembalikan kantor yang datanya ditampilkan untuk request ini. Pengguna yang
+// dibatasi ke satu kantor selalu mendapat kantornya sendiri; pengguna semua kantor memilih lewat
+// parameter ?kantor= dan 0 (atau tanpa parameter) berarti gabungan semua kantor.
+func kantorDipilih(r *http.Request) (int, error) {
+	if user := CurrentUser(r); user != nil && user.KantorID != 0 {
+		return user.KantorID, nil
+	}
+	v := r.URL.Query().Get("kantor")
+	if v == "" {
+		return 0, nil
+	}
+	id, err := strconv.Atoi(v)
+	if err != nil || id < 0 {
+		return 0, fmt.Errorf("kantor harus ID kantor")
+	}
+	return id, nil
+}
+
+// kantorIsian mengembalikan kantor untuk data yang dibuat lewat form: kantor pengguna, atau kantor
+// yang dipilih di isian kantor_id bagi pengguna semua kantor. Arti 0 bergantung pada datanya, mis.
+// kantor utama untuk surat dan semua kantor untuk petugas.
+func kantorIsian(r *http.Request, dipilih int) int {
+	if user := CurrentUser(r); user != nil && user.KantorID != 0 {
+		return user.KantorID
+	}
+	return dipilih
+}
+
+// kantorPengguna mengembalikan kantor pengguna, atau kantor utama bagi pengguna semua kantor. Dipakai
+// untuk pengaturan yang tidak terikat ke satu surat, mis. periode laporan berulang.
+func kantorPengguna(r *http.Request) int {
+	if user := CurrentUser(r); user != nil && user.KantorID != 0 {
+		return user.KantorID
+	}
+	return model.KantorUtama
+}
+
+// daftarKantorPilihan mengembalikan pilihan kantor untuk filter dan form. Hasilnya kosong bila
+// pengguna dibatasi ke satu kantor atau instalasi hanya punya satu kantor, sehingga pilihan kantor
+// tidak perlu ditampilkan.
+func (h *Handler) daftarKantorPilihan(r *http.Request) []model.Kantor {
+	if user := CurrentUser(r); user == nil || user.KantorID != 0 {
+		return nil
+	}
+	daftar, err := h.Repo.GetDaftarKantor()
+	if err != nil {
+		log.Printf("Gagal mengambil daftar kantor: %v", err)
+		return nil
+	}
+	if len(daftar) < 2 {
+		return nil
+	}
+	return daftar
+}
+
+// petaNamaKantor mengembalikan nama kantor per ID untuk kolom kantor di daftar surat; kosong bila
+// instalasi hanya punya satu kantor
+func (h *Handler) petaNamaKantor() map[int]string {
+	daftar, err := h.Repo.GetDaftarKantor()
+	if err != nil {
+		log.Printf("Gagal mengambil daftar kantor: %v", err)
+		return nil
+	}
+	if len(daftar) < 2 {
+		return nil
+	}
+	nama := make(map[int]string, len(daftar))
+	for _, k := range daftar {
+		nama[k.ID] = k.Nama
+	}
+	return nama
+}
+
+// bolehAksesSurat memeriksa kantor surat {id} terhadap kantor pengguna. Surat yang tidak ada dan
+// surat kantor lain sama-sama dianggap tidak ditemukan.
+func (h *Handler) bolehAksesSurat(r *http.Request) (bool, error) {
+	user := CurrentUser(r)
+	if user == nil || user.KantorID == 0 {
+		return true, nil
+	}
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		return false, nil
+	}
+	kantorID, err := h.Repo.GetKantorSurat(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return user.BolehAksesKantor(kantorID), nil
+}
+
+// RequireKantorSurat menjawab 404 untuk surat {id} milik kantor lain
+func (h *Handler) RequireKantorSurat(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		boleh, err := h.bolehAksesSurat(r)
+		if err != nil {
+			log.Printf("Gagal memeriksa kantor surat: %v", err)
+			http.Error(w, "Gagal mengambil surat", http.StatusInternalServerError)
+			return
+		}
+		if !boleh {
+			http.Error(w, "Surat tidak ditemukan", http.StatusNotFound)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// APIRequireKantorSurat sama dengan RequireKantorSurat tetapi menjawab error JSON
+func (h *Handler) APIRequireKantorSurat(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		boleh, err := h.bolehAksesSurat(r)
+		if err != nil {
+			log.Printf("Gagal memeriksa kantor surat: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, apiErrKesalahanServer, "Gagal mengambil surat")
+			return
+		}
+		if !boleh {
+			writeJSONError(w, http.StatusNotFound, apiErrTidakDitemukan, "Surat tidak ditemukan")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// PengaturanKantorCreate menambah kantor baru lalu membuka pengaturannya untuk dilengkapi. Hanya
+// admin semua kantor yang dapat menambah kantor.
+func (h *Handler) PengaturanKantorCreate(w http.ResponseWriter, r *http.Request) {
+	if user := CurrentUser(r); user == nil || user.KantorID != 0 {
+		http.Error(w, "Hanya admin semua kantor yang dapat menambah kantor", http.StatusForbidden)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Gagal mem-parsing form", http.StatusBadRequest)
+		return
+	}
+	baru, err := h.PengaturanService.TambahKantor(r.FormValue("nama_kantor"), r.FormValue("kode_kantor"), actorName(r))
+	if err != nil {
+		pengaturan, errGet := h.Repo.GetPengaturan(model.KantorUtama)
+		if errGet != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		h.renderPengaturanForm(w, r, pengaturan, "", "", err.Error())
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/pengaturan?kantor=%d&status=success_kantor", baru.ID), http.StatusSeeOther)
+}
@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"skh_app/internal/model"
+	"skh_app/internal/service"
+	"strconv"
+	"time"
+)
+
+// laporanDariQuery menyusun laporan dari parameter periode, tahun dan bulan. Tanpa parameter,
+// laporan yang dibuat adalah laporan bulanan untuk bulan berjalan.
+func (h *Handler) laporanDariQuery(w http.ResponseWriter, r *http.Request) (*model.Laporan, bool) {
+	q := r.URL.Query()
+	sekarang := time.Now().In(h.Lokasi)
+
+	periode := q.Get("periode")
+	if periode == "" {
+		periode = model.LaporanBulanan
+	}
+	tahun, bulan := sekarang.Year(), int(sekarang.Month())
+	var err error
+	if v := q.Get("tahun"); v != "" {
+		if tahun, err = strconv.Atoi(v); err != nil {
+			http.Error(w, "tahun tidak valid", http.StatusBadRequest)
+			return nil, false
+		}
+	}
+	if v := q.Get("bulan"); v != "" {
+		if bulan, err = strconv.Atoi(v); err != nil {
+			http.Error(w, "bulan tidak valid", http.StatusBadRequest)
+			return nil, false
+		}
+	}
+
+	kantorID, err := kantorDipilih(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+
+	lap, err := h.LaporanService.Buat(periode, tahun, bulan, kantorID)
+	if errors.Is(err, service.ErrPeriodeTidakValid) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	if err != nil {
+		log.Printf("Gagal menyusun laporan: %v", err)
+		http.Error(w, "Gagal menyusun laporan", http.StatusInternalServerError)
+		return nil, false
+	}
+	return lap, true
+}
+
+// laporanQuery adalah query string periode dan kantor laporan untuk tautan cetak dan unduh
+func laporanQuery(lap *model.Laporan) string {
+	q := fmt.Sprintf("periode=%s&tahun=%d", lap.Periode, lap.Tahun)
+	if lap.Periode != model.LaporanTahunan {
+		q += fmt.Sprintf("&bulan=%d", lap.Bulan)
+	}
+	if lap.KantorID != 0 {
+		q += fmt.Sprintf("&kantor=%d", lap.KantorID)
+	}
+	return q
+}
+
+// pengaturanLaporan mengambil kop untuk laporan: kop kantor laporan, atau kop kantor utama untuk
+// laporan gabungan semua kantor
+func (h *Handler) pengaturanLaporan(lap *model.Laporan) (*model.Pengaturan, error) {
+	if lap.KantorID == 0 {
+		return h.Repo.GetPengaturan(model.KantorUtama)
+	}
+	return h.Repo.GetPengaturan(lap.KantorID)
+}
+
+// laporanNamaFile adalah nama file unduhan, mis. laporan-skh-2026-10.pdf atau laporan-skh-2026.xlsx
+func laporanNamaFile(lap *model.Laporan, ext string) string {
+	if lap.Periode == model.LaporanTahunan {
+		return fmt.Sprintf("laporan-skh-%d.%s", lap.Tahun, ext)
+	}
+	return fmt.Sprintf("laporan-skh-%d-%02d.%s", lap.Tahun, lap.Bulan, ext)
+}
+
+// Laporan menampilkan rekap bulanan atau tahunan beserta pilihan periode
+func (h *Handler) Laporan(w http.ResponseWriter, r *http.Request) {
+	lap, ok := h.laporanDariQuery(w, r)
+	if !ok {
+		return
+	}
+	bulan := make([]map[string]interface{}, 0, 12)
+	for i := 1; i <= 12; i++ {
+		bulan = append(bulan, map[string]interface{}{"Nomor": i, "Nama": service.NamaBulan(i)})
+	}
+	data := map[string]interface{}{
+		"Laporan":      lap,
+		"DaftarBulan":  bulan,
+		"Query":        laporanQuery(lap),
+		"DaftarKantor": h.daftarKantorPilihan(r),
+	}
+	h.render(w, r, "laporan.html", data)
+}
+
+// LaporanCetak menampilkan laporan dengan kop surat untuk dicetak dari browser
+func (h *Handler) LaporanCetak(w http.ResponseWriter, r *http.Request) {
+	lap, ok := h.laporanDariQuery(w, r)
+	if !ok {
+		return
+	}
+	pengaturan, err := h.pengaturanLaporan(lap)
+	if err != nil {
+		http.Error(w, "Gagal mengambil pengaturan untuk cetak", http.StatusInternalServerError)
+		return
+	}
+	data := map[string]interface{}{
+		"Laporan":    lap,
+		"Pengaturan": pengaturan,
+		"Dicetak":    time.Now(),
+	}
+	h.renderPrint(w, r, "laporan_print.html", data)
+}
+
+// LaporanPDF mengirim laporan dalam bentuk PDF A4 yang dibuat di server
+func (h *Handler) LaporanPDF(w http.ResponseWriter, r *http.Request) {
+	lap, ok := h.laporanDariQuery(w, r)
+	if !ok {
+		return
+	}
+	pengaturan, err := h.pengaturanLaporan(lap)
+	if err != nil {
+		http.Error(w, "Gagal mengambil pengaturan untuk cetak", http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := h.PDFService.RenderLaporan(&buf, lap, pengaturan, service.FormatTanggalIndo(time.Now().In(h.Lokasi))); err != nil {
+		log.Printf("Gagal membuat PDF laporan %s: %v", lap.Judul, err)
+		http.Error(w, "Gagal membuat PDF laporan", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", laporanNamaFile(lap, "pdf")))
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	buf.WriteTo(w)
+}
+
+// LaporanXLSX mengunduh laporan sebagai workbook XLSX dengan satu sheet per bagian
+func (h *Handler) LaporanXLSX(w http.ResponseWriter, r *http.Request) {
+	lap, ok := h.laporanDariQuery(w, r)
+	if !ok {
+		return
+	}
+	pengaturan, err := h.pengaturanLaporan(lap)
+	if err != nil {
+		http.Error(w, "Gagal mengambil pengaturan", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, laporanNamaFile(lap, "xlsx")))
+	if err := h.LaporanService.TulisXLSX(w, lap, pengaturan); err != nil {
+		log.Printf("Gagal menulis XLSX laporan %s: %v", lap.Judul, err)
+	}
+}
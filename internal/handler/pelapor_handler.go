@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"skh_app/internal/model"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// batasDaftarPelapor adalah jumlah pelapor yang ditampilkan di halaman daftar
+const batasDaftarPelapor = 100
+
+// batasSaranPelapor adalah jumlah saran pelapor pada isian NIK form surat
+const batasSaranPelapor = 10
+
+// PelaporList menampilkan data induk pelapor dengan pencarian NIK atau nama
+func (h *Handler) PelaporList(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query().Get("q")
+	kantorID, err := kantorDipilih(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	daftar, err := h.Repo.CariPelapor(q, kantorID, batasDaftarPelapor)
+	if err != nil {
+		log.Printf("Gagal mengambil data pelapor: %v", err)
+		http.Error(w, "Gagal mengambil data pelapor", http.StatusInternalServerError)
+		return
+	}
+	h.render(w, r, "pelapor_list.html", map[string]interface{}{
+		"Pelapor": daftar,
+		"Query":   q,
+		"Batas":   batasDaftarPelapor,
+	})
+}
+
+// PelaporDetail menampilkan identitas pelapor, riwayat semua suratnya dan jumlah laporan per
+// jenis barang dalam periode laporan berulang. Pengguna yang dibatasi ke satu kantor hanya melihat
+// pelapor dan surat kantornya.
+func (h *Handler) PelaporDetail(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	var kantorID int
+	if user := CurrentUser(r); user != nil {
+		kantorID = user.KantorID
+	}
+	pelapor, err := h.Repo.GetPelaporByID(id, kantorID)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		log.Printf("Gagal mengambil pelapor %d: %v", id, err)
+		http.Error(w, "Gagal mengambil data pelapor", http.StatusInternalServerError)
+		return
+	}
+	surats, err := h.Repo.GetSuratPelapor(id, kantorID)
+	if err != nil {
+		log.Printf("Gagal mengambil surat pelapor %d: %v", id, err)
+		http.Error(w, "Gagal mengambil riwayat surat", http.StatusInternalServerError)
+		return
+	}
+	pengaturan, err := h.Repo.GetPengaturan(kantorPengguna(r))
+	if err != nil {
+		http.Error(w, "Gagal mengambil pengaturan", http.StatusInternalServerError)
+		return
+	}
+	sejak := time.Now().In(h.Lokasi).AddDate(0, 0, -pengaturan.PeriodeLaporanBerulang)
+	jumlah, err := h.Repo.HitungLaporanPelapor(pelapor.NIK, sejak)
+	if err != nil {
+		log.Printf("Gagal menghitung laporan pelapor %d: %v", id, err)
+		http.Error(w, "Gagal menghitung laporan pelapor", http.StatusInternalServerError)
+		return
+	}
+	berulang := make([]model.LaporanBerulang, 0, len(jumlah))
+	for jenis, n := range jumlah {
+		berulang = append(berulang, model.LaporanBerulang{
+			JenisBarang: jenis,
+			Jumlah:      n,
+			Melewati:    pengaturan.BatasLaporanBerulang > 0 && n > pengaturan.BatasLaporanBerulang,
+		})
+	}
+	sort.Slice(berulang, func(i, j int) bool {
+		if berulang[i].Jumlah != berulang[j].Jumlah {
+			return berulang[i].Jumlah > berulang[j].Jumlah
+		}
+		return berulang[i].JenisBarang < berulang[j].JenisBarang
+	})
+
+	h.render(w, r, "pelapor_detail.html", map[string]interface{}{
+		"Pelapor":    pelapor,
+		"Surats":     surats,
+		"Berulang":   berulang,
+		"Pengaturan": pengaturan,
+		"NamaKantor": h.petaNamaKantor(),
+	})
+}
+
+// pelaporSaran adalah bentuk JSON pelapor untuk isian otomatis di form surat
+type pelaporSaran struct {
+	ID          int    `json:"id"`
+	NIK         string `json:"nik"`
+	Nama        string `json:"nama"`
+	TTL         string `json:"ttl"`
+	Agama       string `json:"agama"`
+	Kelamin     string `json:"kelamin"`
+	Pekerjaan   string `json:"pekerjaan"`
+	Alamat      string `json:"alamat"`
+	JumlahSurat int    `json:"jumlah_surat"`
+}
+
+// PelaporCari mengembalikan saran pelapor (JSON) untuk teks NIK atau nama yang sedang diketik
+func (h *Handler) PelaporCari(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query().Get("q")
+	if len(q) < 3 {
+		writeJSON(w, http.StatusOK, []pelaporSaran{})
+		return
+	}
+	kantorID, err := kantorDipilih(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, []pelaporSaran{})
+		return
+	}
+	daftar, err := h.Repo.CariPelapor(q, kantorID, batasSaranPelapor)
+	if err != nil {
+		log.Printf("Gagal mencari pelapor: %v", err)
+		writeJSON(w, http.StatusInternalServerError, []pelaporSaran{})
+		return
+	}
+	saran := make([]pelaporSaran, 0, len(daftar))
+	for _, p := range daftar {
+		saran = append(saran, pelaporSaran{
+			ID: p.ID, NIK: p.NIK, Nama: p.Nama, TTL: p.TTL, Agama: p.Agama,
+			Kelamin: p.Kelamin, Pekerjaan: p.Pekerjaan, Alamat: p.Alamat, JumlahSurat: p.JumlahSurat,
+		})
+	}
+	writeJSON(w, http.StatusOK, saran)
+}
@@ -1,6 +1,9 @@
 package handler
 
 import (
+	"database/sql"
+	"errors"
+	"log"
 	"net/http"
 	"skh_app/internal/model"
 	"strconv"
@@ -8,17 +11,72 @@ import (
 	"github.com/go-chi/chi/v5"
 )
 
+// errPetugasSemuaKantor menolak admin satu kantor yang mengubah atau menghapus petugas semua kantor
+var errPetugasSemuaKantor = errors.New("petugas semua kantor hanya dapat diubah atau dihapus admin semua kantor")
+
+// petugasPengguna mengambil petugas id yang terlihat oleh pengguna: petugas kantornya dan petugas semua
+// kantor. Petugas kantor lain dianggap tidak ada (sql.ErrNoRows). Bila ubah, petugas semua kantor
+// hanya boleh diubah atau dihapus admin semua kantor (errPetugasSemuaKantor).
+func (h *Handler) petugasPengguna(r *http.Request, id int, ubah bool) (*model.Petugas, error) {
+	p, err := h.Repo.GetPetugasByID(id)
+	if err != nil {
+		return nil, err
+	}
+	user := CurrentUser(r)
+	if user == nil || (user.KantorID != 0 && !p.BertugasDi(user.KantorID)) {
+		return nil, sql.ErrNoRows
+	}
+	if ubah && !user.BolehAksesKantor(p.KantorID) {
+		return nil, errPetugasSemuaKantor
+	}
+	return p, nil
+}
+
+// petugasUbahan mengambil petugas yang akan diubah atau dihapus lewat halaman admin. Mengembalikan
+// false setelah menulis error 403/404/500.
+func (h *Handler) petugasUbahan(w http.ResponseWriter, r *http.Request, id int) (*model.Petugas, bool) {
+	p, err := h.petugasPengguna(r, id, true)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		http.Error(w, "Data petugas tidak ditemukan", http.StatusNotFound)
+		return nil, false
+	case errors.Is(err, errPetugasSemuaKantor):
+		http.Error(w, "Akses ditolak: "+err.Error(), http.StatusForbidden)
+		return nil, false
+	case err != nil:
+		log.Printf("Gagal mengambil petugas %d: %v", id, err)
+		http.Error(w, "Gagal mengambil data petugas", http.StatusInternalServerError)
+		return nil, false
+	}
+	return p, true
+}
+
 func (h *Handler) PetugasList(w http.ResponseWriter, r *http.Request) {
-	petugas, err := h.Repo.GetAllPetugas()
+	var kantorID int
+	if user := CurrentUser(r); user != nil {
+		kantorID = user.KantorID
+	}
+	petugas, err := h.Repo.GetAllPetugas(kantorID)
 	if err != nil {
 		http.Error(w, "Gagal mengambil data petugas", http.StatusInternalServerError)
 		return
 	}
-	h.render(w, "petugas_list.html", petugas)
+	h.render(w, r, "petugas_list.html", map[string]interface{}{
+		"Petugas":    petugas,
+		"NamaKantor": h.petaNamaKantor(),
+	})
 }
 
 func (h *Handler) PetugasFormNew(w http.ResponseWriter, r *http.Request) {
-	h.render(w, "petugas_form.html", nil)
+	h.renderPetugasForm(w, r, &model.Petugas{KantorID: kantorIsian(r, 0)})
+}
+
+// renderPetugasForm menampilkan form petugas beserta pilihan kantor tempat petugas bertugas
+func (h *Handler) renderPetugasForm(w http.ResponseWriter, r *http.Request, p *model.Petugas) {
+	h.render(w, r, "petugas_form.html", map[string]interface{}{
+		"Petugas":      p,
+		"DaftarKantor": h.daftarKantorPilihan(r),
+	})
 }
 
 func (h *Handler) PetugasCreate(w http.ResponseWriter, r *http.Request) {
@@ -33,7 +91,9 @@ func (h *Handler) PetugasCreate(w http.ResponseWriter, r *http.Request) {
 		Jabatan: r.FormValue("jabatan"),
 		Tipe:    r.FormValue("tipe"),
 	}
-	if err := h.Repo.CreatePetugas(p); err != nil {
+	kantorID, _ := strconv.Atoi(r.FormValue("kantor_id"))
+	p.KantorID = kantorIsian(r, kantorID)
+	if err := h.Repo.CreatePetugas(p, actorName(r)); err != nil {
 		http.Error(w, "Gagal menyimpan data petugas", http.StatusInternalServerError)
 		return
 	}
@@ -42,12 +102,11 @@ func (h *Handler) PetugasCreate(w http.ResponseWriter, r *http.Request) {
 
 func (h *Handler) PetugasFormEdit(w http.ResponseWriter, r *http.Request) {
 	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
-	p, err := h.Repo.GetPetugasByID(id)
-	if err != nil {
-		http.Error(w, "Data petugas tidak ditemukan", http.StatusNotFound)
+	p, ok := h.petugasUbahan(w, r, id)
+	if !ok {
 		return
 	}
-	h.render(w, "petugas_form.html", p)
+	h.renderPetugasForm(w, r, p)
 }
 
 func (h *Handler) PetugasUpdate(w http.ResponseWriter, r *http.Request) {
@@ -56,6 +115,9 @@ func (h *Handler) PetugasUpdate(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Gagal parsing form", http.StatusBadRequest)
 		return
 	}
+	if _, ok := h.petugasUbahan(w, r, id); !ok {
+		return
+	}
 	p := &model.Petugas{
 		ID:      id,
 		Nama:    r.FormValue("nama"),
@@ -64,7 +126,9 @@ func (h *Handler) PetugasUpdate(w http.ResponseWriter, r *http.Request) {
 		Jabatan: r.FormValue("jabatan"),
 		Tipe:    r.FormValue("tipe"),
 	}
-	if err := h.Repo.UpdatePetugas(p); err != nil {
+	kantorID, _ := strconv.Atoi(r.FormValue("kantor_id"))
+	p.KantorID = kantorIsian(r, kantorID)
+	if err := h.Repo.UpdatePetugas(p, actorName(r)); err != nil {
 		http.Error(w, "Gagal mengupdate data petugas", http.StatusInternalServerError)
 		return
 	}
@@ -73,7 +137,10 @@ func (h *Handler) PetugasUpdate(w http.ResponseWriter, r *http.Request) {
 
 func (h *Handler) PetugasDelete(w http.ResponseWriter, r *http.Request) {
 	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
-	if err := h.Repo.DeletePetugas(id); err != nil {
+	if _, ok := h.petugasUbahan(w, r, id); !ok {
+		return
+	}
+	if err := h.Repo.DeletePetugas(id, actorName(r)); err != nil {
 		http.Error(w, "Gagal menghapus data petugas", http.StatusInternalServerError)
 		return
 	}
@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"skh_app/internal/model"
+	"strconv"
+	"time"
+)
+
+// suratPerHalaman adalah jumlah baris per halaman di daftar surat
+const suratPerHalaman = 25
+
+// parseSuratFilter membaca filter, urutan dan kursor daftar surat dari query string. Dipakai halaman
+// daftar surat dan API agar URL yang di-bookmark menghasilkan tampilan yang sama. kunciStatus adalah
+// nama parameter filter status, karena di halaman web "status" sudah dipakai untuk notifikasi.
+// Tanggal dari/sampai dibaca dalam zona waktu loc. Pengguna yang dibatasi ke satu kantor hanya
+// melihat surat kantornya, apa pun parameter kantor yang dikirim.
+func (h *Handler) parseSuratFilter(r *http.Request, kunciStatus string) (model.SuratFilter, error) {
+	q := r.URL.Query()
+	loc := h.Lokasi
+	filter := model.SuratFilter{
+		Query:       q.Get("q"),
+		Status:      q.Get(kunciStatus),
+		JenisBarang: q.Get("jenis"),
+		Urut:        q.Get("urut"),
+		Setelah:     q.Get("setelah"),
+		Sebelum:     q.Get("sebelum"),
+	}
+	switch filter.Status {
+	case "", model.StatusAktif, model.StatusDibatalkan, model.FilterBerlaku, model.FilterKedaluwarsa:
+	default:
+		return filter, fmt.Errorf("%s harus salah satu dari aktif, dibatalkan, berlaku, kedaluwarsa", kunciStatus)
+	}
+	switch filter.Urut {
+	case "", model.UrutTanggal, model.UrutNomor, model.UrutPelapor, model.UrutRelevansi:
+	default:
+		return filter, fmt.Errorf("urut harus salah satu dari tanggal, nomor, pelapor, relevansi")
+	}
+	switch q.Get("arah") {
+	case "", "turun":
+	case "naik":
+		filter.Naik = true
+	default:
+		return filter, fmt.Errorf("arah harus naik atau turun")
+	}
+	if filter.Setelah != "" && filter.Sebelum != "" {
+		return filter, fmt.Errorf("setelah dan sebelum tidak boleh dipakai bersamaan")
+	}
+	if v := q.Get("dari"); v != "" {
+		dari, err := time.ParseInLocation("2006-01-02", v, loc)
+		if err != nil {
+			return filter, fmt.Errorf("dari harus berformat YYYY-MM-DD")
+		}
+		filter.Dari = dari
+	}
+	if v := q.Get("sampai"); v != "" {
+		sampai, err := time.ParseInLocation("2006-01-02", v, loc)
+		if err != nil {
+			return filter, fmt.Errorf("sampai harus berformat YYYY-MM-DD")
+		}
+		filter.Sampai = sampai.AddDate(0, 0, 1)
+	}
+	if v := q.Get("penerima"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil || id <= 0 {
+			return filter, fmt.Errorf("penerima harus ID petugas")
+		}
+		filter.PenerimaID = id
+	}
+	kantorID, err := kantorDipilih(r)
+	if err != nil {
+		return filter, err
+	}
+	filter.KantorID = kantorID
+	return filter, nil
+}
+
+// urutAktif mengembalikan kolom urut yang dipakai repository bila filter.Urut kosong
+func urutAktif(f model.SuratFilter) string {
+	switch {
+	case f.Urut == model.UrutRelevansi && f.Query == "":
+		return model.UrutTanggal
+	case f.Urut != "":
+		return f.Urut
+	case f.Query != "":
+		return model.UrutRelevansi
+	}
+	return model.UrutTanggal
+}
+
+// suratListURL menyusun ulang URL daftar surat dari query saat ini dengan beberapa parameter diganti.
+// Kursor dan notifikasi selalu dibuang; nilai kosong menghapus parameter.
+func suratListURL(q url.Values, ganti map[string]string) string {
+	return suratURL("/surat", q, ganti)
+}
+
+// suratURL sama dengan suratListURL untuk halaman lain yang memakai filter daftar surat
+func suratURL(path string, q url.Values, ganti map[string]string) string {
+	v := url.Values{}
+	for k, vals := range q {
+		switch k {
+		case "setelah", "sebelum", "status", "new_id":
+			continue
+		}
+		v[k] = vals
+	}
+	for k, val := range ganti {
+		if val == "" {
+			v.Del(k)
+		} else {
+			v.Set(k, val)
+		}
+	}
+	if len(v) == 0 {
+		return path
+	}
+	return path + "?" + v.Encode()
+}
@@ -1,13 +1,17 @@
 package handler
 
 import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
+	"log"
 	"net/http"
-	"os"
-	"path/filepath"
 	"skh_app/internal/model"
+	"skh_app/internal/repository"
 	"skh_app/internal/service"
+	"skh_app/internal/validasi"
 	"strconv"
 	"time"
 
@@ -16,37 +20,187 @@ import (
 
 // Dashboard menampilkan halaman utama dengan statistik
 func (h *Handler) Dashboard(w http.ResponseWriter, r *http.Request) {
+	kantorID, err := kantorDipilih(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	// Handler sekarang hanya memanggil satu fungsi dari service
-	data, err := h.Service.GetDashboardData()
+	data, err := h.SuratService.GetDashboardData(kantorID)
 	if err != nil {
 		http.Error(w, "Gagal memuat data dashboard: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
+	data.DaftarKantor = h.daftarKantorPilihan(r)
 
 	// Dan langsung merender data yang sudah jadi
-	h.render(w, "dashboard.html", data)
+	h.render(w, r, "dashboard.html", data)
 }
 
-
-// SuratList menampilkan semua surat yang telah dibuat, dengan fitur pencarian
+// SuratList menampilkan daftar surat per halaman dengan pencarian, filter dan urutan dari query string
 func (h *Handler) SuratList(w http.ResponseWriter, r *http.Request) {
-	query := r.URL.Query().Get("q")
-	surats, err := h.Repo.GetAllSurat(query)
+	q := r.URL.Query()
+	filter, err := h.parseSuratFilter(r, "status_surat")
+	urut := urutAktif(filter)
+	data := map[string]interface{}{
+		"Query":  filter.Query,
+		"Params": q,
+		"Urut":   urut,
+		"Naik":   filter.Naik,
+	}
+	data["Jenis"], _ = h.BarangService.GetAllJenis()
+	data["PenerimaList"], _ = h.Repo.GetPetugasByTipe("Penerima", filter.KantorID)
+	// Kolom kantor hanya perlu bagi pengguna yang melihat surat lebih dari satu kantor
+	if daftar := h.daftarKantorPilihan(r); daftar != nil {
+		data["DaftarKantor"] = daftar
+		data["NamaKantor"] = h.petaNamaKantor()
+	}
+
+	// Tautan judul kolom: klik kolom yang sama membalik arah, kolom lain mulai dari arah bawaannya
+	urutURL := map[string]string{}
+	for _, kolom := range []string{model.UrutNomor, model.UrutTanggal, model.UrutPelapor} {
+		arah := "naik"
+		if kolom == model.UrutTanggal {
+			arah = "turun"
+		}
+		if kolom == urut {
+			arah = "naik"
+			if filter.Naik {
+				arah = "turun"
+			}
+		}
+		urutURL[kolom] = suratListURL(q, map[string]string{"urut": kolom, "arah": arah})
+	}
+	data["UrutURL"] = urutURL
+	data["ExportURL"] = map[string]string{
+		"csv":         suratURL("/surat/export", q, map[string]string{"format": service.FormatCSV}),
+		"xlsx":        suratURL("/surat/export", q, map[string]string{"format": service.FormatXLSX}),
+		"xlsx_barang": suratURL("/surat/export", q, map[string]string{"format": service.FormatXLSX, "per": "barang"}),
+		"csv_barang":  suratURL("/surat/export", q, map[string]string{"format": service.FormatCSV, "per": "barang"}),
+	}
+
+	if err != nil {
+		data["Error"] = err.Error()
+		w.WriteHeader(http.StatusBadRequest)
+		h.render(w, r, "surat_list.html", data)
+		return
+	}
+	filter.Batas = suratPerHalaman
+	halaman, err := h.Repo.CariSuratHalaman(filter)
+	if errors.Is(err, repository.ErrKursorTidakValid) {
+		// Kursor dari bookmark lama atau urutan lain: kembali ke halaman pertama
+		http.Redirect(w, r, suratListURL(q, nil), http.StatusSeeOther)
+		return
+	}
 	if err != nil {
+		log.Printf("Gagal mengambil daftar surat: %v", err)
 		http.Error(w, "Gagal mengambil daftar surat", http.StatusInternalServerError)
 		return
 	}
 
-	data := map[string]interface{}{
-		"Surats": surats,
-		"Query":  query,
+	data["Surats"] = halaman.Surats
+	if halaman.Berikutnya != "" {
+		data["BerikutnyaURL"] = suratListURL(q, map[string]string{"setelah": halaman.Berikutnya})
+	}
+	if halaman.Sebelumnya != "" {
+		data["SebelumnyaURL"] = suratListURL(q, map[string]string{"sebelum": halaman.Sebelumnya})
+		data["PertamaURL"] = suratListURL(q, nil)
+	}
+	h.render(w, r, "surat_list.html", data)
+}
+
+// SuratExport mengunduh register surat (CSV atau XLSX) dengan filter yang sama seperti daftar surat.
+// per=barang menghasilkan satu baris per barang hilang.
+func (h *Handler) SuratExport(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter, err := h.parseSuratFilter(r, "status_surat")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	format := q.Get("format")
+	var contentType string
+	switch format {
+	case service.FormatCSV:
+		contentType = "text/csv; charset=utf-8"
+	case service.FormatXLSX:
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		http.Error(w, "format harus csv atau xlsx", http.StatusBadRequest)
+		return
+	}
+
+	namaFile := fmt.Sprintf("register-surat-%s.%s", time.Now().In(h.Lokasi).Format("20060102-1504"), format)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, namaFile))
+	// File ditulis bertahap; error di tengah jalan hanya bisa dicatat karena header sudah terkirim
+	if err := h.ExportService.Register(w, format, filter, q.Get("per") == "barang"); err != nil {
+		log.Printf("Gagal mengekspor register surat: %v", err)
 	}
-	h.render(w, "surat_list.html", data)
 }
 
 // SuratFormNew menampilkan formulir untuk membuat surat baru
 func (h *Handler) SuratFormNew(w http.ResponseWriter, r *http.Request) {
-	h.render(w, "surat_form.html", model.PageData{})
+	h.renderSuratForm(w, r, http.StatusOK, model.PageData{})
+}
+
+// renderSuratForm melengkapi data form dengan jenis barang dari registri lalu merender surat_form.html
+func (h *Handler) renderSuratForm(w http.ResponseWriter, r *http.Request, status int, data model.PageData) {
+	jenis, err := h.BarangService.JenisUntukForm(data.Surat)
+	if err != nil {
+		http.Error(w, "Gagal mengambil jenis barang", http.StatusInternalServerError)
+		return
+	}
+	data.JenisBarang = jenis
+
+	// Kantor dan petugas bertugas hanya dipilih saat surat dibuat; surat yang sudah terbit memakai salinannya
+	if data.Surat == nil || data.Surat.ID == 0 {
+		data.KantorID = kantorIsian(r, 0)
+		if data.Surat != nil && data.Surat.KantorID != 0 {
+			data.KantorID = data.Surat.KantorID
+		}
+		if data.KantorID == 0 {
+			data.KantorID = model.KantorUtama
+		}
+		data.DaftarKantor = h.daftarKantorPilihan(r)
+		pengaturan, err := h.Repo.GetPengaturan(data.KantorID)
+		if err != nil {
+			http.Error(w, "Gagal mengambil pengaturan", http.StatusInternalServerError)
+			return
+		}
+		// Pengguna semua kantor melihat semua petugas tanpa pilihan awal, karena petugas pengaturan
+		// bergantung pada kantor yang dipilih; pilihan yang tidak cocok dengan kantor ditolak saat disimpan
+		kantorPetugas := data.KantorID
+		if len(data.DaftarKantor) > 0 {
+			kantorPetugas = 0
+		} else {
+			data.PejabatID, data.PenerimaID = pengaturan.PejabatID, pengaturan.PenerimaID
+		}
+		data.PejabatList, _ = h.Repo.GetPetugasByTipe("Pejabat", kantorPetugas)
+		data.PenerimaList, _ = h.Repo.GetPetugasByTipe("Penerima", kantorPetugas)
+		if data.Surat != nil && data.Surat.PejabatID != 0 {
+			data.PejabatID = data.Surat.PejabatID
+		}
+		if data.Surat != nil && data.Surat.PenerimaID != 0 {
+			data.PenerimaID = data.Surat.PenerimaID
+		}
+	}
+	if status != http.StatusOK {
+		w.WriteHeader(status)
+	}
+	h.render(w, r, "surat_form.html", data)
+}
+
+// setFormError menaruh error validasi per field ke FieldErrors agar ditampilkan di samping
+// isiannya; error lain tetap ditampilkan sebagai pesan umum
+func setFormError(data *model.PageData, err error) {
+	var fe validasi.Errors
+	if errors.As(err, &fe) {
+		data.FieldErrors = fe
+		data.Error = "Periksa kembali isian yang ditandai merah."
+		return
+	}
+	data.Error = err.Error()
 }
 
 // SuratCreate memproses data dari form dan membuat surat baru
@@ -62,6 +216,7 @@ func (h *Handler) SuratCreate(w http.ResponseWriter, r *http.Request) {
 	ttl := fmt.Sprintf("%s, %s", tempatLahir, tanggalLahir)
 
 	surat := &model.SuratKeteranganHilang{
+		PelaporNIK:       r.FormValue("pelapor_nik"),
 		PelaporNama:      r.FormValue("pelapor_nama"),
 		PelaporTTL:       ttl,
 		PelaporAgama:     r.FormValue("pelapor_agama"),
@@ -71,6 +226,11 @@ func (h *Handler) SuratCreate(w http.ResponseWriter, r *http.Request) {
 		LokasiHilang:     r.FormValue("lokasi_hilang"),
 	}
 
+	surat.PejabatID, _ = strconv.Atoi(r.FormValue("pejabat_id"))
+	surat.PenerimaID, _ = strconv.Atoi(r.FormValue("penerima_id"))
+	kantorID, _ := strconv.Atoi(r.FormValue("kantor_id"))
+	surat.KantorID = kantorIsian(r, kantorID)
+
 	jenisBarangList := r.Form["barang_jenis[]"]
 	dataBarangList := r.Form["barang_data[]"]
 	for i, jenis := range jenisBarangList {
@@ -83,15 +243,12 @@ func (h *Handler) SuratCreate(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 2. Panggil Service untuk menjalankan SEMUA logika bisnis
-	createdSurat, err := h.Service.CreateNewSurat(surat)
+	createdSurat, err := h.SuratService.CreateNewSurat(surat, actorName(r))
 	if err != nil {
 		// Jika ada error dari service, tampilkan di form agar pengguna bisa memperbaiki
-		data := model.PageData{
-			Surat: surat, // Kirim kembali data yang sudah diisi pengguna
-			Error: err.Error(),
-		}
-		w.WriteHeader(http.StatusBadRequest) // Set status code yang sesuai
-		h.render(w, "surat_form.html", data)
+		data := model.PageData{Surat: surat} // Kirim kembali data yang sudah diisi pengguna
+		setFormError(&data, err)
+		h.renderSuratForm(w, r, http.StatusBadRequest, data)
 		return
 	}
 
@@ -99,7 +256,6 @@ func (h *Handler) SuratCreate(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, fmt.Sprintf("/surat?status=success_create&new_id=%d", createdSurat.ID), http.StatusSeeOther)
 }
 
-
 // SuratFormEdit menampilkan form yang sudah terisi data untuk diubah
 func (h *Handler) SuratFormEdit(w http.ResponseWriter, r *http.Request) {
 	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
@@ -108,8 +264,15 @@ func (h *Handler) SuratFormEdit(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Surat tidak ditemukan", http.StatusNotFound)
 		return
 	}
-	data := model.PageData{Surat: surat}
-	h.render(w, "surat_form.html", data)
+	if surat.IsDibatalkan() {
+		http.Error(w, "Surat yang sudah dibatalkan tidak dapat diubah", http.StatusBadRequest)
+		return
+	}
+	if err := h.SuratService.CekBolehUbah(surat); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	h.renderSuratForm(w, r, http.StatusOK, model.PageData{Surat: surat})
 }
 
 // SuratUpdate memproses data dari form edit
@@ -126,22 +289,17 @@ func (h *Handler) SuratUpdate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if r.FormValue("pelapor_nama") == "" || r.FormValue("lokasi_hilang") == "" {
-		data := model.PageData{
-			Surat: existingSurat,
-			Error: "Nama Pelapor dan Lokasi Hilang wajib diisi.",
-		}
-		h.render(w, "surat_form.html", data)
-		return
-	}
-
 	tempatLahir := r.FormValue("tempat_lahir")
 	tanggalLahir := r.FormValue("tanggal_lahir")
 	ttl := fmt.Sprintf("%s, %s", tempatLahir, tanggalLahir)
 
 	surat := model.SuratKeteranganHilang{
 		ID:               id,
+		NomorSurat:       existingSurat.NomorSurat,
 		TanggalSurat:     existingSurat.TanggalSurat,
+		KantorID:         existingSurat.KantorID,
+		Revisi:           existingSurat.Revisi,
+		PelaporNIK:       r.FormValue("pelapor_nik"),
 		PelaporNama:      r.FormValue("pelapor_nama"),
 		PelaporTTL:       ttl,
 		PelaporAgama:     r.FormValue("pelapor_agama"),
@@ -149,6 +307,7 @@ func (h *Handler) SuratUpdate(w http.ResponseWriter, r *http.Request) {
 		PelaporPekerjaan: r.FormValue("pelapor_pekerjaan"),
 		PelaporAlamat:    r.FormValue("pelapor_alamat"),
 		LokasiHilang:     r.FormValue("lokasi_hilang"),
+		AlasanUbah:       r.FormValue("alasan"),
 	}
 
 	jenisBarangList := r.Form["barang_jenis[]"]
@@ -163,25 +322,249 @@ func (h *Handler) SuratUpdate(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	if err := h.Repo.UpdateSurat(&surat); err != nil {
-		http.Error(w, "Gagal mengupdate surat", http.StatusInternalServerError)
+	if err := h.SuratService.UpdateSurat(&surat, actorName(r)); err != nil {
+		// Kirim kembali isian pengguna agar tidak perlu mengetik ulang
+		data := model.PageData{Surat: &surat}
+		setFormError(&data, err)
+		h.renderSuratForm(w, r, http.StatusBadRequest, data)
 		return
 	}
 
 	http.Redirect(w, r, "/surat?status=success_update", http.StatusSeeOther)
 }
 
-// SuratDelete menghapus surat
-func (h *Handler) SuratDelete(w http.ResponseWriter, r *http.Request) {
+// SuratDetail menampilkan isi surat beserta riwayat cetaknya
+func (h *Handler) SuratDetail(w http.ResponseWriter, r *http.Request) {
 	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
-	if err := h.Repo.DeleteSurat(id); err != nil {
-		http.Error(w, "Gagal menghapus surat", http.StatusInternalServerError)
+	surat, err := h.Repo.GetSuratByID(id)
+	if err != nil {
+		http.Error(w, "Surat tidak ditemukan", http.StatusNotFound)
+		return
+	}
+	registri, err := h.BarangService.Registri()
+	if err != nil {
+		http.Error(w, "Gagal mengambil jenis barang", http.StatusInternalServerError)
+		return
+	}
+	riwayat, err := h.Repo.GetRiwayatCetak(id)
+	if err != nil {
+		http.Error(w, "Gagal mengambil riwayat cetak", http.StatusInternalServerError)
 		return
 	}
-	http.Redirect(w, r, "/surat?status=success_delete", http.StatusSeeOther)
+	h.render(w, r, "surat_detail.html", map[string]interface{}{
+		"Surat":      surat,
+		"Registri":   registri,
+		"Cetak":      riwayat,
+		"NamaKantor": h.petaNamaKantor(),
+	})
 }
 
-// SuratPrint menampilkan halaman siap cetak
+// SuratRevisi menampilkan riwayat revisi surat dan perbandingan dua revisi berdampingan.
+// Parameter dari dan ke memilih revisi yang dibandingkan; bawaannya revisi terakhir dengan sebelumnya.
+func (h *Handler) SuratRevisi(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	surat, err := h.Repo.GetSuratByID(id)
+	if err != nil {
+		http.Error(w, "Surat tidak ditemukan", http.StatusNotFound)
+		return
+	}
+	daftar, err := h.Repo.GetRevisiSurat(id)
+	if err != nil || len(daftar) == 0 {
+		http.Error(w, "Gagal mengambil revisi surat", http.StatusInternalServerError)
+		return
+	}
+	registri, err := h.BarangService.Registri()
+	if err != nil {
+		http.Error(w, "Gagal mengambil jenis barang", http.StatusInternalServerError)
+		return
+	}
+
+	cari := func(param string, bawaan int) *model.RevisiSurat {
+		nomor, err := strconv.Atoi(r.URL.Query().Get(param))
+		if err != nil {
+			nomor = bawaan
+		}
+		for i := range daftar {
+			if daftar[i].Revisi == nomor {
+				return &daftar[i]
+			}
+		}
+		return nil
+	}
+	terakhir := daftar[len(daftar)-1].Revisi
+	ke := cari("ke", terakhir)
+	dari := cari("dari", terakhir-1)
+	if ke == nil {
+		ke = &daftar[len(daftar)-1]
+	}
+	if dari == nil {
+		dari = &daftar[0]
+	}
+
+	data := map[string]interface{}{
+		"Surat":  surat,
+		"Revisi": daftar,
+		"Dari":   dari,
+		"Ke":     ke,
+		"Beda":   model.BandingkanRevisi(dari, ke, registri),
+	}
+	if err := h.SuratService.CekBolehUbah(surat); err != nil {
+		data["Terkunci"] = err.Error()
+	}
+	h.render(w, r, "surat_revisi.html", data)
+}
+
+// SuratCancelForm menampilkan formulir pembatalan surat
+func (h *Handler) SuratCancelForm(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	surat, err := h.Repo.GetSuratByID(id)
+	if err != nil {
+		http.Error(w, "Surat tidak ditemukan", http.StatusNotFound)
+		return
+	}
+	h.renderCancelForm(w, r, surat, "")
+}
+
+// SuratCancel membatalkan surat. Surat tidak dihapus agar nomornya tetap tercatat.
+func (h *Handler) SuratCancel(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Gagal mem-parsing form", http.StatusBadRequest)
+		return
+	}
+	surat, err := h.Repo.GetSuratByID(id)
+	if err != nil {
+		http.Error(w, "Surat tidak ditemukan", http.StatusNotFound)
+		return
+	}
+
+	petugasID, _ := strconv.Atoi(r.FormValue("petugas_id"))
+	if err := h.SuratService.CancelSurat(id, r.FormValue("alasan"), petugasID, actorName(r)); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		h.renderCancelForm(w, r, surat, err.Error())
+		return
+	}
+	http.Redirect(w, r, "/surat?status=success_cancel", http.StatusSeeOther)
+}
+
+func (h *Handler) renderCancelForm(w http.ResponseWriter, r *http.Request, surat *model.SuratKeteranganHilang, errMsg string) {
+	petugasList, _ := h.Repo.GetAllPetugas(surat.KantorID)
+	data := map[string]interface{}{
+		"Surat":       surat,
+		"PetugasList": petugasList,
+		"Alasan":      r.FormValue("alasan"),
+		"Error":       errMsg,
+	}
+	h.render(w, r, "surat_batal.html", data)
+}
+
+// SuratPerpanjangForm menampilkan konfirmasi penerbitan surat perpanjangan
+func (h *Handler) SuratPerpanjangForm(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	surat, err := h.Repo.GetSuratByID(id)
+	if err != nil {
+		http.Error(w, "Surat tidak ditemukan", http.StatusNotFound)
+		return
+	}
+	h.renderPerpanjangForm(w, r, surat, "")
+}
+
+// SuratPerpanjang menerbitkan surat baru yang menunjuk ke surat asal. Surat asal tidak diubah.
+func (h *Handler) SuratPerpanjang(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	baru, err := h.SuratService.PerpanjangSurat(id, actorName(r))
+	if err != nil {
+		surat, errGet := h.Repo.GetSuratByID(id)
+		if errGet != nil {
+			http.Error(w, "Surat tidak ditemukan", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		h.renderPerpanjangForm(w, r, surat, err.Error())
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/surat?status=success_perpanjang&new_id=%d", baru.ID), http.StatusSeeOther)
+}
+
+func (h *Handler) renderPerpanjangForm(w http.ResponseWriter, r *http.Request, surat *model.SuratKeteranganHilang, errMsg string) {
+	pengaturan, err := h.Repo.GetPengaturan(surat.KantorID)
+	if err != nil {
+		http.Error(w, "Gagal mengambil pengaturan", http.StatusInternalServerError)
+		return
+	}
+	data := map[string]interface{}{
+		"Surat":           surat,
+		"MasaBerlakuHari": pengaturan.MasaBerlakuHari,
+		"Error":           errMsg,
+	}
+	h.render(w, r, "surat_perpanjang.html", data)
+}
+
+// pengaturanSurat mengembalikan pengaturan kantor penerbit untuk mencetak surat, dengan kop surat
+// dan identitas kantor dari revisi yang aktif saat surat diterbitkan
+func (h *Handler) pengaturanSurat(surat *model.SuratKeteranganHilang) (*model.Pengaturan, error) {
+	pengaturan, err := h.Repo.GetPengaturan(surat.KantorID)
+	if err != nil || surat.RevisiID == 0 {
+		return pengaturan, err
+	}
+	revisi, err := h.Repo.GetRevisiPengaturan(surat.RevisiID)
+	if err != nil {
+		return nil, err
+	}
+	return pengaturan.DenganRevisi(revisi), nil
+}
+
+// SuratPrintForm menampilkan konfirmasi cetak surat. Surat baru dicatat sebagai tercetak saat form
+// dikirim (SuratPrint), jadi membuka halaman ini tidak mengubah riwayat cetak.
+func (h *Handler) SuratPrintForm(w http.ResponseWriter, r *http.Request) {
+	h.cetakForm(w, r, model.CetakHalaman)
+}
+
+// SuratPDFForm menampilkan konfirmasi unduh PDF surat
+func (h *Handler) SuratPDFForm(w http.ResponseWriter, r *http.Request) {
+	h.cetakForm(w, r, model.CetakPDF)
+}
+
+func (h *Handler) cetakForm(w http.ResponseWriter, r *http.Request, format string) {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	surat, err := h.Repo.GetSuratByID(id)
+	if err != nil {
+		http.Error(w, "Surat tidak ditemukan", http.StatusNotFound)
+		return
+	}
+	h.renderCetakForm(w, r, surat, format, "")
+}
+
+func (h *Handler) renderCetakForm(w http.ResponseWriter, r *http.Request, surat *model.SuratKeteranganHilang, format, errMsg string) {
+	riwayat, err := h.Repo.GetRiwayatCetak(surat.ID)
+	if err != nil {
+		http.Error(w, "Gagal mengambil riwayat cetak", http.StatusInternalServerError)
+		return
+	}
+	data := map[string]interface{}{
+		"Surat":   surat,
+		"Riwayat": riwayat,
+		"Format":  format,
+		"Aksi":    r.URL.Path,
+		"Alasan":  r.PostFormValue("alasan"),
+		"Error":   errMsg,
+	}
+	h.render(w, r, "surat_cetak.html", data)
+}
+
+// writeCetakError menampilkan kembali form cetak bila alasan cetak ulang belum diisi, selain itu 500
+func (h *Handler) writeCetakError(w http.ResponseWriter, r *http.Request, surat *model.SuratKeteranganHilang, format string, err error) {
+	if errors.Is(err, repository.ErrAlasanCetakUlang) {
+		w.WriteHeader(http.StatusBadRequest)
+		h.renderCetakForm(w, r, surat, format, "Alasan cetak ulang wajib diisi.")
+		return
+	}
+	log.Printf("Gagal mencetak surat %d: %v", surat.ID, err)
+	http.Error(w, "Gagal mencetak surat", http.StatusInternalServerError)
+}
+
+// SuratPrint membuat halaman siap cetak lalu mencatatnya di riwayat cetak. Cetakan hanya dicatat
+// bila halaman berhasil dibuat.
 func (h *Handler) SuratPrint(w http.ResponseWriter, r *http.Request) {
 	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
 
@@ -190,64 +573,216 @@ func (h *Handler) SuratPrint(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Surat tidak ditemukan", http.StatusNotFound)
 		return
 	}
-	pengaturan, err := h.Repo.GetPengaturan()
+	pengaturan, err := h.pengaturanSurat(surat)
 	if err != nil {
 		http.Error(w, "Gagal mengambil pengaturan untuk cetak", http.StatusInternalServerError)
 		return
 	}
 
+	registri, err := h.BarangService.Registri()
+	if err != nil {
+		http.Error(w, "Gagal mengambil jenis barang untuk cetak", http.StatusInternalServerError)
+		return
+	}
+
+	qr, err := h.verifikasiQRDataURI(surat)
+	if err != nil {
+		http.Error(w, "Gagal membuat QR code verifikasi", http.StatusInternalServerError)
+		return
+	}
+
 	data := map[string]interface{}{
 		"Surat":      surat,
 		"Pengaturan": pengaturan,
+		"Registri":   registri,
+		"QRCode":     qr,
 	}
 
-	h.renderPrint(w, "surat_print.html", data)
+	// Halaman disusun di buffer dulu: nomor salinannya baru diketahui saat cetakan dicatat
+	var buf bytes.Buffer
+	_, err = h.SuratService.CatatCetak(surat, model.CetakHalaman, r.PostFormValue("alasan"), actorName(r), func(c *model.CetakSurat) error {
+		data["Cetak"] = c
+		buf.Reset()
+		return h.executePrint(&buf, r, "surat_print.html", data)
+	})
+	if err != nil {
+		h.writeCetakError(w, r, surat, model.CetakHalaman, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	buf.WriteTo(w)
+}
+
+// SuratPDF membuat surat dalam bentuk PDF ukuran legal di server lalu mencatatnya di riwayat cetak
+func (h *Handler) SuratPDF(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+
+	surat, err := h.Repo.GetSuratByID(id)
+	if err != nil {
+		http.Error(w, "Surat tidak ditemukan", http.StatusNotFound)
+		return
+	}
+	pengaturan, err := h.pengaturanSurat(surat)
+	if err != nil {
+		http.Error(w, "Gagal mengambil pengaturan untuk cetak", http.StatusInternalServerError)
+		return
+	}
+
+	registri, err := h.BarangService.Registri()
+	if err != nil {
+		http.Error(w, "Gagal mengambil jenis barang untuk cetak", http.StatusInternalServerError)
+		return
+	}
+
+	qr, err := h.verifikasiQRCode(surat)
+	if err != nil {
+		http.Error(w, "Gagal membuat QR code verifikasi", http.StatusInternalServerError)
+		return
+	}
+
+	// PDF disusun di buffer dulu supaya error masih bisa dikirim sebagai status 500
+	var buf bytes.Buffer
+	_, err = h.SuratService.CatatCetak(surat, model.CetakPDF, r.PostFormValue("alasan"), actorName(r), func(c *model.CetakSurat) error {
+		buf.Reset()
+		return h.PDFService.RenderSurat(&buf, surat, pengaturan, registri, qr, c)
+	})
+	if err != nil {
+		h.writeCetakError(w, r, surat, model.CetakPDF, err)
+		return
+	}
+
+	namaFile := fmt.Sprintf("surat_%d.pdf", surat.ID)
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", namaFile))
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	buf.WriteTo(w)
 }
 
-// PengaturanForm menampilkan halaman pengaturan
+// PengaturanForm menampilkan halaman pengaturan kantor ?kantor= (bawaan kantor utama)
 func (h *Handler) PengaturanForm(w http.ResponseWriter, r *http.Request) {
-	pengaturan, err := h.Repo.GetPengaturan()
+	pengaturan, err := h.Repo.GetPengaturan(kantorPengaturan(r, r.URL.Query().Get("kantor")))
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Kantor tidak ditemukan", http.StatusNotFound)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Gagal mengambil data pengaturan", http.StatusInternalServerError)
 		return
 	}
+	h.renderPengaturanForm(w, r, pengaturan, "", "", "")
+}
 
-	totalSurat, _ := h.Repo.GetTotalSurat()
-	if totalSurat == 0 {
-		pengaturan.LastNomorSurat = 0
+// kantorPengaturan mengubah isian kantor di halaman pengaturan menjadi ID kantor; kosong atau tidak
+// valid berarti kantor utama. Admin yang dibatasi ke satu kantor hanya mengatur kantornya sendiri.
+func kantorPengaturan(r *http.Request, v string) int {
+	if user := CurrentUser(r); user != nil && user.KantorID != 0 {
+		return user.KantorID
 	}
+	id, err := strconv.Atoi(v)
+	if err != nil || id <= 0 {
+		return model.KantorUtama
+	}
+	return id
+}
 
-	pejabatList, _ := h.Repo.GetPetugasByTipe("Pejabat")
-	penerimaList, _ := h.Repo.GetPetugasByTipe("Penerima")
+// renderPengaturanForm dipakai oleh PengaturanForm, saat validasi PengaturanUpdate gagal, saat
+// pencadangan atau pemulihan gagal (backupErr) dan saat penambahan kantor gagal (kantorErr)
+func (h *Handler) renderPengaturanForm(w http.ResponseWriter, r *http.Request, pengaturan *model.Pengaturan, errMsg, backupErr, kantorErr string) {
+	// Nomor terakhir diambil dari penghitung periode berjalan (tahun atau bulan ini).
+	// Saat validasi gagal, nilai yang diketik admin tetap ditampilkan.
+	nomorAwal, _ := h.PengaturanService.NomorTerakhir(pengaturan)
+	if totalSurat, _ := h.Repo.GetTotalSurat(pengaturan.ID); totalSurat == 0 {
+		nomorAwal = 0
+	}
+	if errMsg == "" {
+		pengaturan.LastNomorSurat = nomorAwal
+	}
+	preview, previewErr := h.PengaturanService.PreviewNomor(pengaturan.ID, pengaturan.FormatNomorSurat, pengaturan.ResetNomor, pengaturan.KodeKantor)
+
+	pejabatList, _ := h.Repo.GetPetugasByTipe("Pejabat", pengaturan.ID)
+	penerimaList, _ := h.Repo.GetPetugasByTipe("Penerima", pengaturan.ID)
+	// Cadangan hanya ditampilkan kepada admin semua kantor
+	var backupList []model.ArsipBackup
+	if user := CurrentUser(r); user != nil && user.KantorID == 0 {
+		var err error
+		if backupList, err = h.BackupService.Daftar(); err != nil {
+			log.Printf("Gagal membaca daftar cadangan: %v", err)
+		}
+	}
 	data := map[string]interface{}{
 		"Pengaturan":   pengaturan,
 		"PejabatList":  pejabatList,
 		"PenerimaList": penerimaList,
 		"Timestamp":    time.Now().Unix(),
+		"NomorAwal":    nomorAwal,
+		"Preview":      preview,
+		"PreviewError": previewErr,
+		"Error":        errMsg,
+		"BackupList":   backupList,
+		"BackupFolder": h.BackupService.Folder(),
+		"BackupJadwal": h.BackupService.Jadwal,
+		"BackupError":  backupErr,
+		"DaftarKantor": h.daftarKantorPilihan(r),
+		"KantorError":  kantorErr,
 	}
-	h.render(w, "pengaturan.html", data)
+	h.render(w, r, "pengaturan.html", data)
 }
 
-// PengaturanUpdate menyimpan perubahan dari form pengaturan
-
-func (h *Handler) PengaturanUpdate(w http.ResponseWriter, r *http.Request) {
-	// 1. Ambil data pengaturan yang sudah ada dari database
-	p, err := h.Repo.GetPengaturan() // Boleh tetap panggil repo untuk get data awal
+// PengaturanRiwayat menampilkan semua revisi kop surat dan identitas kantor ?kantor= beserta jumlah
+// surat yang diterbitkan dengan setiap revisi
+func (h *Handler) PengaturanRiwayat(w http.ResponseWriter, r *http.Request) {
+	riwayat, err := h.Repo.GetRiwayatPengaturan(kantorPengaturan(r, r.URL.Query().Get("kantor")))
 	if err != nil {
-		http.Error(w, "Gagal mengambil pengaturan yang ada", http.StatusInternalServerError)
+		log.Printf("Gagal mengambil riwayat pengaturan: %v", err)
+		http.Error(w, "Gagal mengambil riwayat pengaturan", http.StatusInternalServerError)
 		return
 	}
+	h.render(w, r, "pengaturan_riwayat.html", riwayat)
+}
 
-	// 2. Parsing form (termasuk file)
+// PengaturanPreviewNomor mengembalikan contoh nomor surat berikutnya dalam JSON untuk pratinjau langsung
+func (h *Handler) PengaturanPreviewNomor(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	result := map[string]string{}
+	nomor, err := h.PengaturanService.PreviewNomor(kantorPengaturan(r, q.Get("kantor")), q.Get("format"), q.Get("reset"), q.Get("kode"))
+	if err != nil {
+		result["error"] = err.Error()
+	} else {
+		result["nomor"] = nomor
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// PengaturanUpdate menyimpan perubahan dari form pengaturan
+
+func (h *Handler) PengaturanUpdate(w http.ResponseWriter, r *http.Request) {
+	// 1. Parsing form (termasuk file)
 	if err := r.ParseMultipartForm(10 << 20); err != nil { // 10 MB max
 		http.Error(w, "Gagal mem-parsing form", http.StatusBadRequest)
 		return
 	}
-	
+
+	// 2. Ambil data pengaturan kantor yang sedang diedit dari database
+	p, err := h.Repo.GetPengaturan(kantorPengaturan(r, r.FormValue("kantor_id")))
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Kantor tidak ditemukan", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Gagal mengambil pengaturan yang ada", http.StatusInternalServerError)
+		return
+	}
+
 	// 3. Kumpulkan data dari form ke struct
 	pejabatID, _ := strconv.Atoi(r.FormValue("pejabat_id"))
 	penerimaID, _ := strconv.Atoi(r.FormValue("penerima_id"))
 	lastNomor, _ := strconv.Atoi(r.FormValue("last_nomor_surat"))
+	lastNomorAwal, _ := strconv.Atoi(r.FormValue("last_nomor_surat_awal"))
+	masaBerlaku, _ := strconv.Atoi(r.FormValue("masa_berlaku_hari"))
+	batasBerulang, _ := strconv.Atoi(r.FormValue("batas_laporan_berulang"))
+	periodeBerulang, _ := strconv.Atoi(r.FormValue("periode_laporan_berulang"))
+	batasUbah, _ := strconv.Atoi(r.FormValue("batas_ubah_jam"))
 
 	p.KopSurat1 = r.FormValue("kop_surat_1")
 	p.KopSurat2 = r.FormValue("kop_surat_2")
@@ -255,7 +790,18 @@ func (h *Handler) PengaturanUpdate(w http.ResponseWriter, r *http.Request) {
 	p.FormatNomorSurat = r.FormValue("format_nomor_surat")
 	p.Wilayah = r.FormValue("wilayah")
 	p.NamaKantor = r.FormValue("nama_kantor")
-	p.LastNomorSurat = lastNomor
+	p.KodeKantor = r.FormValue("kode_kantor")
+	p.ResetNomor = r.FormValue("reset_nomor")
+	p.MasaBerlakuHari = masaBerlaku
+	p.BatasLaporanBerulang = batasBerulang
+	p.PeriodeLaporanBerulang = periodeBerulang
+	p.BatasUbahJam = batasUbah
+	// Nomor terakhir hanya diubah jika admin benar-benar mengganti nilainya,
+	// agar form yang dibuka sebelum ada surat baru tidak memundurkan penomoran
+	if lastNomor != lastNomorAwal {
+		p.LastNomorSurat = lastNomor
+		p.UbahNomorTerakhir = true
+	}
 	p.PejabatID = pejabatID
 	p.PenerimaID = penerimaID
 
@@ -267,11 +813,12 @@ func (h *Handler) PengaturanUpdate(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 4. Panggil Service untuk menjalankan SEMUA logika
-	if _, err := h.PengaturanService.UpdatePengaturan(p, file, handler); err != nil {
-		http.Error(w, "Gagal menyimpan pengaturan: "+err.Error(), http.StatusInternalServerError)
+	if _, err := h.PengaturanService.UpdatePengaturan(p, file, handler, actorName(r)); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		h.renderPengaturanForm(w, r, p, "Gagal menyimpan pengaturan: "+err.Error(), "", "")
 		return
 	}
 
 	// 5. Jika berhasil, redirect
-	http.Redirect(w, r, "/pengaturan?status=success_update", http.StatusSeeOther)
+	http.Redirect(w, r, fmt.Sprintf("/pengaturan?kantor=%d&status=success_update", p.ID), http.StatusSeeOther)
 }
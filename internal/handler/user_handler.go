@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"skh_app/internal/model"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// userKelolaan mengambil pengguna id yang boleh dikelola admin yang sedang login. Admin yang dibatasi
+// ke satu kantor hanya mengelola pengguna kantornya; pengguna semua kantor, termasuk admin semua
+// kantor, dianggap tidak ada baginya. Mengembalikan false setelah menulis error 404/500.
+func (h *Handler) userKelolaan(w http.ResponseWriter, r *http.Request, id int) (*model.User, bool) {
+	u, err := h.AuthService.GetUserByID(id)
+	if err == nil && !CurrentUser(r).BolehAksesKantor(u.KantorID) {
+		err = sql.ErrNoRows
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Data pengguna tidak ditemukan", http.StatusNotFound)
+		return nil, false
+	}
+	if err != nil {
+		log.Printf("Gagal mengambil pengguna %d: %v", id, err)
+		http.Error(w, "Gagal mengambil data pengguna", http.StatusInternalServerError)
+		return nil, false
+	}
+	return u, true
+}
+
+func (h *Handler) UserList(w http.ResponseWriter, r *http.Request) {
+	semua, err := h.AuthService.GetAllUsers()
+	if err != nil {
+		http.Error(w, "Gagal mengambil data pengguna", http.StatusInternalServerError)
+		return
+	}
+	current := CurrentUser(r)
+	users := semua[:0]
+	for _, u := range semua {
+		if current.BolehAksesKantor(u.KantorID) {
+			users = append(users, u)
+		}
+	}
+	h.render(w, r, "user_list.html", map[string]interface{}{
+		"Users":      users,
+		"NamaKantor": h.petaNamaKantor(),
+	})
+}
+
+func (h *Handler) UserFormNew(w http.ResponseWriter, r *http.Request) {
+	data := map[string]interface{}{
+		"User":         &model.User{Role: model.RoleOperator, Aktif: true, KantorID: kantorIsian(r, 0)},
+		"DaftarKantor": h.daftarKantorPilihan(r),
+	}
+	h.render(w, r, "user_form.html", data)
+}
+
+func (h *Handler) UserCreate(w http.ResponseWriter, r *http.Request) {
+	h.saveUser(w, r, &model.User{})
+}
+
+func (h *Handler) UserFormEdit(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	u, ok := h.userKelolaan(w, r, id)
+	if !ok {
+		return
+	}
+	data := map[string]interface{}{
+		"User":         u,
+		"DaftarKantor": h.daftarKantorPilihan(r),
+	}
+	h.render(w, r, "user_form.html", data)
+}
+
+func (h *Handler) UserUpdate(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	u, ok := h.userKelolaan(w, r, id)
+	if !ok {
+		return
+	}
+	h.saveUser(w, r, u)
+}
+
+// saveUser dipakai bersama oleh UserCreate dan UserUpdate
+func (h *Handler) saveUser(w http.ResponseWriter, r *http.Request, u *model.User) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Gagal parsing form", http.StatusBadRequest)
+		return
+	}
+	u.Username = r.FormValue("username")
+	u.NamaLengkap = r.FormValue("nama_lengkap")
+	u.Role = r.FormValue("role")
+	u.Aktif = r.FormValue("aktif") == "1"
+	kantorID, _ := strconv.Atoi(r.FormValue("kantor_id"))
+	u.KantorID = kantorIsian(r, kantorID)
+
+	// Admin tidak boleh menurunkan peran, membatasi kantor atau menonaktifkan akunnya sendiri
+	if current := CurrentUser(r); current != nil && current.ID == u.ID {
+		u.Role = current.Role
+		u.KantorID = current.KantorID
+		u.Aktif = true
+	}
+
+	if err := h.AuthService.SaveUser(u, r.FormValue("password")); err != nil {
+		data := map[string]interface{}{
+			"User":         u,
+			"DaftarKantor": h.daftarKantorPilihan(r),
+			"Error":        err.Error(),
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		h.render(w, r, "user_form.html", data)
+		return
+	}
+	http.Redirect(w, r, "/pengguna?status=success_update", http.StatusSeeOther)
+}
+
+func (h *Handler) UserDelete(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	if current := CurrentUser(r); current != nil && current.ID == id {
+		http.Error(w, "Tidak dapat menghapus akun sendiri", http.StatusBadRequest)
+		return
+	}
+	if _, ok := h.userKelolaan(w, r, id); !ok {
+		return
+	}
+	if err := h.AuthService.DeleteUser(id); err != nil {
+		http.Error(w, "Gagal menghapus data pengguna", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/pengguna?status=success_delete", http.StatusSeeOther)
+}
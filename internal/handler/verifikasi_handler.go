@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"encoding/base64"
+	"html/template"
+	"net/http"
+	"skh_app/internal/model"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// qrSize adalah ukuran QR code verifikasi dalam piksel
+const qrSize = 256
+
+// verifikasiURL mengembalikan alamat publik untuk memeriksa keaslian surat. Alamatnya disusun dari
+// public_url di konfigurasi, bukan dari header request yang bisa diisi sembarang oleh pengirim.
+// Hasilnya kosong bila public_url belum diatur.
+func (h *Handler) verifikasiURL(surat *model.SuratKeteranganHilang) string {
+	if h.PublicURL == "" {
+		return ""
+	}
+	return h.PublicURL + "/verifikasi/" + h.VerifikasiService.Token(surat)
+}
+
+// verifikasiQRCode menghasilkan PNG QR code yang menunjuk ke halaman verifikasi surat. Tanpa
+// public_url QR code tidak dibuat (hasilnya nil) dan surat dicetak tanpa QR code.
+func (h *Handler) verifikasiQRCode(surat *model.SuratKeteranganHilang) ([]byte, error) {
+	url := h.verifikasiURL(surat)
+	if url == "" {
+		return nil, nil
+	}
+	return h.VerifikasiService.QRCode(url, qrSize)
+}
+
+// verifikasiQRDataURI sama dengan verifikasiQRCode tetapi dalam bentuk data URI untuk tag <img>
+func (h *Handler) verifikasiQRDataURI(surat *model.SuratKeteranganHilang) (template.URL, error) {
+	png, err := h.verifikasiQRCode(surat)
+	if err != nil || png == nil {
+		return "", err
+	}
+	return template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(png)), nil
+}
+
+// Verifikasi adalah halaman publik (tanpa login) untuk memeriksa keaslian surat dari QR code
+func (h *Handler) Verifikasi(w http.ResponseWriter, r *http.Request) {
+	hasil, err := h.VerifikasiService.Verifikasi(chi.URLParam(r, "token"))
+	if err != nil {
+		http.Error(w, "Gagal memeriksa surat", http.StatusInternalServerError)
+		return
+	}
+
+	// Hasil verifikasi tidak boleh disimpan cache atau diindeks mesin pencari
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Robots-Tag", "noindex")
+	if hasil.Status == model.VerifikasiTidakDitemukan {
+		w.WriteHeader(http.StatusNotFound)
+	}
+
+	h.renderPrint(w, r, "verifikasi.html", map[string]interface{}{
+		"Hasil": hasil,
+	})
+}
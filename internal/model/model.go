@@ -1,6 +1,13 @@
 package model
 
-import "time"
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
 
 // Petugas menyimpan data petugas kepolisian
 type Petugas struct {
@@ -10,9 +17,76 @@ type Petugas struct {
 	NRP     string `db:"nrp"`
 	Jabatan string `db:"jabatan"`
 	Tipe    string `db:"tipe"` // Tipe: "Pejabat" atau "Penerima"
+	// KantorID adalah kantor tempat petugas bertugas, 0 berarti dapat dipilih di semua kantor
+	KantorID int `db:"kantor_id"`
 }
 
-// Pengaturan menyimpan semua konfigurasi aplikasi
+// BertugasDi memeriksa apakah petugas dapat dipilih untuk surat kantor kantorID
+func (p *Petugas) BertugasDi(kantorID int) bool {
+	return p.KantorID == 0 || p.KantorID == kantorID
+}
+
+// KantorUtama adalah kantor yang sudah ada sebelum aplikasi mendukung banyak kantor. Kop suratnya
+// dipakai untuk laporan gabungan semua kantor.
+const KantorUtama = 1
+
+// Kantor adalah ringkasan satu kantor (satu baris pengaturan) untuk pilihan dan filter
+type Kantor struct {
+	ID   int    `db:"id"`
+	Nama string `db:"nama_kantor"`
+	Kode string `db:"kode_kantor"`
+}
+
+// Pelapor adalah data induk pelapor dengan NIK sebagai kunci. Surat menyimpan salinan data
+// pelapor seperti saat surat dibuat; data di sini adalah yang terakhir dilaporkan.
+type Pelapor struct {
+	ID        int       `db:"id"`
+	NIK       string    `db:"nik"`
+	Nama      string    `db:"nama"`
+	TTL       string    `db:"ttl"`
+	Agama     string    `db:"agama"`
+	Kelamin   string    `db:"kelamin"`
+	Pekerjaan string    `db:"pekerjaan"`
+	Alamat    string    `db:"alamat"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+
+	// Kolom turunan untuk daftar dan pencarian pelapor
+	JumlahSurat   int
+	SuratTerakhir time.Time
+}
+
+// LaporanBerulang adalah jumlah surat seorang pelapor untuk satu jenis barang dalam periode pengawasan
+type LaporanBerulang struct {
+	JenisBarang string
+	Jumlah      int
+	Melewati    bool // Jumlah lebih dari batas laporan berulang
+}
+
+// RevisiPengaturan adalah salinan kop surat dan identitas kantor yang tercetak di surat.
+// Revisi baru dibuat setiap kali salah satu isinya diubah; revisi lama tidak pernah diubah.
+type RevisiPengaturan struct {
+	ID         int       `db:"id"`
+	KantorID   int       `db:"kantor_id"`
+	KopSurat1  string    `db:"kop_surat_1"`
+	KopSurat2  string    `db:"kop_surat_2"`
+	KopSurat3  string    `db:"kop_surat_3"`
+	LogoPath   string    `db:"logo_path"`
+	Wilayah    string    `db:"wilayah"`
+	NamaKantor string    `db:"nama_kantor"`
+	DibuatOleh string    `db:"dibuat_oleh"`
+	CreatedAt  time.Time `db:"created_at"`
+
+	JumlahSurat int // surat yang diterbitkan dengan revisi ini
+}
+
+// SamaDengan memeriksa apakah isi tercetak revisi sama dengan pengaturan p
+func (r *RevisiPengaturan) SamaDengan(p *Pengaturan) bool {
+	return r.KopSurat1 == p.KopSurat1 && r.KopSurat2 == p.KopSurat2 && r.KopSurat3 == p.KopSurat3 &&
+		r.LogoPath == p.LogoPath && r.Wilayah == p.Wilayah && r.NamaKantor == p.NamaKantor
+}
+
+// Pengaturan menyimpan konfigurasi satu kantor; ID-nya adalah ID kantor
 type Pengaturan struct {
 	ID               int    `db:"id"`
 	KopSurat1        string `db:"kop_surat_1"`
@@ -26,9 +100,90 @@ type Pengaturan struct {
 	PenerimaID       int    `db:"penerima_id"`
 	Wilayah          string `db:"wilayah"`
 	NamaKantor       string `db:"nama_kantor"`
+	KodeKantor       string `db:"kode_kantor"`
+	ResetNomor       string `db:"reset_nomor"` // ResetTahunan atau ResetBulanan
+	MasaBerlakuHari  int    `db:"masa_berlaku_hari"`
+	// Surat ditandai bila pelapor melaporkan jenis barang yang sama lebih dari BatasLaporanBerulang
+	// kali dalam PeriodeLaporanBerulang hari. Batas 0 mematikan penandaan.
+	BatasLaporanBerulang   int `db:"batas_laporan_berulang"`
+	PeriodeLaporanBerulang int `db:"periode_laporan_berulang"`
+	// Surat hanya dapat diubah selama BatasUbahJam jam sejak terbit; 0 berarti tanpa batas
+	BatasUbahJam int `db:"batas_ubah_jam"`
 
 	PejabatDetails  *Petugas
 	PenerimaDetails *Petugas
+
+	// UbahNomorTerakhir diisi jika admin mengubah nomor terakhir secara manual;
+	// nilainya lalu disimpan ke penghitung periode NomorPeriode.
+	UbahNomorTerakhir bool
+	NomorPeriode      string
+}
+
+// DenganRevisi mengembalikan salinan pengaturan dengan kop surat dan identitas kantor dari revisi,
+// untuk mencetak ulang surat persis seperti saat diterbitkan. Revisi nil mengembalikan salinan apa adanya.
+func (p *Pengaturan) DenganRevisi(r *RevisiPengaturan) *Pengaturan {
+	salinan := *p
+	if r != nil {
+		salinan.KopSurat1, salinan.KopSurat2, salinan.KopSurat3 = r.KopSurat1, r.KopSurat2, r.KopSurat3
+		salinan.LogoPath, salinan.Wilayah, salinan.NamaKantor = r.LogoPath, r.Wilayah, r.NamaKantor
+	}
+	return &salinan
+}
+
+// Filter status yang dapat dipakai saat mencari surat. "aktif" dan "dibatalkan" sesuai kolom status,
+// "berlaku" dan "kedaluwarsa" adalah surat aktif yang masa berlakunya belum atau sudah habis.
+const (
+	FilterBerlaku     = "berlaku"
+	FilterKedaluwarsa = "kedaluwarsa"
+)
+
+// Kolom urut daftar surat. UrutRelevansi hanya berlaku jika ada teks pencarian.
+const (
+	UrutTanggal   = "tanggal"
+	UrutNomor     = "nomor"
+	UrutPelapor   = "pelapor"
+	UrutRelevansi = "relevansi"
+)
+
+// SuratFilter menampung kriteria pencarian daftar surat
+type SuratFilter struct {
+	Query       string    // teks bebas: nomor surat, nama pelapor, lokasi hilang atau isian barang (NIK, nopol, dst)
+	Status      string    // StatusAktif, StatusDibatalkan, FilterBerlaku, FilterKedaluwarsa atau kosong
+	Dari        time.Time // tanggal surat inklusif, zero value berarti tanpa batas
+	Sampai      time.Time // tanggal surat eksklusif, zero value berarti tanpa batas
+	JenisBarang string    // hanya surat yang memuat barang jenis ini
+	PenerimaID  int       // hanya surat yang diterima petugas ini
+	KantorID    int       // hanya surat kantor ini, 0 berarti semua kantor
+
+	// Urutan dan paginasi keyset. Urut kosong berarti tanggal terbaru lebih dulu, atau relevansi jika
+	// Query diisi. Setelah/Sebelum adalah kursor dari HalamanSurat; Batas 0 berarti tanpa batas.
+	Urut    string
+	Naik    bool
+	Setelah string
+	Sebelum string
+	Batas   int
+}
+
+// HalamanSurat adalah satu halaman hasil CariSuratHalaman beserta kursor ke halaman sekitarnya.
+// Kursor kosong berarti tidak ada halaman ke arah tersebut.
+type HalamanSurat struct {
+	Surats     []SuratKeteranganHilang
+	Berikutnya string
+	Sebelumnya string
+}
+
+// Pilihan reset nomor urut surat
+const (
+	ResetTahunan = "tahunan"
+	ResetBulanan = "bulanan"
+)
+
+// NomorPeriode mengembalikan kunci penghitung nomor surat untuk waktu t, mis. "2025" atau "2025-08"
+func NomorPeriode(resetNomor string, t time.Time) string {
+	if resetNomor == ResetBulanan {
+		return t.Format("2006-01")
+	}
+	return t.Format("2006")
 }
 
 // SuratKeteranganHilang adalah data utama surat
@@ -45,6 +200,263 @@ type SuratKeteranganHilang struct {
 	LokasiHilang     string    `db:"lokasi_hilang"`
 	BarangHilang     []Barang
 	CreatedAt        time.Time `db:"created_at"`
+	Status           string    `db:"status"` // StatusAktif atau StatusDibatalkan
+	AlasanBatal      string    `db:"alasan_batal"`
+	DibatalkanOleh   string    `db:"dibatalkan_oleh"`
+	DibatalkanPada   time.Time `db:"dibatalkan_pada"`
+	BerlakuSampai    time.Time `db:"berlaku_sampai"` // hari terakhir surat berlaku (00:00 WITA)
+	SuratAsalID      int       `db:"surat_asal_id"`  // diisi jika surat ini perpanjangan dari surat lain
+	PejabatID        int       `db:"pejabat_id"`     // pejabat penandatangan saat surat dibuat
+	PenerimaID       int       `db:"penerima_id"`    // petugas penerima laporan saat surat dibuat
+	PelaporID        int       `db:"pelapor_id"`     // data induk pelapor, 0 bila NIK tidak diisi
+	PelaporNIK       string    // NIK pelapor, dibaca dari data induk pelapor
+	TandaBerulang    string    `db:"tanda_berulang"`       // peringatan laporan berulang saat surat dibuat
+	RevisiID         int       `db:"pengaturan_revisi_id"` // revisi kop surat yang aktif saat surat dibuat
+	KantorID         int       `db:"kantor_id"`            // kantor yang menerbitkan surat
+	Revisi           int       `db:"revisi"`               // nomor revisi isi surat, 1 untuk surat yang belum pernah diubah
+	AlasanUbah       string    // alasan perubahan, hanya diisi saat surat diubah
+	KodeVerifikasi   string    `db:"kode_verifikasi"` // kode acak yang ditandatangani token QR code, tidak berubah saat surat direvisi
+
+	// Identitas pejabat dan penerima seperti tercetak saat surat dibuat (kolom pejabat_* dan
+	// penerima_*), tidak ikut berubah jika pengaturan atau data petugas diganti
+	Pejabat  Petugas
+	Penerima Petugas
+
+	// Kolom turunan, diisi repository saat membaca surat
+	SuratAsalNomor    string
+	PerpanjanganID    int // surat perpanjangan aktif yang menggantikan surat ini
+	PerpanjanganNomor string
+	Kedaluwarsa       bool
+	Cuplikan          string // potongan teks yang cocok dengan pencarian, kata cocok diapit SorotAwal/SorotAkhir
+}
+
+// BatasUbah mengembalikan waktu terakhir surat masih boleh diubah jika perubahan dibatasi batasJam
+// jam sejak surat terbit. Hasilnya nol bila batasJam 0 (tanpa batas).
+func (s *SuratKeteranganHilang) BatasUbah(batasJam int) time.Time {
+	if batasJam <= 0 {
+		return time.Time{}
+	}
+	return s.TanggalSurat.Add(time.Duration(batasJam) * time.Hour)
+}
+
+// Terkunci memeriksa apakah masa ubah surat sudah lewat pada waktu now
+func (s *SuratKeteranganHilang) Terkunci(batasJam int, now time.Time) bool {
+	batas := s.BatasUbah(batasJam)
+	return !batas.IsZero() && now.After(batas)
+}
+
+// CekBolehUbah menolak perubahan bila masa ubah surat sudah lewat pada waktu now. Waktu batas
+// ditulis di zona waktu now.
+func (s *SuratKeteranganHilang) CekBolehUbah(batasJam int, now time.Time) error {
+	if s.Terkunci(batasJam, now) {
+		return fmt.Errorf("surat terkunci: masa ubah %d jam sejak terbit sudah lewat pada %s",
+			batasJam, s.BatasUbah(batasJam).In(now.Location()).Format("02-01-2006 15:04"))
+	}
+	return nil
+}
+
+// RevisiSurat adalah isi surat (data pelapor dan barang hilang) pada satu revisi. Revisi 1 adalah
+// isi saat surat diterbitkan; setiap perubahan menambah revisi baru dan revisi lama tidak pernah diubah.
+type RevisiSurat struct {
+	ID               int    `db:"id"`
+	SuratID          int    `db:"surat_id"`
+	Revisi           int    `db:"revisi"`
+	PelaporNIK       string `db:"pelapor_nik"`
+	PelaporNama      string `db:"pelapor_nama"`
+	PelaporTTL       string `db:"pelapor_ttl"`
+	PelaporAgama     string `db:"pelapor_agama"`
+	PelaporKelamin   string `db:"pelapor_kelamin"`
+	PelaporPekerjaan string `db:"pelapor_pekerjaan"`
+	PelaporAlamat    string `db:"pelapor_alamat"`
+	LokasiHilang     string `db:"lokasi_hilang"`
+	BarangHilang     []Barang
+	Alasan           string    `db:"alasan"` // alasan perubahan; kosong untuk revisi 1
+	DibuatOleh       string    `db:"dibuat_oleh"`
+	CreatedAt        time.Time `db:"created_at"`
+}
+
+// RevisiDariSurat menyalin isi surat yang dapat diubah menjadi revisi
+func RevisiDariSurat(s *SuratKeteranganHilang) *RevisiSurat {
+	return &RevisiSurat{
+		SuratID:          s.ID,
+		Revisi:           s.Revisi,
+		PelaporNIK:       s.PelaporNIK,
+		PelaporNama:      s.PelaporNama,
+		PelaporTTL:       s.PelaporTTL,
+		PelaporAgama:     s.PelaporAgama,
+		PelaporKelamin:   s.PelaporKelamin,
+		PelaporPekerjaan: s.PelaporPekerjaan,
+		PelaporAlamat:    s.PelaporAlamat,
+		LokasiHilang:     s.LokasiHilang,
+		BarangHilang:     s.BarangHilang,
+		Alasan:           s.AlasanUbah,
+	}
+}
+
+// BarisBeda adalah satu baris perbandingan dua revisi surat
+type BarisBeda struct {
+	Label string
+	Lama  string
+	Baru  string
+}
+
+// Beda bernilai true jika isi baris pada kedua revisi tidak sama
+func (b BarisBeda) Beda() bool { return b.Lama != b.Baru }
+
+// BandingkanRevisi menyusun perbandingan isi dua revisi per isian. Barang dibandingkan menurut
+// urutannya dan ditulis dengan uraian cetaknya dari registri.
+func BandingkanRevisi(lama, baru *RevisiSurat, registri RegistriBarang) []BarisBeda {
+	baris := []BarisBeda{
+		{"NIK", lama.PelaporNIK, baru.PelaporNIK},
+		{"Nama", lama.PelaporNama, baru.PelaporNama},
+		{"Tempat/Tgl. Lahir", lama.PelaporTTL, baru.PelaporTTL},
+		{"Jenis Kelamin", lama.PelaporKelamin, baru.PelaporKelamin},
+		{"Agama", lama.PelaporAgama, baru.PelaporAgama},
+		{"Pekerjaan", lama.PelaporPekerjaan, baru.PelaporPekerjaan},
+		{"Alamat", lama.PelaporAlamat, baru.PelaporAlamat},
+		{"Lokasi Hilang", lama.LokasiHilang, baru.LokasiHilang},
+	}
+	uraian := func(daftar []Barang, i int) string {
+		if i >= len(daftar) {
+			return ""
+		}
+		return daftar[i].JenisBarang + ": " + registri.Keterangan(daftar[i])
+	}
+	jumlah := len(lama.BarangHilang)
+	if len(baru.BarangHilang) > jumlah {
+		jumlah = len(baru.BarangHilang)
+	}
+	for i := 0; i < jumlah; i++ {
+		baris = append(baris, BarisBeda{fmt.Sprintf("Barang %d", i+1), uraian(lama.BarangHilang, i), uraian(baru.BarangHilang, i)})
+	}
+	return baris
+}
+
+// SamaDengan memeriksa apakah isi dua revisi sama persis, termasuk urutan barang
+func (r *RevisiSurat) SamaDengan(o *RevisiSurat) bool {
+	if r.PelaporNIK != o.PelaporNIK || r.PelaporNama != o.PelaporNama || r.PelaporTTL != o.PelaporTTL ||
+		r.PelaporAgama != o.PelaporAgama || r.PelaporKelamin != o.PelaporKelamin ||
+		r.PelaporPekerjaan != o.PelaporPekerjaan || r.PelaporAlamat != o.PelaporAlamat ||
+		r.LokasiHilang != o.LokasiHilang || len(r.BarangHilang) != len(o.BarangHilang) {
+		return false
+	}
+	for i := range r.BarangHilang {
+		if r.BarangHilang[i].JenisBarang != o.BarangHilang[i].JenisBarang || r.BarangHilang[i].Data != o.BarangHilang[i].Data {
+			return false
+		}
+	}
+	return true
+}
+
+// Format cetak surat yang dicatat di riwayat cetak
+const (
+	CetakHalaman = "halaman" // halaman cetak di browser
+	CetakPDF     = "pdf"
+)
+
+// CetakSurat adalah catatan satu kali surat dicetak. Cetakan pertama (Salinan 0) adalah surat asli;
+// cetakan berikutnya diberi tanda SALINAN dengan nomor urutnya.
+type CetakSurat struct {
+	ID          int       `db:"id"`
+	SuratID     int       `db:"surat_id"`
+	Salinan     int       `db:"salinan"`
+	Format      string    `db:"format"`
+	Revisi      int       `db:"revisi"` // revisi isi surat yang dicetak
+	Alasan      string    `db:"alasan"`
+	DicetakOleh string    `db:"dicetak_oleh"`
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+// IsAsli bernilai true untuk cetakan pertama surat
+func (c *CetakSurat) IsAsli() bool {
+	return c.Salinan == 0
+}
+
+// Penanda kata yang cocok di Cuplikan. Dipakai karakter kontrol agar tidak bentrok dengan isi surat
+// dan aman di-escape sebelum diganti tag <mark>.
+const (
+	SorotAwal  = "\x02"
+	SorotAkhir = "\x03"
+)
+
+// Jenis kelamin pelapor
+const (
+	KelaminLakiLaki  = "Laki-laki"
+	KelaminPerempuan = "Perempuan"
+)
+
+// Status surat
+const (
+	StatusAktif      = "aktif"
+	StatusDibatalkan = "dibatalkan"
+)
+
+// IsDibatalkan mengembalikan true jika surat sudah dibatalkan
+func (s *SuratKeteranganHilang) IsDibatalkan() bool {
+	return s.Status == StatusDibatalkan
+}
+
+// IsPerpanjangan mengembalikan true jika surat ini diterbitkan sebagai perpanjangan surat lain
+func (s *SuratKeteranganHilang) IsPerpanjangan() bool {
+	return s.SuratAsalID != 0
+}
+
+// MasaBerlaku mengembalikan lama surat berlaku dalam hari, termasuk tanggal surat
+func (s *SuratKeteranganHilang) MasaBerlaku() int {
+	if s.BerlakuSampai.IsZero() {
+		return DefaultMasaBerlakuHari
+	}
+	awal := time.Date(s.TanggalSurat.Year(), s.TanggalSurat.Month(), s.TanggalSurat.Day(), 0, 0, 0, 0, time.UTC)
+	akhir := time.Date(s.BerlakuSampai.Year(), s.BerlakuSampai.Month(), s.BerlakuSampai.Day(), 0, 0, 0, 0, time.UTC)
+	return int(akhir.Sub(awal).Hours()/24) + 1
+}
+
+// Batas masa berlaku surat yang dapat diatur di pengaturan
+const (
+	DefaultMasaBerlakuHari = 15
+	MaxMasaBerlakuHari     = 365
+)
+
+// Batas pengawasan laporan berulang yang dapat diatur di pengaturan
+const (
+	DefaultBatasLaporanBerulang   = 2
+	MaxBatasLaporanBerulang       = 100
+	DefaultPeriodeLaporanBerulang = 365
+	MaxPeriodeLaporanBerulang     = 3650
+)
+
+// MaxBatasUbahJam adalah batas terlama (satu tahun) surat masih boleh diubah setelah terbit
+const MaxBatasUbahJam = 24 * 365
+
+// HitungBerlakuSampai mengembalikan hari terakhir surat berlaku, dengan tanggal surat dihitung sebagai hari pertama
+func HitungBerlakuSampai(tanggalSurat time.Time, masaBerlakuHari int) time.Time {
+	if masaBerlakuHari < 1 {
+		masaBerlakuHari = DefaultMasaBerlakuHari
+	}
+	return time.Date(tanggalSurat.Year(), tanggalSurat.Month(), tanggalSurat.Day()+masaBerlakuHari-1, 0, 0, 0, 0, tanggalSurat.Location())
+}
+
+// Hasil pemeriksaan keaslian surat di halaman verifikasi publik
+const (
+	VerifikasiBerlaku        = "berlaku"
+	VerifikasiKedaluwarsa    = "kedaluwarsa"
+	VerifikasiDibatalkan     = "dibatalkan"
+	VerifikasiTidakDitemukan = "tidak_ditemukan"
+)
+
+// HasilVerifikasi berisi data minimal yang boleh ditampilkan kepada pihak luar
+type HasilVerifikasi struct {
+	Status         string
+	NomorSurat     string
+	TanggalSurat   time.Time
+	BerlakuSampai  time.Time // hari terakhir surat berlaku
+	PelaporSamaran string    // nama pelapor yang sebagian hurufnya disamarkan
+	JenisBarang    []string
+	NamaKantor     string
+	DibatalkanPada time.Time
+
+	SuratAsalNomor    string // jika surat ini perpanjangan
+	PerpanjanganNomor string // jika surat ini sudah diperpanjang
 }
 
 // Barang yang hilang dalam satu surat
@@ -55,10 +467,107 @@ type Barang struct {
 	Data        string `db:"data"`
 }
 
+// Tipe input field jenis barang
+const (
+	FieldTeks        = "text"
+	FieldTeksPanjang = "textarea"
+	FieldPilihan     = "select"
+)
+
+// FieldBarang adalah satu isian pada jenis barang, disimpan sebagai key Kunci di Barang.Data
+type FieldBarang struct {
+	Kunci       string // huruf kecil, angka dan garis bawah, mis. "nik"
+	Label       string
+	Tipe        string // FieldTeks, FieldTeksPanjang atau FieldPilihan
+	Wajib       bool
+	Pola        string // regex opsional yang harus cocok dengan seluruh isian
+	Validasi    string // aturan dari package validasi, mis. "nik" atau "tnkb"; kosong berarti tanpa aturan
+	Pilihan     []string
+	MaksPanjang int // 0 berarti tanpa batas
+}
+
+// JenisBarang mendefinisikan satu jenis barang hilang beserta isian dan frasa cetaknya
+type JenisBarang struct {
+	ID         int    `db:"id"`
+	Nama       string `db:"nama"` // disimpan di barang.jenis_barang, tidak dapat diganti setelah dibuat
+	Fields     []FieldBarang
+	FrasaCetak string `db:"frasa_cetak"` // mis. "NIK: {nik}, a.n. Pelapor"; {kunci} diganti isian
+	Urutan     int    `db:"urutan"`
+	Aktif      bool   `db:"aktif"` // jenis nonaktif tidak muncul di form surat baru
+}
+
+// Keterangan menyusun uraian barang untuk dicetak dari FrasaCetak. Jika frasa kosong,
+// isian ditulis berurutan sebagai "Label: nilai".
+func (j *JenisBarang) Keterangan(data map[string]string) string {
+	if j.FrasaCetak == "" {
+		var bagian []string
+		for _, f := range j.Fields {
+			if v := data[f.Kunci]; v != "" {
+				bagian = append(bagian, f.Label+": "+v)
+			}
+		}
+		return strings.Join(bagian, ", ")
+	}
+	pasangan := make([]string, 0, len(j.Fields)*2)
+	for _, f := range j.Fields {
+		pasangan = append(pasangan, "{"+f.Kunci+"}", data[f.Kunci])
+	}
+	return strings.NewReplacer(pasangan...).Replace(j.FrasaCetak)
+}
+
+// RegistriBarang memetakan nama jenis ke definisinya, dipakai saat mencetak surat
+type RegistriBarang map[string]JenisBarang
+
+// Keterangan mengembalikan uraian barang untuk surat. Jenis yang tidak lagi terdaftar
+// ditulis sebagai daftar nilai agar surat lama tetap dapat dicetak.
+func (r RegistriBarang) Keterangan(b Barang) string {
+	data := b.DataMap()
+	if j, ok := r[b.JenisBarang]; ok {
+		return j.Keterangan(data)
+	}
+	kunci := make([]string, 0, len(data))
+	for k := range data {
+		kunci = append(kunci, k)
+	}
+	sort.Strings(kunci)
+	nilai := make([]string, 0, len(kunci))
+	for _, k := range kunci {
+		if data[k] != "" {
+			nilai = append(nilai, data[k])
+		}
+	}
+	return strings.Join(nilai, ", ")
+}
+
+// DataMap membaca Data sebagai pasangan kunci dan teks. Data yang rusak dianggap kosong.
+func (b Barang) DataMap() map[string]string {
+	var raw map[string]interface{}
+	_ = json.Unmarshal([]byte(b.Data), &raw)
+	data := make(map[string]string, len(raw))
+	for k, v := range raw {
+		if v != nil {
+			data[k] = fmt.Sprint(v)
+		}
+	}
+	return data
+}
+
 // PageData adalah struct untuk mengirim data ke template
 type PageData struct {
-	Surat *SuratKeteranganHilang
-	Error string
+	Surat       *SuratKeteranganHilang
+	Error       string
+	FieldErrors map[string]string // pesan per field, mis. "pelapor_nama" atau "barang.0"
+	JenisBarang []JenisBarang     // jenis yang dapat dipilih di form
+
+	// Pilihan petugas bertugas pada form surat baru, terpilih sesuai pengaturan
+	PejabatList  []Petugas
+	PenerimaList []Petugas
+	PejabatID    int
+	PenerimaID   int
+
+	// Kantor penerbit surat baru; DaftarKantor hanya diisi bila pengguna boleh memilih kantor
+	KantorID     int
+	DaftarKantor []Kantor
 }
 
 // BarangStat untuk menampung hasil statistik
@@ -67,14 +576,215 @@ type BarangStat struct {
 	Total       int
 }
 
+// Periode laporan rekap
+const (
+	LaporanBulanan = "bulanan"
+	LaporanTahunan = "tahunan"
+)
+
+// RekapSurat adalah hitungan surat dalam satu rentang tanggal. Rincian (per jenis, tanggal, dst.)
+// hanya menghitung surat yang tidak dibatalkan.
+type RekapSurat struct {
+	Terbit       int // semua surat yang terbit dalam rentang, termasuk yang kemudian dibatalkan
+	Sah          int // surat terbit yang tidak dibatalkan
+	Perpanjangan int // surat sah yang merupakan perpanjangan
+	PerJenis     map[string]int
+	PerTanggal   map[string]int // kunci YYYY-MM-DD
+	PerKelamin   map[string]int
+	PerPekerjaan map[string]int
+	PerLokasi    map[string]int          // kunci lokasi dalam huruf besar
+	PerKantor    map[string]int          // kunci nama kantor, hanya diisi untuk rekap semua kantor
+	Pembatalan   []SuratKeteranganHilang // surat yang dibatalkan dalam rentang (menurut tanggal pembatalan)
+	CetakUlang   int                     // salinan yang dicetak dalam rentang, menurut waktu cetak
+}
+
+// BarisLaporan adalah satu baris rekap: jumlah pada periode laporan dan periode pembanding
+type BarisLaporan struct {
+	Label      string
+	Jumlah     int
+	Pembanding int
+}
+
+// Selisih adalah perubahan dari periode pembanding
+func (b BarisLaporan) Selisih() int {
+	return b.Jumlah - b.Pembanding
+}
+
+// Laporan adalah rekap resmi satu bulan atau satu tahun beserta perbandingan dengan periode sebelumnya
+type Laporan struct {
+	Periode         string // LaporanBulanan atau LaporanTahunan
+	Tahun           int
+	Bulan           int    // 1-12, hanya untuk laporan bulanan
+	Judul           string // mis. "Oktober 2026" atau "Tahun 2026"
+	JudulPembanding string
+	Dari            time.Time // awal periode (inklusif)
+	Sampai          time.Time // akhir periode (eksklusif)
+	LabelWaktu      string    // "Tanggal" untuk laporan bulanan, "Bulan" untuk tahunan
+	KantorID        int       // 0 untuk laporan gabungan semua kantor
+
+	Ringkasan     []BarisLaporan
+	PerJenis      []BarisLaporan
+	PerWaktu      []BarisLaporan
+	PerKelamin    []BarisLaporan
+	PerPekerjaan  []BarisLaporan
+	LokasiTeratas []BarisLaporan
+	PerKantor     []BarisLaporan // hanya pada laporan gabungan
+	Pembatalan    []SuratKeteranganHilang
+}
+
+// BagianLaporan adalah satu tabel rekap dalam laporan
+type BagianLaporan struct {
+	Judul string
+	Kolom string // judul kolom label
+	Baris []BarisLaporan
+}
+
+// Bagian mengembalikan tabel-tabel rekap laporan sesuai urutan cetak
+func (l *Laporan) Bagian() []BagianLaporan {
+	bagian := []BagianLaporan{{"Ringkasan", "Uraian", l.Ringkasan}}
+	if len(l.PerKantor) > 0 {
+		bagian = append(bagian, BagianLaporan{"Per Kantor", "Kantor", l.PerKantor})
+	}
+	return append(bagian, []BagianLaporan{
+		{"Jenis Barang Hilang", "Jenis Barang", l.PerJenis},
+		{"Per " + l.LabelWaktu, l.LabelWaktu, l.PerWaktu},
+		{"Jenis Kelamin Pelapor", "Jenis Kelamin", l.PerKelamin},
+		{"Pekerjaan Pelapor", "Pekerjaan", l.PerPekerjaan},
+		{"Lokasi Kehilangan Terbanyak", "Lokasi", l.LokasiTeratas},
+	}...)
+}
+
 // DashboardData untuk statistik di dashboard
 type DashboardData struct {
 	TotalSurat       int
 	TotalBulanIni    int
+	TotalBerlaku     int
+	TotalKedaluwarsa int
 	PesanPenyambutan string
 	StatLabels       []string // Untuk Pie Chart Kategori
 	StatData         []int
 	HarianLabels     []string // Untuk Bar Chart Harian
 	HarianData       []int
+
+	// Kantor yang ditampilkan (0 = semua kantor) dan pilihan kantor bagi pengguna semua kantor
+	KantorID     int
+	DaftarKantor []Kantor
+}
+
+// Peran pengguna aplikasi, diurutkan dari hak akses terendah ke tertinggi
+const (
+	RoleOperator   = "operator"
+	RoleSupervisor = "supervisor"
+	RoleAdmin      = "admin"
+)
+
+// roleLevel menentukan urutan hak akses setiap peran
+var roleLevel = map[string]int{
+	RoleOperator:   1,
+	RoleSupervisor: 2,
+	RoleAdmin:      3,
+}
+
+// User menyimpan data akun yang dapat login ke aplikasi
+type User struct {
+	ID           int       `db:"id"`
+	Username     string    `db:"username"`
+	NamaLengkap  string    `db:"nama_lengkap"`
+	PasswordHash string    `db:"password_hash"`
+	Role         string    `db:"role"`
+	Aktif        bool      `db:"aktif"`
+	KantorID     int       `db:"kantor_id"` // 0 berarti dapat mengakses semua kantor
+	CreatedAt    time.Time `db:"created_at"`
+
+	// WajibGantiPassword membatasi user ke halaman ganti password sampai password-nya diganti
+	WajibGantiPassword bool `db:"wajib_ganti_password"`
+}
+
+// BolehAksesKantor memeriksa apakah user boleh melihat dan mengolah surat kantor kantorID
+func (u *User) BolehAksesKantor(kantorID int) bool {
+	return u != nil && (u.KantorID == 0 || u.KantorID == kantorID)
 }
 
+// HasRole mengembalikan true jika peran user setara atau lebih tinggi dari role. Peran yang tidak
+// dikenal selalu ditolak.
+func (u *User) HasRole(role string) bool {
+	level, ok := roleLevel[role]
+	if u == nil || !ok {
+		return false
+	}
+	return roleLevel[u.Role] >= level
+}
+
+// IsValidRole memeriksa apakah nama peran dikenal oleh aplikasi
+func IsValidRole(role string) bool {
+	_, ok := roleLevel[role]
+	return ok
+}
+
+// Nama entitas dan aksi yang dicatat di audit log
+const (
+	EntitySurat       = "surat"
+	EntityPetugas     = "petugas"
+	EntityPengaturan  = "pengaturan"
+	EntityJenisBarang = "jenis_barang"
+	EntityBackup      = "backup"
+
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditCancel  = "cancel"
+	AuditRestore = "restore"
+)
+
+// AuditLog adalah satu catatan perubahan data yang tidak dapat diubah
+type AuditLog struct {
+	ID        int       `db:"id"`
+	CreatedAt time.Time `db:"created_at"`
+	Actor     string    `db:"actor"`
+	Entity    string    `db:"entity"`
+	EntityID  int       `db:"entity_id"`
+	Action    string    `db:"action"`
+	Diff      string    `db:"diff"` // JSON: {"Field": {"before": ..., "after": ...}}
+}
+
+// AuditFilter menampung filter untuk halaman audit
+type AuditFilter struct {
+	KantorID int // hanya jejak data kantor ini, 0 berarti semua kantor termasuk data semua kantor
+	Entity   string
+	Actor    string
+	From     time.Time // inklusif, zero value berarti tanpa batas
+	To       time.Time // eksklusif, zero value berarti tanpa batas
+	Limit    int
+}
+
+// Jenis cadangan, tercantum di akhir nama file arsip
+const (
+	BackupOtomatis = "otomatis"
+	BackupManual   = "manual"
+	BackupPraPulih = "pra-pulih" // dibuat tepat sebelum database dipulihkan dari arsip lain
+)
+
+// ArsipBackup adalah satu file cadangan (zip berisi database dan folder uploads) di folder backup
+type ArsipBackup struct {
+	Nama   string
+	Jenis  string
+	Dibuat time.Time
+	Ukuran int64
+}
+
+// UkuranTeks menampilkan ukuran arsip dalam KB atau MB
+func (a ArsipBackup) UkuranTeks() string {
+	if a.Ukuran < 1<<20 {
+		return fmt.Sprintf("%.0f KB", math.Ceil(float64(a.Ukuran)/(1<<10)))
+	}
+	return fmt.Sprintf("%.1f MB", float64(a.Ukuran)/(1<<20))
+}
+
+// ManifestBackup disimpan sebagai manifest.json di dalam arsip cadangan
+type ManifestBackup struct {
+	Aplikasi     string    `json:"aplikasi"`
+	Dibuat       time.Time `json:"dibuat"`
+	Jenis        string    `json:"jenis"`
+	VersiSkema   int       `json:"versi_skema"`
+	JumlahUnggah int       `json:"jumlah_uploads"`
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"reflect"
+	"skh_app/internal/model"
+	"time"
+)
+
+// --- FUNGSI AUDIT LOG ---
+
+// auditChange adalah nilai sebelum dan sesudah satu field
+type auditChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// writeAudit mencatat perubahan ke audit_log di dalam transaksi yang sama dengan perubahannya.
+// before bernilai nil untuk create, after bernilai nil untuk delete.
+func writeAudit(tx *sql.Tx, actor, entity string, entityID int, action string, before, after interface{}) error {
+	diff, err := buildDiff(before, after)
+	if err != nil {
+		return err
+	}
+	if actor == "" {
+		actor = "sistem"
+	}
+	kantorID := kantorAudit(after)
+	if kantorID == 0 {
+		kantorID = kantorAudit(before)
+	}
+	_, err = tx.Exec(
+		"INSERT INTO audit_log (created_at, actor, entity, entity_id, action, diff, kantor_id) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		time.Now().UTC(), actor, entity, entityID, action, diff, nullInt(kantorID),
+	)
+	return err
+}
+
+// kantorAudit mengembalikan kantor pemilik data yang diaudit, 0 untuk data semua kantor
+func kantorAudit(v interface{}) int {
+	switch d := v.(type) {
+	case *model.SuratKeteranganHilang:
+		return d.KantorID
+	case *model.Petugas:
+		return d.KantorID
+	case *model.Pengaturan:
+		return d.ID
+	}
+	return 0
+}
+
+// buildDiff membandingkan dua struct per field dan mengembalikan field yang berubah dalam format JSON
+func buildDiff(before, after interface{}) (string, error) {
+	beforeMap, err := toFieldMap(before)
+	if err != nil {
+		return "", err
+	}
+	afterMap, err := toFieldMap(after)
+	if err != nil {
+		return "", err
+	}
+
+	changes := make(map[string]auditChange)
+	for k, b := range beforeMap {
+		if a := afterMap[k]; !reflect.DeepEqual(b, a) {
+			changes[k] = auditChange{Before: b, After: a}
+		}
+	}
+	for k, a := range afterMap {
+		if _, ok := beforeMap[k]; !ok {
+			changes[k] = auditChange{Before: nil, After: a}
+		}
+	}
+
+	// json.Marshal mengurutkan key map, sehingga hasilnya stabil
+	out, err := json.Marshal(changes)
+	return string(out), err
+}
+
+func toFieldMap(v interface{}) (map[string]interface{}, error) {
+	result := make(map[string]interface{})
+	if rv := reflect.ValueOf(v); v == nil || (rv.Kind() == reflect.Ptr && rv.IsNil()) {
+		return result, nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(raw, &result)
+	return result, err
+}
+
+func (r *SuratRepository) GetAuditLogs(f model.AuditFilter) ([]model.AuditLog, error) {
+	var logs []model.AuditLog
+	query := `SELECT id, created_at, actor, entity, entity_id, action, diff FROM audit_log WHERE 1=1`
+	args := []interface{}{}
+	if f.KantorID != 0 {
+		query += " AND kantor_id = ?"
+		args = append(args, f.KantorID)
+	}
+	if f.Entity != "" {
+		query += " AND entity = ?"
+		args = append(args, f.Entity)
+	}
+	if f.Actor != "" {
+		query += " AND actor LIKE ?"
+		args = append(args, "%"+f.Actor+"%")
+	}
+	if !f.From.IsZero() {
+		query += " AND created_at >= ?"
+		args = append(args, f.From.UTC())
+	}
+	if !f.To.IsZero() {
+		query += " AND created_at < ?"
+		args = append(args, f.To.UTC())
+	}
+	query += " ORDER BY id DESC"
+	if f.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, f.Limit)
+	}
+
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var l model.AuditLog
+		if err := rows.Scan(&l.ID, &l.CreatedAt, &l.Actor, &l.Entity, &l.EntityID, &l.Action, &l.Diff); err != nil {
+			return nil, err
+		}
+		logs = append(logs, l)
+	}
+	return logs, nil
+}
@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"skh_app/internal/model"
+	"skh_app/migrations"
+	"strings"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// --- FUNGSI CADANGAN DAN PEMULIHAN DATABASE ---
+
+// SalinDatabase menulis salinan utuh database ke file tujuan (yang belum ada) dengan VACUUM INTO.
+// Aplikasi tetap bisa dipakai selama penyalinan; isinya adalah keadaan saat perintah dimulai.
+func (r *SuratRepository) SalinDatabase(tujuan string) error {
+	_, err := r.DB.Exec(`VACUUM INTO ?`, tujuan)
+	return err
+}
+
+// VersiSkema mengembalikan versi migrasi tertinggi yang sudah diterapkan
+func (r *SuratRepository) VersiSkema() (int, error) {
+	var versi int
+	err := r.DB.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&versi)
+	return versi, err
+}
+
+// PeriksaCadangan membuka file database cadangan hanya-baca, menjalankan PRAGMA integrity_check
+// dan mengembalikan versi skemanya. Database yang bukan milik aplikasi ini atau yang versinya
+// lebih baru dari aplikasi ditolak.
+func (r *SuratRepository) PeriksaCadangan(path string) (int, error) {
+	src, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+
+	rows, err := src.Query(`PRAGMA integrity_check(5)`)
+	if err != nil {
+		return 0, fmt.Errorf("file database rusak atau bukan database SQLite: %w", err)
+	}
+	var hasil []string
+	for rows.Next() {
+		var baris string
+		if err := rows.Scan(&baris); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		hasil = append(hasil, strings.TrimSpace(baris))
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("file database rusak atau bukan database SQLite: %w", err)
+	}
+	if len(hasil) != 1 || hasil[0] != "ok" {
+		return 0, fmt.Errorf("pemeriksaan integritas gagal: %s", strings.Join(hasil, "; "))
+	}
+
+	var versi int
+	if err := src.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&versi); err != nil {
+		return 0, fmt.Errorf("bukan database aplikasi SKH: %w", err)
+	}
+	daftar, err := bacaMigrasi(migrations.Files)
+	if err != nil {
+		return 0, err
+	}
+	if terbaru := daftar[len(daftar)-1].Versi; versi > terbaru {
+		return 0, fmt.Errorf("cadangan berasal dari aplikasi yang lebih baru (versi skema %d, aplikasi ini sampai versi %d)", versi, terbaru)
+	}
+	return versi, nil
+}
+
+// PulihkanDatabase menimpa seluruh isi database dengan file sumber memakai SQLite backup API,
+// sehingga koneksi yang sedang terbuka langsung melihat data hasil pemulihan. Setelah itu migrasi
+// yang belum ada di cadangan dijalankan dan pemulihan dicatat di audit log.
+func (r *SuratRepository) PulihkanDatabase(sumber, actor, arsip string) error {
+	ctx := context.Background()
+	src, err := sql.Open("sqlite3", "file:"+sumber+"?mode=ro")
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	srcConn, err := src.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer srcConn.Close()
+	dstConn, err := r.DB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer dstConn.Close()
+
+	err = dstConn.Raw(func(dst interface{}) error {
+		return srcConn.Raw(func(s interface{}) error {
+			b, err := dst.(*sqlite3.SQLiteConn).Backup("main", s.(*sqlite3.SQLiteConn), "main")
+			if err != nil {
+				return err
+			}
+			if _, err := b.Step(-1); err != nil {
+				b.Finish()
+				return err
+			}
+			return b.Finish()
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("gagal menyalin database cadangan: %w", err)
+	}
+
+	m, err := NewMigrator(r.DB, migrations.Files)
+	if err == nil {
+		err = m.Periksa()
+	}
+	if err == nil {
+		err = m.Naik()
+	}
+	if err != nil {
+		return fmt.Errorf("database sudah dipulihkan tetapi migrasi gagal: %w", err)
+	}
+
+	versi, _ := m.Versi()
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	catatan := struct {
+		Arsip      string
+		VersiSkema int
+	}{arsip, versi}
+	if err := writeAudit(tx, actor, model.EntityBackup, 0, model.AuditRestore, nil, catatan); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// CatatBackup mencatat pembuatan cadangan oleh pengguna di audit log
+func (r *SuratRepository) CatatBackup(actor string, arsip *model.ArsipBackup) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	catatan := struct {
+		Arsip  string
+		Ukuran int64
+	}{arsip.Nama, arsip.Ukuran}
+	if err := writeAudit(tx, actor, model.EntityBackup, 0, model.AuditCreate, nil, catatan); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
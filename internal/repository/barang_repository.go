@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"skh_app/internal/model"
+)
+
+// --- FUNGSI JENIS BARANG ---
+
+const selectJenisBarang = `SELECT id, nama, fields, frasa_cetak, urutan, aktif FROM jenis_barang`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanJenisBarang(row rowScanner) (*model.JenisBarang, error) {
+	j := &model.JenisBarang{}
+	var fields string
+	if err := row.Scan(&j.ID, &j.Nama, &fields, &j.FrasaCetak, &j.Urutan, &j.Aktif); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(fields), &j.Fields); err != nil {
+		return nil, fmt.Errorf("definisi field jenis barang %s rusak: %w", j.Nama, err)
+	}
+	return j, nil
+}
+
+// GetAllJenisBarang mengembalikan semua jenis barang, termasuk yang nonaktif, sesuai urutan tampil
+func (r *SuratRepository) GetAllJenisBarang() ([]model.JenisBarang, error) {
+	rows, err := r.DB.Query(selectJenisBarang + ` ORDER BY urutan ASC, nama ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []model.JenisBarang
+	for rows.Next() {
+		j, err := scanJenisBarang(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *j)
+	}
+	return list, rows.Err()
+}
+
+func (r *SuratRepository) GetJenisBarangByID(id int) (*model.JenisBarang, error) {
+	return getJenisBarangByID(r.DB, id)
+}
+
+func getJenisBarangByID(q queryer, id int) (*model.JenisBarang, error) {
+	return scanJenisBarang(q.QueryRow(selectJenisBarang+` WHERE id = ?`, id))
+}
+
+func (r *SuratRepository) CreateJenisBarang(j *model.JenisBarang, actor string) error {
+	fields, err := json.Marshal(j.Fields)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO jenis_barang (nama, fields, frasa_cetak, urutan, aktif) VALUES (?, ?, ?, ?, ?)`
+	res, err := tx.Exec(query, j.Nama, string(fields), j.FrasaCetak, j.Urutan, j.Aktif)
+	if err != nil {
+		return err
+	}
+	id, _ := res.LastInsertId()
+	j.ID = int(id)
+
+	if err := writeAudit(tx, actor, model.EntityJenisBarang, j.ID, model.AuditCreate, nil, j); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UpdateJenisBarang menyimpan perubahan definisi. Nama sengaja tidak ikut diubah karena
+// menjadi acuan barang.jenis_barang di surat yang sudah terbit.
+func (r *SuratRepository) UpdateJenisBarang(j *model.JenisBarang, actor string) error {
+	fields, err := json.Marshal(j.Fields)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := getJenisBarangByID(tx, j.ID)
+	if err != nil {
+		return err
+	}
+	query := `UPDATE jenis_barang SET fields = ?, frasa_cetak = ?, urutan = ?, aktif = ? WHERE id = ?`
+	if _, err := tx.Exec(query, string(fields), j.FrasaCetak, j.Urutan, j.Aktif, j.ID); err != nil {
+		return err
+	}
+	after, err := getJenisBarangByID(tx, j.ID)
+	if err != nil {
+		return err
+	}
+	if err := writeAudit(tx, actor, model.EntityJenisBarang, j.ID, model.AuditUpdate, before, after); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
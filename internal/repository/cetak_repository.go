@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"skh_app/internal/model"
+)
+
+// --- FUNGSI RIWAYAT CETAK ---
+
+// ErrAlasanCetakUlang dikembalikan CatatCetak bila surat sudah pernah dicetak tetapi alasan cetak ulang kosong
+var ErrAlasanCetakUlang = errors.New("surat sudah pernah dicetak, alasan cetak ulang wajib diisi")
+
+// CatatCetak mencatat satu cetakan surat dan mengisi c.ID serta c.Salinan. Nomor salinan dialokasikan
+// di dalam transaksi: cetakan pertama mendapat 0 (asli), berikutnya 1, 2, dan seterusnya. render
+// dipanggil dengan nomor salinan itu sebelum transaksi di-commit, jadi cetakan yang gagal dibuat
+// tidak pernah tercatat.
+func (r *SuratRepository) CatatCetak(c *model.CetakSurat, render func(*model.CetakSurat) error) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var salinan int
+	if err := tx.QueryRow("SELECT COALESCE(MAX(salinan) + 1, 0) FROM cetak_surat WHERE surat_id = ?", c.SuratID).Scan(&salinan); err != nil {
+		return err
+	}
+	if salinan > 0 && c.Alasan == "" {
+		return ErrAlasanCetakUlang
+	}
+	res, err := tx.Exec(`
+		INSERT INTO cetak_surat (surat_id, salinan, format, revisi, alasan, dicetak_oleh, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		c.SuratID, salinan, c.Format, c.Revisi, c.Alasan, c.DicetakOleh, c.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	id, _ := res.LastInsertId()
+	c.ID = int(id)
+	c.Salinan = salinan
+	if err := render(c); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetRiwayatCetak mengambil semua cetakan satu surat, dimulai dari cetakan asli
+func (r *SuratRepository) GetRiwayatCetak(suratID int) ([]model.CetakSurat, error) {
+	rows, err := r.DB.Query(`
+		SELECT id, surat_id, salinan, format, revisi, alasan, dicetak_oleh, created_at
+		FROM cetak_surat WHERE surat_id = ? ORDER BY salinan`, suratID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var riwayat []model.CetakSurat
+	for rows.Next() {
+		var c model.CetakSurat
+		var dicetak sql.NullTime
+		if err := rows.Scan(&c.ID, &c.SuratID, &c.Salinan, &c.Format, &c.Revisi, &c.Alasan, &c.DicetakOleh, &dicetak); err != nil {
+			return nil, err
+		}
+		c.CreatedAt = dicetak.Time
+		riwayat = append(riwayat, c)
+	}
+	return riwayat, rows.Err()
+}
@@ -3,104 +3,72 @@ package repository
 import (
 	"database/sql"
 	"fmt"
-	"log"
-	"os"
-	"path/filepath"
-	"sort"
-	"strconv"
-	"strings"
+	"log/slog"
+	"net/url"
+	"skh_app/migrations"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
-func ConnectDatabase() (*sql.DB, error) {
-	db, err := sql.Open("sqlite3", "./skh.db?_foreign_keys=on")
+// ConnectDatabase membuka database di path lalu menjalankan migrasi yang belum diterapkan.
+// Waktu yang dibaca dari database dikonversi ke zona waktu loc.
+func ConnectDatabase(path string, loc *time.Location) (*sql.DB, error) {
+	db, err := BukaDatabase(path, loc)
 	if err != nil {
 		return nil, err
 	}
 
-	if err = db.Ping(); err != nil {
-		return nil, err
+	m, err := NewMigrator(db, migrations.Files)
+	if err == nil {
+		err = m.Periksa()
 	}
-
-	// Ganti createTables dengan runMigrations
-	if err = runMigrations(db); err != nil {
+	if err == nil {
+		err = m.Naik()
+	}
+	if err != nil {
+		db.Close()
 		return nil, fmt.Errorf("gagal menjalankan migrasi: %w", err)
 	}
-
+	versi, _ := m.Versi()
+	slog.Info("Database siap", "versi", versi)
 	return db, nil
 }
 
-func runMigrations(db *sql.DB) error {
-	// 1. Buat tabel untuk mencatat versi migrasi
-	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER NOT NULL PRIMARY KEY);`)
+// BukaDatabase membuka database di path tanpa menjalankan migrasi
+func BukaDatabase(path string, loc *time.Location) (*sql.DB, error) {
+	// _txlock=immediate membuat setiap transaksi langsung mengunci database untuk menulis,
+	// sehingga dua transaksi pembuatan surat tidak bisa membaca nomor yang sama.
+	// Mode WAL membuat pembacaan tidak menunggu penulisan; isinya dipindah ke file utama oleh CloseDatabase.
+	dsn := path + "?_foreign_keys=on&_busy_timeout=5000&_txlock=immediate&_journal_mode=WAL&_loc=" + url.QueryEscape(loc.String())
+	db, err := sql.Open("sqlite3", dsn)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// 2. Dapatkan versi migrasi saat ini dari database
-	var currentVersion int
-	err = db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&currentVersion)
-	if err != nil {
-		return err
+	if err = db.Ping(); err != nil {
+		return nil, err
 	}
-	
-	log.Printf("Versi database saat ini: %d", currentVersion)
 
-	// 3. Baca semua file migrasi dari folder
-	files, err := filepath.Glob("migrations/*.sql")
-	if err != nil {
-		return err
+	// Pencarian surat memakai FTS5 yang hanya ikut dikompilasi dengan build tag sqlite_fts5
+	var fts5 bool
+	if err = db.QueryRow(`SELECT sqlite_compileoption_used('ENABLE_FTS5')`).Scan(&fts5); err != nil {
+		return nil, err
+	}
+	if !fts5 {
+		db.Close()
+		return nil, fmt.Errorf("SQLite tidak mendukung FTS5, build ulang aplikasi dengan build tag sqlite_fts5: go build -tags sqlite_fts5 ./cmd/server (atau go run -tags sqlite_fts5 ./cmd/server)")
 	}
-	sort.Strings(files) // Urutkan file berdasarkan nama
-
-	// 4. Jalankan migrasi yang versinya lebih tinggi dari versi saat ini
-	for _, file := range files {
-		fileName := filepath.Base(file)
-		parts := strings.Split(fileName, "_")
-		if len(parts) == 0 {
-			continue
-		}
-
-		version, err := strconv.Atoi(parts[0])
-		if err != nil {
-			continue // Abaikan file dengan format nama yang salah
-		}
-
-		if version > currentVersion {
-			log.Printf("Menjalankan migrasi: %s (versi %d)", fileName, version)
-
-			// Baca isi file SQL
-			content, err := os.ReadFile(file)
-			if err != nil {
-				return err
-			}
-
-			// Jalankan SQL di dalam transaksi agar aman
-			tx, err := db.Begin()
-			if err != nil {
-				return err
-			}
-
-			if _, err := tx.Exec(string(content)); err != nil {
-				tx.Rollback()
-				return fmt.Errorf("error di file %s: %w", fileName, err)
-			}
 
-			// Catat versi baru ke tabel schema_migrations
-			if _, err := tx.Exec(`INSERT INTO schema_migrations (version) VALUES (?)`, version); err != nil {
-				tx.Rollback()
-				return err
-			}
+	return db, nil
+}
 
-			if err := tx.Commit(); err != nil {
-				return err
-			}
-			
-			log.Printf("Migrasi %s berhasil.", fileName)
-		}
+// CloseDatabase memindahkan isi WAL ke file database lalu menutup koneksi, sehingga skh.db
+// bisa disalin sendiri tanpa file -wal dan -shm.
+func CloseDatabase(db *sql.DB) error {
+	if _, err := db.Exec(`PRAGMA wal_checkpoint(TRUNCATE)`); err != nil {
+		db.Close()
+		return fmt.Errorf("gagal checkpoint WAL: %w", err)
 	}
-
-	log.Println("Migrasi database selesai.")
-	return nil
+	return db.Close()
 }
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"skh_app/internal/model"
+	"strings"
+)
+
+// --- FUNGSI KANTOR ---
+
+// GetDaftarKantor mengambil semua kantor menurut ID, kantor utama lebih dulu
+func (r *SuratRepository) GetDaftarKantor() ([]model.Kantor, error) {
+	rows, err := r.DB.Query(`SELECT id, COALESCE(nama_kantor, ''), COALESCE(kode_kantor, '') FROM pengaturan ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var daftar []model.Kantor
+	for rows.Next() {
+		var k model.Kantor
+		if err := rows.Scan(&k.ID, &k.Nama, &k.Kode); err != nil {
+			return nil, err
+		}
+		if k.Nama == "" {
+			k.Nama = fmt.Sprintf("Kantor %d", k.ID)
+		}
+		daftar = append(daftar, k)
+	}
+	return daftar, rows.Err()
+}
+
+// GetKantorSurat mengembalikan kantor penerbit surat, untuk memeriksa hak akses sebelum surat dibuka
+func (r *SuratRepository) GetKantorSurat(suratID int) (int, error) {
+	var kantorID sql.NullInt64
+	err := r.DB.QueryRow(`SELECT kantor_id FROM surat WHERE id = ?`, suratID).Scan(&kantorID)
+	return int(kantorID.Int64), err
+}
+
+// cekKodeKantor memastikan kode kantor p tidak dipakai kantor lain, karena nomor surat harus unik
+// di seluruh instalasi
+func cekKodeKantor(tx *sql.Tx, p *model.Pengaturan) error {
+	kode := strings.TrimSpace(p.KodeKantor)
+	if kode == "" {
+		return nil
+	}
+	var nama sql.NullString
+	err := tx.QueryRow(`SELECT nama_kantor FROM pengaturan WHERE kode_kantor = ? AND id != ?`, kode, p.ID).Scan(&nama)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("kode kantor %s sudah dipakai %s", kode, nama.String)
+}
+
+// CreateKantor menambah kantor baru dari p lalu mengisi p.ID. Revisi kop surat pertama kantor
+// langsung dicatat agar surat pertamanya sudah menunjuk ke revisi.
+func (r *SuratRepository) CreateKantor(p *model.Pengaturan, actor string) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := cekKodeKantor(tx, p); err != nil {
+		return err
+	}
+	res, err := tx.Exec(`
+		INSERT INTO pengaturan (kop_surat_1, kop_surat_2, kop_surat_3, logo_path, format_nomor_surat,
+			wilayah, nama_kantor, kode_kantor, reset_nomor, masa_berlaku_hari,
+			batas_laporan_berulang, periode_laporan_berulang, batas_ubah_jam, last_nomor_surat, last_nomor_year)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 0, ?)`,
+		p.KopSurat1, p.KopSurat2, p.KopSurat3, p.LogoPath, p.FormatNomorSurat,
+		p.Wilayah, p.NamaKantor, p.KodeKantor, p.ResetNomor, p.MasaBerlakuHari,
+		p.BatasLaporanBerulang, p.PeriodeLaporanBerulang, p.BatasUbahJam, p.LastNomorYear,
+	)
+	if err != nil {
+		return err
+	}
+	id, _ := res.LastInsertId()
+	p.ID = int(id)
+
+	after, err := getPengaturan(tx, p.ID)
+	if err != nil {
+		return err
+	}
+	if err := simpanRevisiPengaturan(tx, after, actor); err != nil {
+		return err
+	}
+	if err := writeAudit(tx, actor, model.EntityPengaturan, p.ID, model.AuditCreate, nil, after); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"database/sql"
+	"skh_app/internal/model"
+	"time"
+)
+
+// RekapSurat menghitung surat kantor kantorID yang terbit pada rentang [dari, sampai) untuk laporan
+// rekap. kantorID 0 menghitung semua kantor sekaligus beserta rincian per kantor. Tanggal
+// dikelompokkan menurut tanggal lokal yang tersimpan di tanggal_surat.
+func (r *SuratRepository) RekapSurat(dari, sampai time.Time, kantorID int) (*model.RekapSurat, error) {
+	rekap := &model.RekapSurat{}
+	saring, args := saringKantor("s.kantor_id", kantorID, []interface{}{dari, sampai})
+	rentang := " s.tanggal_surat >= ? AND s.tanggal_surat < ?" + saring + " "
+	sah := rentang + "AND s.status != 'dibatalkan' "
+
+	err := r.DB.QueryRow(`
+		SELECT COUNT(*),
+			COALESCE(SUM(s.status != 'dibatalkan'), 0),
+			COALESCE(SUM(s.status != 'dibatalkan' AND s.surat_asal_id IS NOT NULL), 0)
+		FROM surat s WHERE`+rentang, args...).Scan(&rekap.Terbit, &rekap.Sah, &rekap.Perpanjangan)
+	if err != nil {
+		return nil, err
+	}
+
+	hitung := []struct {
+		tujuan *map[string]int
+		query  string
+	}{
+		{&rekap.PerJenis, `SELECT b.jenis_barang, COUNT(*) FROM barang b JOIN surat s ON s.id = b.surat_id
+			WHERE` + sah + `GROUP BY 1`},
+		{&rekap.PerTanggal, `SELECT substr(s.tanggal_surat, 1, 10), COUNT(*) FROM surat s WHERE` + sah + `GROUP BY 1`},
+		{&rekap.PerKelamin, `SELECT COALESCE(NULLIF(s.pelapor_kelamin, ''), 'Tidak diisi'), COUNT(*) FROM surat s
+			WHERE` + sah + `GROUP BY 1`},
+		{&rekap.PerPekerjaan, `SELECT COALESCE(NULLIF(s.pelapor_pekerjaan, ''), 'Tidak diisi'), COUNT(*) FROM surat s
+			WHERE` + sah + `GROUP BY 1`},
+		{&rekap.PerLokasi, `SELECT upper(trim(s.lokasi_hilang)), COUNT(*) FROM surat s
+			WHERE` + sah + `AND trim(COALESCE(s.lokasi_hilang, '')) != '' GROUP BY 1`},
+	}
+	// Rincian per kantor memuat semua kantor, termasuk yang belum menerbitkan surat
+	if kantorID == 0 {
+		hitung = append(hitung, struct {
+			tujuan *map[string]int
+			query  string
+		}{&rekap.PerKantor, `SELECT COALESCE(NULLIF(k.nama_kantor, ''), 'Kantor ' || k.id), COUNT(s.id) FROM pengaturan k
+			LEFT JOIN surat s ON s.kantor_id = k.id AND` + sah + `GROUP BY k.id`})
+	}
+	// Cetak ulang dihitung menurut waktu cetak, termasuk salinan surat yang terbit sebelum rentang
+	err = r.DB.QueryRow(`
+		SELECT COUNT(*) FROM cetak_surat c JOIN surat s ON s.id = c.surat_id
+		WHERE c.salinan > 0 AND c.created_at >= ? AND c.created_at < ?`+saring, args...).Scan(&rekap.CetakUlang)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, h := range hitung {
+		hasil, err := hitungKelompok(r.DB, h.query, args...)
+		if err != nil {
+			return nil, err
+		}
+		*h.tujuan = hasil
+	}
+
+	rows, err := r.DB.Query(`
+		SELECT id, nomor_surat, tanggal_surat, pelapor_nama, alasan_batal, dibatalkan_oleh, dibatalkan_pada
+		FROM surat s WHERE status = 'dibatalkan' AND dibatalkan_pada >= ? AND dibatalkan_pada < ?`+saring+`
+		ORDER BY dibatalkan_pada`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		s := model.SuratKeteranganHilang{Status: model.StatusDibatalkan}
+		var alasan, oleh sql.NullString
+		if err := rows.Scan(&s.ID, &s.NomorSurat, &s.TanggalSurat, &s.PelaporNama, &alasan, &oleh, &s.DibatalkanPada); err != nil {
+			return nil, err
+		}
+		s.AlasanBatal = alasan.String
+		s.DibatalkanOleh = oleh.String
+		rekap.Pembatalan = append(rekap.Pembatalan, s)
+	}
+	return rekap, rows.Err()
+}
+
+// hitungKelompok menjalankan query "SELECT kunci, COUNT(*) ... GROUP BY 1" menjadi map kunci -> jumlah
+func hitungKelompok(db *sql.DB, query string, args ...interface{}) (map[string]int, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	hasil := map[string]int{}
+	for rows.Next() {
+		var kunci string
+		var jumlah int
+		if err := rows.Scan(&kunci, &jumlah); err != nil {
+			return nil, err
+		}
+		hasil[kunci] += jumlah
+	}
+	return hasil, rows.Err()
+}
@@ -0,0 +1,316 @@
+package repository
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// polaMigrasi adalah nama file migrasi yang sah: NNN_nama.sql atau NNN_nama.down.sql
+var polaMigrasi = regexp.MustCompile(`^(\d+)_([a-z0-9_]+?)(\.down)?\.sql$`)
+
+// Migrasi adalah satu versi skema beserta skrip naik dan (opsional) skrip turunnya
+type Migrasi struct {
+	Versi    int
+	Nama     string
+	Naik     string
+	Turun    string // kosong bila migrasi tidak bisa dibatalkan
+	Checksum string // sha256 skrip naik
+}
+
+// StatusMigrasi adalah keadaan satu migrasi pada database
+type StatusMigrasi struct {
+	Migrasi
+	Diterapkan     bool
+	DiterapkanPada time.Time
+	Berubah        bool // skrip naik berbeda dengan yang dulu diterapkan
+}
+
+// Migrator menjalankan migrasi skema dari fsys (biasanya migrations.Files) terhadap db
+type Migrator struct {
+	db     *sql.DB
+	daftar []Migrasi // urut menurut versi
+}
+
+// NewMigrator membaca dan memeriksa semua file migrasi lalu menyiapkan tabel schema_migrations.
+// File .sql dengan nama yang tidak sesuai pola, versi ganda dan skrip turun tanpa skrip naik
+// dianggap kesalahan agar tidak ada migrasi yang terlewat diam-diam.
+func NewMigrator(db *sql.DB, fsys fs.FS) (*Migrator, error) {
+	daftar, err := bacaMigrasi(fsys)
+	if err != nil {
+		return nil, err
+	}
+	if err := siapkanTabelMigrasi(db); err != nil {
+		return nil, fmt.Errorf("gagal menyiapkan schema_migrations: %w", err)
+	}
+	return &Migrator{db: db, daftar: daftar}, nil
+}
+
+func bacaMigrasi(fsys fs.FS) ([]Migrasi, error) {
+	files, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+	perVersi := map[int]*Migrasi{}
+	for _, file := range files {
+		m := polaMigrasi.FindStringSubmatch(file)
+		if m == nil {
+			return nil, fmt.Errorf("nama file migrasi %s tidak valid, gunakan NNN_nama.sql atau NNN_nama.down.sql", file)
+		}
+		versi, _ := strconv.Atoi(m[1])
+		isi, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+
+		mg := perVersi[versi]
+		if mg == nil {
+			mg = &Migrasi{Versi: versi, Nama: m[2]}
+			perVersi[versi] = mg
+		}
+		if mg.Nama != m[2] {
+			return nil, fmt.Errorf("versi migrasi %d dipakai dua nama: %s dan %s", versi, mg.Nama, m[2])
+		}
+		if m[3] != "" {
+			mg.Turun = string(isi)
+			continue
+		}
+		if mg.Naik != "" {
+			return nil, fmt.Errorf("versi migrasi %d dipakai lebih dari satu file", versi)
+		}
+		mg.Naik = string(isi)
+		mg.Checksum = checksumMigrasi(isi)
+	}
+
+	daftar := make([]Migrasi, 0, len(perVersi))
+	for _, mg := range perVersi {
+		if mg.Naik == "" {
+			return nil, fmt.Errorf("migrasi %03d_%s hanya punya skrip down", mg.Versi, mg.Nama)
+		}
+		daftar = append(daftar, *mg)
+	}
+	sort.Slice(daftar, func(i, j int) bool { return daftar[i].Versi < daftar[j].Versi })
+	return daftar, nil
+}
+
+// checksumMigrasi menghitung sha256 skrip dengan akhir baris dinormalkan, sehingga checkout
+// Windows (CRLF) dan Linux (LF) menghasilkan checksum yang sama
+func checksumMigrasi(isi []byte) string {
+	sum := sha256.Sum256([]byte(strings.ReplaceAll(string(isi), "\r\n", "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// siapkanTabelMigrasi membuat schema_migrations dan menambah kolom checksum pada database
+// yang dibuat sebelum checksum dicatat
+func siapkanTabelMigrasi(db *sql.DB) error {
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER NOT NULL PRIMARY KEY)`); err != nil {
+		return err
+	}
+	kolom := map[string]bool{}
+	rows, err := db.Query(`SELECT name FROM pragma_table_info('schema_migrations')`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var nama string
+		if err := rows.Scan(&nama); err != nil {
+			return err
+		}
+		kolom[nama] = true
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, k := range []string{"checksum TEXT", "diterapkan_pada DATETIME"} {
+		if !kolom[strings.Fields(k)[0]] {
+			if _, err := db.Exec(`ALTER TABLE schema_migrations ADD COLUMN ` + k); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Terbaru adalah versi migrasi tertinggi yang dikenal aplikasi
+func (m *Migrator) Terbaru() int {
+	if len(m.daftar) == 0 {
+		return 0
+	}
+	return m.daftar[len(m.daftar)-1].Versi
+}
+
+// Versi adalah versi tertinggi yang sudah diterapkan pada database
+func (m *Migrator) Versi() (int, error) {
+	var versi int
+	err := m.db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&versi)
+	return versi, err
+}
+
+// Status mengembalikan keadaan setiap migrasi yang dikenal aplikasi
+func (m *Migrator) Status() ([]StatusMigrasi, error) {
+	type catatan struct {
+		checksum string
+		pada     time.Time
+	}
+	diterapkan := map[int]catatan{}
+	rows, err := m.db.Query(`SELECT version, COALESCE(checksum, ''), diterapkan_pada FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var versi int
+		var c catatan
+		var pada sql.NullTime
+		if err := rows.Scan(&versi, &c.checksum, &pada); err != nil {
+			return nil, err
+		}
+		c.pada = pada.Time
+		diterapkan[versi] = c
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	hasil := make([]StatusMigrasi, 0, len(m.daftar))
+	for _, mg := range m.daftar {
+		st := StatusMigrasi{Migrasi: mg}
+		if c, ok := diterapkan[mg.Versi]; ok {
+			st.Diterapkan = true
+			st.DiterapkanPada = c.pada
+			st.Berubah = c.checksum != "" && c.checksum != mg.Checksum
+		}
+		hasil = append(hasil, st)
+	}
+	return hasil, nil
+}
+
+// Periksa menolak database yang versinya lebih baru dari aplikasi atau yang migrasinya sudah
+// diterapkan dengan isi berbeda. Migrasi lama yang belum punya checksum dicatat checksumnya sekarang.
+func (m *Migrator) Periksa() error {
+	versi, err := m.Versi()
+	if err != nil {
+		return err
+	}
+	if versi > m.Terbaru() {
+		return fmt.Errorf("database sudah di versi %d sedangkan aplikasi ini hanya mengenal sampai versi %d; gunakan aplikasi versi terbaru", versi, m.Terbaru())
+	}
+
+	status, err := m.Status()
+	if err != nil {
+		return err
+	}
+	for _, st := range status {
+		if st.Berubah {
+			return fmt.Errorf("migrasi %03d_%s sudah diterapkan tetapi isinya telah diubah (checksum tidak cocok); kembalikan file aslinya dan buat migrasi baru untuk perubahan skema", st.Versi, st.Nama)
+		}
+	}
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	dicatat := int64(0)
+	for _, mg := range m.daftar {
+		res, err := tx.Exec(`UPDATE schema_migrations SET checksum = ? WHERE version = ? AND checksum IS NULL`, mg.Checksum, mg.Versi)
+		if err != nil {
+			return fmt.Errorf("gagal mencatat checksum migrasi lama: %w", err)
+		}
+		n, _ := res.RowsAffected()
+		dicatat += n
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	if dicatat > 0 {
+		slog.Info("Checksum migrasi lama dicatat", "jumlah", dicatat)
+	}
+	return nil
+}
+
+// Naik menerapkan semua migrasi yang belum diterapkan
+func (m *Migrator) Naik() error {
+	return m.Ke(m.Terbaru())
+}
+
+// Turun membatalkan satu migrasi terakhir yang diterapkan
+func (m *Migrator) Turun() error {
+	versi, err := m.Versi()
+	if err != nil {
+		return err
+	}
+	if versi == 0 {
+		return fmt.Errorf("belum ada migrasi yang diterapkan")
+	}
+	sebelumnya := 0
+	for _, mg := range m.daftar {
+		if mg.Versi < versi {
+			sebelumnya = mg.Versi
+		}
+	}
+	return m.Ke(sebelumnya)
+}
+
+// Ke menaikkan atau menurunkan database sampai versi target. Migrasi di atas target dibatalkan
+// dari yang terbaru; migrasi sampai target yang belum diterapkan dijalankan dari yang terlama.
+func (m *Migrator) Ke(target int) error {
+	if target < 0 || target > m.Terbaru() {
+		return fmt.Errorf("versi %d tidak dikenal, versi terbaru adalah %d", target, m.Terbaru())
+	}
+	status, err := m.Status()
+	if err != nil {
+		return err
+	}
+
+	for i := len(status) - 1; i >= 0; i-- {
+		st := status[i]
+		if st.Versi <= target || !st.Diterapkan {
+			continue
+		}
+		if st.Turun == "" {
+			return fmt.Errorf("migrasi %03d_%s tidak punya skrip down sehingga tidak bisa dibatalkan", st.Versi, st.Nama)
+		}
+		slog.Info("Membatalkan migrasi", "file", fmt.Sprintf("%03d_%s", st.Versi, st.Nama), "versi", st.Versi)
+		if err := m.jalankan(st.Turun, `DELETE FROM schema_migrations WHERE version = ?`, st.Versi); err != nil {
+			return fmt.Errorf("error di skrip down %03d_%s: %w", st.Versi, st.Nama, err)
+		}
+	}
+
+	for _, st := range status {
+		if st.Versi > target || st.Diterapkan {
+			continue
+		}
+		slog.Info("Menjalankan migrasi", "file", fmt.Sprintf("%03d_%s", st.Versi, st.Nama), "versi", st.Versi)
+		err := m.jalankan(st.Naik, `INSERT INTO schema_migrations (version, checksum, diterapkan_pada) VALUES (?, ?, ?)`,
+			st.Versi, st.Checksum, time.Now())
+		if err != nil {
+			return fmt.Errorf("error di file %03d_%s: %w", st.Versi, st.Nama, err)
+		}
+	}
+	return nil
+}
+
+// jalankan mengeksekusi skrip dan mencatatnya di schema_migrations dalam satu transaksi
+func (m *Migrator) jalankan(skrip, catat string, args ...interface{}) error {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(skrip); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(catat, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,129 @@
+//go:build sqlite_fts5
+
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"skh_app/migrations"
+	"strings"
+	"testing"
+	"time"
+)
+
+// skemaDatabase mengembalikan kolom dan foreign key setiap tabel serta definisi indeks, view dan
+// trigger, urut menurut nama. Tabel dibandingkan lewat PRAGMA karena teks CREATE TABLE hasil
+// ALTER TABLE berbeda dengan tabel yang dibuat ulang oleh skrip down meski strukturnya sama.
+func skemaDatabase(t *testing.T, db *sql.DB) string {
+	t.Helper()
+	var sb strings.Builder
+	tulis := func(query string) {
+		rows, err := db.Query(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+		kolom, err := rows.Columns()
+		if err != nil {
+			t.Fatal(err)
+		}
+		nilai := make([]sql.NullString, len(kolom))
+		tujuan := make([]interface{}, len(kolom))
+		for i := range nilai {
+			tujuan[i] = &nilai[i]
+		}
+		for rows.Next() {
+			if err := rows.Scan(tujuan...); err != nil {
+				t.Fatal(err)
+			}
+			for _, n := range nilai {
+				sb.WriteString(n.String + "|")
+			}
+			sb.WriteString("\n")
+		}
+		if err := rows.Err(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	const tabel = `FROM sqlite_master m, %s WHERE m.type = 'table' AND m.name NOT LIKE 'sqlite_%%' AND m.name != 'schema_migrations'`
+	tulis(`SELECT m.name, p.cid, p.name, p.type, p."notnull", p.dflt_value, p.pk ` +
+		fmt.Sprintf(tabel, "pragma_table_info(m.name) p") + ` ORDER BY m.name, p.cid`)
+	tulis(`SELECT m.name, f."table", f."from", f."to", f.on_update, f.on_delete ` +
+		fmt.Sprintf(tabel, "pragma_foreign_key_list(m.name) f") + ` ORDER BY m.name, f.id, f.seq`)
+	tulis(`SELECT type, name, tbl_name, sql FROM sqlite_master WHERE type != 'table' AND name NOT LIKE 'sqlite_%' ORDER BY type, name`)
+	return sb.String()
+}
+
+// TestMigrasiNaikTurunNaik menaikkan database kosong ke versi terbaru, menurunkannya ke versi
+// terendah yang masih bisa dicapai lewat skrip down, lalu menaikkannya lagi. Skema di setiap
+// titik harus sama dengan skema saat versi itu pertama kali dicapai.
+func TestMigrasiNaikTurunNaik(t *testing.T) {
+	db, err := BukaDatabase(filepath.Join(t.TempDir(), "skh.db"), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { CloseDatabase(db) })
+
+	m, err := NewMigrator(db, migrations.Files)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Versi terendah yang bisa dicapai adalah versi sebelum migrasi terakhir tanpa skrip down
+	dasar := 0
+	for _, mg := range m.daftar {
+		if mg.Turun == "" {
+			dasar = mg.Versi
+		}
+	}
+	if dasar == m.Terbaru() {
+		t.Fatalf("migrasi terbaru %d tidak punya skrip down", dasar)
+	}
+
+	versiHarus := func(harus int) {
+		t.Helper()
+		versi, err := m.Versi()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if versi != harus {
+			t.Fatalf("versi database = %d, seharusnya %d", versi, harus)
+		}
+	}
+
+	if err := m.Ke(dasar); err != nil {
+		t.Fatalf("naik ke versi %d: %v", dasar, err)
+	}
+	skemaDasar := skemaDatabase(t, db)
+	if err := m.Naik(); err != nil {
+		t.Fatalf("naik ke versi terbaru: %v", err)
+	}
+	versiHarus(m.Terbaru())
+	skemaTerbaru := skemaDatabase(t, db)
+
+	// Turun satu per satu agar setiap skrip down dijalankan terhadap skema versinya sendiri
+	for versi := m.Terbaru(); versi > dasar; {
+		if err := m.Turun(); err != nil {
+			t.Fatalf("turun dari versi %d: %v", versi, err)
+		}
+		if versi, err = m.Versi(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	versiHarus(dasar)
+	if got := skemaDatabase(t, db); got != skemaDasar {
+		t.Errorf("skema setelah turun ke versi %d berbeda dari skema awal versi itu:\n%s\nseharusnya:\n%s", dasar, got, skemaDasar)
+	}
+
+	if err := m.Naik(); err != nil {
+		t.Fatalf("naik lagi ke versi terbaru: %v", err)
+	}
+	versiHarus(m.Terbaru())
+	if got := skemaDatabase(t, db); got != skemaTerbaru {
+		t.Errorf("skema setelah naik lagi berbeda dari skema pertama:\n%s\nseharusnya:\n%s", got, skemaTerbaru)
+	}
+	if err := m.Periksa(); err != nil {
+		t.Errorf("Periksa setelah naik lagi: %v", err)
+	}
+}
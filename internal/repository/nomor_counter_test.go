@@ -0,0 +1,97 @@
+//go:build sqlite_fts5
+
+package repository
+
+import (
+	"path/filepath"
+	"skh_app/internal/model"
+	"testing"
+	"time"
+)
+
+// gantiReset menyimpan pengaturan kantor utama dengan reset nomor baru pada waktu t
+func gantiReset(t *testing.T, r *SuratRepository, reset string, waktu time.Time) {
+	t.Helper()
+	p, err := r.GetPengaturan(model.KantorUtama)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.ResetNomor = reset
+	p.NomorPeriode = model.NomorPeriode(reset, waktu)
+	if err := r.UpdatePengaturan(p, "admin"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// ambilNomor mengalokasikan nomor berikutnya seperti CreateSurat
+func ambilNomor(t *testing.T, r *SuratRepository, periode string) int {
+	t.Helper()
+	tx, err := r.DB.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	nomor, err := nextNomor(tx, model.KantorUtama, periode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	return nomor
+}
+
+func TestGantiResetNomorTengahTahun(t *testing.T) {
+	db, err := ConnectDatabase(filepath.Join(t.TempDir(), "skh.db"), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { CloseDatabase(db) })
+	r := NewSuratRepository(db, time.UTC)
+
+	agustus := time.Date(2025, time.August, 20, 9, 0, 0, 0, time.UTC)
+	for periode, nomor := range map[string]int{"2024": 300, "2025": 57} {
+		if _, err := db.Exec(`INSERT INTO nomor_counter (kantor_id, periode, last_nomor) VALUES (?, ?, ?)`,
+			model.KantorUtama, periode, nomor); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Tahunan -> bulanan: Agustus melanjutkan nomor tahunan, bukan mulai dari 1 lagi
+	gantiReset(t, r, model.ResetBulanan, agustus)
+	if got := ambilNomor(t, r, "2025-08"); got != 58 {
+		t.Errorf("nomor pertama setelah ganti ke bulanan = %d, seharusnya 58", got)
+	}
+	// Bulan berikutnya tetap mulai dari 1
+	if got := ambilNomor(t, r, "2025-09"); got != 1 {
+		t.Errorf("nomor pertama September = %d, seharusnya 1", got)
+	}
+
+	// Bulanan -> tahunan: nomor tahunan melewati semua nomor bulanan tahun ini
+	gantiReset(t, r, model.ResetTahunan, agustus.AddDate(0, 1, 0))
+	if got := ambilNomor(t, r, "2025"); got != 59 {
+		t.Errorf("nomor pertama setelah kembali ke tahunan = %d, seharusnya 59", got)
+	}
+
+	// Menyimpan tanpa mengganti reset tidak menyentuh penghitung
+	gantiReset(t, r, model.ResetTahunan, agustus.AddDate(0, 1, 0))
+	if got, _ := r.GetNomorCounter(model.KantorUtama, "2025"); got != 59 {
+		t.Errorf("penghitung 2025 = %d setelah simpan ulang, seharusnya 59", got)
+	}
+
+	// Nomor terakhir yang diisi manual tetap menang atas penyesuaian otomatis
+	p, err := r.GetPengaturan(model.KantorUtama)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.ResetNomor = model.ResetBulanan
+	p.NomorPeriode = "2025-10"
+	p.UbahNomorTerakhir = true
+	p.LastNomorSurat = 5
+	if err := r.UpdatePengaturan(p, "admin"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := r.GetNomorCounter(model.KantorUtama, "2025-10"); got != 5 {
+		t.Errorf("penghitung 2025-10 = %d, seharusnya nomor manual 5", got)
+	}
+}
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"database/sql"
+	"skh_app/internal/model"
+	"strings"
+	"time"
+)
+
+// --- FUNGSI PELAPOR ---
+
+// simpanPelapor menambah atau memperbarui data induk pelapor dari data pelapor pada surat, lalu
+// mengembalikan id-nya. Surat tanpa NIK tidak dihubungkan ke data induk (id 0).
+func simpanPelapor(tx *sql.Tx, s *model.SuratKeteranganHilang) (int, error) {
+	if s.PelaporNIK == "" {
+		return 0, nil
+	}
+	var id int
+	err := tx.QueryRow(`
+		INSERT INTO pelapor (nik, nama, ttl, agama, kelamin, pekerjaan, alamat, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(nik) DO UPDATE SET nama = excluded.nama, ttl = excluded.ttl, agama = excluded.agama,
+			kelamin = excluded.kelamin, pekerjaan = excluded.pekerjaan, alamat = excluded.alamat,
+			updated_at = excluded.updated_at
+		RETURNING id`,
+		s.PelaporNIK, s.PelaporNama, s.PelaporTTL, s.PelaporAgama, s.PelaporKelamin, s.PelaporPekerjaan, s.PelaporAlamat,
+		time.Now().UTC(), time.Now().UTC(),
+	).Scan(&id)
+	return id, err
+}
+
+// kolomPelapor menyusun kolom pelapor beserta jumlah dan tanggal surat terakhirnya. kantorID selain 0
+// hanya menghitung surat kantor itu.
+func kolomPelapor(kantorID int, args []interface{}) (string, []interface{}) {
+	jumlah, args := saringKantor("s.kantor_id", kantorID, args)
+	terakhir, args := saringKantor("s.kantor_id", kantorID, args)
+	return `p.id, p.nik, p.nama, p.ttl, p.agama, p.kelamin, p.pekerjaan, p.alamat, p.created_at, p.updated_at,
+	(SELECT COUNT(*) FROM surat s WHERE s.pelapor_id = p.id` + jumlah + `),
+	(SELECT MAX(s.tanggal_surat) FROM surat s WHERE s.pelapor_id = p.id` + terakhir + `)`, args
+}
+
+// syaratPelaporKantor membatasi pelapor ke yang punya surat di kantor kantorID; 0 berarti semua pelapor
+func syaratPelaporKantor(kantorID int, args []interface{}) (string, []interface{}) {
+	if kantorID == 0 {
+		return "", args
+	}
+	syarat, args := saringKantor("s.kantor_id", kantorID, args)
+	return ` AND EXISTS (SELECT 1 FROM surat s WHERE s.pelapor_id = p.id` + syarat + `)`, args
+}
+
+func scanPelapor(row interface{ Scan(...interface{}) error }) (*model.Pelapor, error) {
+	p := &model.Pelapor{}
+	var dibuat, diubah sql.NullTime
+	var terakhir sql.NullString
+	err := row.Scan(&p.ID, &p.NIK, &p.Nama, &p.TTL, &p.Agama, &p.Kelamin, &p.Pekerjaan, &p.Alamat,
+		&dibuat, &diubah, &p.JumlahSurat, &terakhir)
+	if err != nil {
+		return nil, err
+	}
+	p.CreatedAt = dibuat.Time
+	p.UpdatedAt = diubah.Time
+	// MAX() mengembalikan teks apa adanya, jadi diurai dengan format penyimpanan waktu driver
+	if terakhir.Valid {
+		p.SuratTerakhir, _ = time.Parse("2006-01-02 15:04:05.999999999-07:00", terakhir.String)
+	}
+	return p, nil
+}
+
+// CariPelapor mencari pelapor berdasarkan awalan NIK atau potongan nama, yang terakhir diubah lebih dulu.
+// Teks kosong mengembalikan pelapor terbaru. kantorID selain 0 hanya mencari pelapor yang pernah
+// melapor di kantor itu.
+func (r *SuratRepository) CariPelapor(teks string, kantorID, batas int) ([]model.Pelapor, error) {
+	teks = strings.TrimSpace(teks)
+	kolom, args := kolomPelapor(kantorID, nil)
+	query := `SELECT ` + kolom + ` FROM pelapor p WHERE 1=1`
+	if teks != "" {
+		query += ` AND (p.nik LIKE ? ESCAPE '\' OR p.nama LIKE ? ESCAPE '\')`
+		pola := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(teks)
+		args = append(args, pola+"%", "%"+pola+"%")
+	}
+	syarat, args := syaratPelaporKantor(kantorID, args)
+	query += syarat + ` ORDER BY p.updated_at DESC, p.id DESC LIMIT ?`
+	args = append(args, batas)
+
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var daftar []model.Pelapor
+	for rows.Next() {
+		p, err := scanPelapor(rows)
+		if err != nil {
+			return nil, err
+		}
+		daftar = append(daftar, *p)
+	}
+	return daftar, rows.Err()
+}
+
+// GetPelaporByID mengambil satu pelapor beserta jumlah suratnya. kantorID selain 0 hanya menghitung
+// surat kantor itu, dan pelapor yang belum pernah melapor di kantor itu dianggap tidak ada.
+func (r *SuratRepository) GetPelaporByID(id, kantorID int) (*model.Pelapor, error) {
+	kolom, args := kolomPelapor(kantorID, nil)
+	args = append(args, id)
+	syarat, args := syaratPelaporKantor(kantorID, args)
+	return scanPelapor(r.DB.QueryRow(`SELECT `+kolom+` FROM pelapor p WHERE p.id = ?`+syarat, args...))
+}
+
+// GetSuratPelapor mengambil surat milik pelapor, yang terbaru lebih dulu. kantorID selain 0 hanya
+// mengambil surat kantor itu.
+func (r *SuratRepository) GetSuratPelapor(pelaporID, kantorID int) ([]model.SuratKeteranganHilang, error) {
+	syarat, args := saringKantor("kantor_id", kantorID, []interface{}{pelaporID})
+	rows, err := r.DB.Query(`SELECT id FROM surat WHERE pelapor_id = ?`+syarat+` ORDER BY tanggal_surat DESC, id DESC`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return r.GetSuratByIDs(ids)
+}
+
+// HitungLaporanPelapor menghitung surat aktif pelapor dengan NIK tersebut per jenis barang sejak
+// waktu tertentu. Surat perpanjangan tidak dihitung karena bukan laporan kehilangan baru.
+func (r *SuratRepository) HitungLaporanPelapor(nik string, sejak time.Time) (map[string]int, error) {
+	rows, err := r.DB.Query(`
+		SELECT b.jenis_barang, COUNT(DISTINCT s.id)
+		FROM surat s
+		JOIN pelapor p ON p.id = s.pelapor_id
+		JOIN barang b ON b.surat_id = s.id
+		WHERE p.nik = ? AND s.status = ? AND s.surat_asal_id IS NULL AND s.tanggal_surat >= ?
+		GROUP BY b.jenis_barang`,
+		nik, model.StatusAktif, sejak,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	hasil := map[string]int{}
+	for rows.Next() {
+		var jenis string
+		var jumlah int
+		if err := rows.Scan(&jenis, &jumlah); err != nil {
+			return nil, err
+		}
+		hasil[jenis] = jumlah
+	}
+	return hasil, rows.Err()
+}
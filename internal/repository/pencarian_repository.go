@@ -0,0 +1,251 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"skh_app/internal/model"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ErrKursorTidakValid dikembalikan jika kursor Setelah/Sebelum rusak atau bukan dari urutan yang sama
+var ErrKursorTidakValid = errors.New("kursor halaman tidak valid")
+
+// urutan adalah ekspresi ORDER BY untuk satu pilihan urut. nilai dibaca bersama setiap baris untuk
+// kursor; tanggal dibaca sebagai teks agar perbandingan keyset memakai nilai persis yang tersimpan.
+type urutan struct {
+	ekspresi string
+	nilai    string
+}
+
+var daftarUrutan = map[string]urutan{
+	model.UrutTanggal: {"s.tanggal_surat", "CAST(s.tanggal_surat AS TEXT)"},
+	model.UrutNomor:   {"s.nomor_surat", "s.nomor_surat"},
+	model.UrutPelapor: {"s.pelapor_nama", "s.pelapor_nama"},
+	// bm25 makin kecil makin relevan; bobot kolom: nomor surat, nama pelapor, lokasi, barang
+	model.UrutRelevansi: {"bm25(surat_fts, 10.0, 5.0, 2.0, 4.0)", "bm25(surat_fts, 10.0, 5.0, 2.0, 4.0)"},
+}
+
+// kursor menandai posisi baris terakhir (atau pertama) sebuah halaman
+type kursor struct {
+	Urut  string `json:"u"`
+	Nilai string `json:"v"`
+	ID    int    `json:"id"`
+}
+
+func (k kursor) encode() string {
+	b, _ := json.Marshal(k)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeKursor(s, urut string) (kursor, error) {
+	var k kursor
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || json.Unmarshal(b, &k) != nil || k.Urut != urut {
+		return k, ErrKursorTidakValid
+	}
+	return k, nil
+}
+
+// CariSurat mengambil semua surat (tanpa detail barang) yang cocok dengan filter, tanpa paginasi
+func (r *SuratRepository) CariSurat(f model.SuratFilter) ([]model.SuratKeteranganHilang, error) {
+	f.Batas, f.Setelah, f.Sebelum = 0, "", ""
+	halaman, err := r.CariSuratHalaman(f)
+	if err != nil {
+		return nil, err
+	}
+	return halaman.Surats, nil
+}
+
+// CariSuratHalaman mengambil satu halaman daftar surat (tanpa detail barang) dengan paginasi keyset:
+// halaman berikutnya dibaca dengan WHERE (kolom urut, id) > kursor, bukan OFFSET, sehingga tetap
+// cepat di halaman mana pun.
+func (r *SuratRepository) CariSuratHalaman(f model.SuratFilter) (*model.HalamanSurat, error) {
+	hariIni := r.awalHariIni()
+	match := ftsQuery(f.Query)
+
+	urut := f.Urut
+	if urut == "" && match != "" {
+		urut = model.UrutRelevansi
+	}
+	if urut == "" || (urut == model.UrutRelevansi && match == "") {
+		urut = model.UrutTanggal
+	}
+	u, ok := daftarUrutan[urut]
+	if !ok {
+		return nil, fmt.Errorf("urutan %q tidak dikenal", f.Urut)
+	}
+	// Tanpa pilihan arah, tanggal terbaru lebih dulu; relevansi selalu dari skor bm25 terkecil
+	naik := f.Naik || urut == model.UrutRelevansi
+	// Halaman sebelumnya dibaca dengan urutan terbalik mulai dari kursor, lalu hasilnya dibalik lagi
+	mundur := f.Sebelum != ""
+
+	query := `
+		SELECT s.id, s.nomor_surat, s.tanggal_surat, s.pelapor_nama, s.status, s.berlaku_sampai, s.surat_asal_id,
+			(SELECT lanjut.id FROM surat lanjut WHERE lanjut.surat_asal_id = s.id AND lanjut.status = 'aktif' LIMIT 1),
+			s.pelapor_id, s.tanda_berulang, s.kantor_id, s.revisi, `
+	args := []interface{}{}
+	if match != "" {
+		// Cuplikan diambil dari kolom yang paling cocok (-1), maksimal 12 token
+		query += `snippet(surat_fts, -1, ?, ?, '…', 12), ` + u.nilai + `
+		FROM surat s JOIN surat_fts ON surat_fts.rowid = s.id
+		WHERE surat_fts MATCH ?`
+		args = append(args, model.SorotAwal, model.SorotAkhir, match)
+	} else {
+		query += `'', ` + u.nilai + ` FROM surat s WHERE 1=1`
+	}
+	switch f.Status {
+	case model.StatusAktif, model.StatusDibatalkan:
+		query += " AND s.status = ?"
+		args = append(args, f.Status)
+	case model.FilterBerlaku:
+		query += " AND s.status = ? AND s.berlaku_sampai >= ?"
+		args = append(args, model.StatusAktif, hariIni)
+	case model.FilterKedaluwarsa:
+		query += " AND s.status = ? AND s.berlaku_sampai < ?"
+		args = append(args, model.StatusAktif, hariIni)
+	}
+	if !f.Dari.IsZero() {
+		query += " AND s.tanggal_surat >= ?"
+		args = append(args, f.Dari)
+	}
+	if !f.Sampai.IsZero() {
+		query += " AND s.tanggal_surat < ?"
+		args = append(args, f.Sampai)
+	}
+	if f.JenisBarang != "" {
+		query += " AND EXISTS (SELECT 1 FROM barang b WHERE b.surat_id = s.id AND b.jenis_barang = ?)"
+		args = append(args, f.JenisBarang)
+	}
+	if f.PenerimaID != 0 {
+		query += " AND s.penerima_id = ?"
+		args = append(args, f.PenerimaID)
+	}
+	if f.KantorID != 0 {
+		query += " AND s.kantor_id = ?"
+		args = append(args, f.KantorID)
+	}
+
+	arahNaik := naik != mundur
+	if posisi := f.Setelah + f.Sebelum; posisi != "" {
+		k, err := decodeKursor(posisi, urut)
+		if err != nil {
+			return nil, err
+		}
+		var nilai interface{} = k.Nilai
+		if urut == model.UrutRelevansi {
+			skor, err := strconv.ParseFloat(k.Nilai, 64)
+			if err != nil {
+				return nil, ErrKursorTidakValid
+			}
+			nilai = skor
+		}
+		op := "<"
+		if arahNaik {
+			op = ">"
+		}
+		query += fmt.Sprintf(" AND (%s, s.id) %s (?, ?)", u.ekspresi, op)
+		args = append(args, nilai, k.ID)
+	}
+	arah := "DESC"
+	if arahNaik {
+		arah = "ASC"
+	}
+	query += fmt.Sprintf(" ORDER BY %s %s, s.id %s", u.ekspresi, arah, arah)
+	if f.Batas > 0 {
+		query += " LIMIT ?"
+		args = append(args, f.Batas+1) // satu baris lebih untuk mengetahui ada halaman lanjutan
+	}
+
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var surats []model.SuratKeteranganHilang
+	var posisi []kursor
+	for rows.Next() {
+		var s model.SuratKeteranganHilang
+		var berlaku sql.NullTime
+		var asalID, lanjutID, pelaporID, kantorID sql.NullInt64
+		var nilai interface{}
+		if err := rows.Scan(&s.ID, &s.NomorSurat, &s.TanggalSurat, &s.PelaporNama, &s.Status, &berlaku, &asalID, &lanjutID,
+			&pelaporID, &s.TandaBerulang, &kantorID, &s.Revisi, &s.Cuplikan, &nilai); err != nil {
+			return nil, err
+		}
+		s.BerlakuSampai = berlaku.Time
+		s.SuratAsalID = int(asalID.Int64)
+		s.PerpanjanganID = int(lanjutID.Int64)
+		s.PelaporID = int(pelaporID.Int64)
+		s.KantorID = int(kantorID.Int64)
+		s.Kedaluwarsa = isKedaluwarsa(&s, hariIni)
+		surats = append(surats, s)
+		posisi = append(posisi, kursor{Urut: urut, Nilai: nilaiKursor(nilai), ID: s.ID})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	adaLagi := f.Batas > 0 && len(surats) > f.Batas
+	if adaLagi {
+		surats, posisi = surats[:f.Batas], posisi[:f.Batas]
+	}
+	if mundur {
+		for i, j := 0, len(surats)-1; i < j; i, j = i+1, j-1 {
+			surats[i], surats[j] = surats[j], surats[i]
+			posisi[i], posisi[j] = posisi[j], posisi[i]
+		}
+	}
+
+	halaman := &model.HalamanSurat{Surats: surats}
+	if len(surats) == 0 || f.Batas == 0 {
+		return halaman, nil
+	}
+	pertama, terakhir := posisi[0].encode(), posisi[len(posisi)-1].encode()
+	if mundur {
+		halaman.Berikutnya = terakhir
+		if adaLagi {
+			halaman.Sebelumnya = pertama
+		}
+	} else {
+		if adaLagi {
+			halaman.Berikutnya = terakhir
+		}
+		if f.Setelah != "" {
+			halaman.Sebelumnya = pertama
+		}
+	}
+	return halaman, nil
+}
+
+// nilaiKursor mengubah nilai kolom urut menjadi teks tanpa kehilangan presisi
+func nilaiKursor(v interface{}) string {
+	switch v := v.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case []byte:
+		return string(v)
+	case string:
+		return v
+	}
+	return ""
+}
+
+// ftsQuery mengubah teks pencarian pengguna menjadi query FTS5. Setiap kata dikutip agar karakter
+// seperti "-", "/" atau kata kunci AND/OR tidak dibaca sebagai sintaks, lalu dicari sebagai awalan
+// sehingga "7371" menemukan NIK 7371xxxxxxxxxxxx. Semua kata harus cocok.
+func ftsQuery(q string) string {
+	kata := strings.FieldsFunc(q, func(c rune) bool {
+		return !unicode.IsLetter(c) && !unicode.IsDigit(c)
+	})
+	for i, k := range kata {
+		kata[i] = `"` + k + `"*`
+	}
+	return strings.Join(kata, " ")
+}
@@ -0,0 +1,302 @@
+//go:build sqlite_fts5
+
+package repository
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"skh_app/internal/model"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFtsQuery(t *testing.T) {
+	tests := map[string]string{
+		"":                      "",
+		"   ":                   "",
+		"budi":                  `"budi"*`,
+		"  Budi   Santoso ":     `"Budi"* "Santoso"*`,
+		"SKH/001/VIII/2025":     `"SKH"* "001"* "VIII"* "2025"*`,
+		"DN-1234-AB":            `"DN"* "1234"* "AB"*`,
+		"7371011508940001":      `"7371011508940001"*`,
+		`budi" OR nama:siti*`:   `"budi"* "OR"* "nama"* "siti"*`,
+		"budi AND NOT siti":     `"budi"* "AND"* "NOT"* "siti"*`,
+		"(budi) -siti ^lokasi":  `"budi"* "siti"* "lokasi"*`,
+		"Désa Pérez":            `"Désa"* "Pérez"*`,
+		"-/.,":                  "",
+		"jl.sudirman no.5 rt02": `"jl"* "sudirman"* "no"* "5"* "rt02"*`,
+	}
+	for q, want := range tests {
+		if got := ftsQuery(q); got != want {
+			t.Errorf("ftsQuery(%q) = %s, seharusnya %s", q, got, want)
+		}
+	}
+}
+
+func TestKursor(t *testing.T) {
+	k := kursor{Urut: model.UrutTanggal, Nilai: "2025-08-07 10:00:00+00:00", ID: 42}
+	teks := k.encode()
+	if strings.ContainsAny(teks, "+/=") {
+		t.Errorf("kursor %q tidak aman untuk URL", teks)
+	}
+	got, err := decodeKursor(teks, model.UrutTanggal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != k {
+		t.Errorf("decodeKursor = %+v, seharusnya %+v", got, k)
+	}
+
+	rusak := map[string]string{
+		"urutan lain":       teks,
+		"bukan base64":      "!!!",
+		"base64 bukan json": "YWJj",
+		"kosong":            "",
+	}
+	for nama, s := range rusak {
+		urut := model.UrutTanggal
+		if nama == "urutan lain" {
+			urut = model.UrutNomor
+		}
+		if _, err := decodeKursor(s, urut); !errors.Is(err, ErrKursorTidakValid) {
+			t.Errorf("%s: decodeKursor error = %v, seharusnya ErrKursorTidakValid", nama, err)
+		}
+	}
+}
+
+// suratUji adalah isi minimal surat untuk menguji pencarian
+type suratUji struct {
+	nomor, pelapor, lokasi  string
+	tanggal                 time.Time
+	jenisBarang, dataBarang string
+}
+
+// newRepoPencarian membuat database sementara berisi surat dengan id 1..len(daftar) sesuai urutan
+func newRepoPencarian(t *testing.T, daftar []suratUji) *SuratRepository {
+	t.Helper()
+	db, err := ConnectDatabase(filepath.Join(t.TempDir(), "skh.db"), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { CloseDatabase(db) })
+
+	for _, s := range daftar {
+		res, err := db.Exec(`INSERT INTO surat (nomor_surat, tanggal_surat, pelapor_nama, lokasi_hilang, berlaku_sampai, kantor_id)
+			VALUES (?, ?, ?, ?, ?, ?)`, s.nomor, s.tanggal, s.pelapor, s.lokasi, s.tanggal.AddDate(0, 0, 14), model.KantorUtama)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.jenisBarang == "" {
+			continue
+		}
+		id, _ := res.LastInsertId()
+		if _, err := db.Exec(`INSERT INTO barang (surat_id, jenis_barang, data) VALUES (?, ?, ?)`, id, s.jenisBarang, s.dataBarang); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return NewSuratRepository(db, time.UTC)
+}
+
+func idSurat(surats []model.SuratKeteranganHilang) []int {
+	ids := []int{}
+	for _, s := range surats {
+		ids = append(ids, s.ID)
+	}
+	return ids
+}
+
+var tanggalUji = time.Date(2025, time.August, 1, 9, 0, 0, 0, time.UTC)
+
+var suratPencarian = []suratUji{
+	{nomor: "SKH/001/VIII/2025", pelapor: "Budi Santoso", lokasi: "Pasar Sentral", tanggal: tanggalUji,
+		jenisBarang: "KTP", dataBarang: `{"nomor":"7371011508940001"}`},
+	{nomor: "SKH/002/VIII/2025", pelapor: "Siti Aminah", lokasi: "Terminal Daya", tanggal: tanggalUji.AddDate(0, 0, 1),
+		jenisBarang: "SIM", dataBarang: `{"nomor":"940812345678","jenis":"C"}`},
+	{nomor: "SKH/003/VIII/2025", pelapor: "Budiman", lokasi: "Pantai Losari", tanggal: tanggalUji.AddDate(0, 0, 2)},
+	{nomor: "SKH/004/VIII/2025", pelapor: "Andi Budi", lokasi: "Pasar Butung", tanggal: tanggalUji.AddDate(0, 0, 3),
+		jenisBarang: "STNK", dataBarang: `{"nopol":"DD 1234 XY"}`},
+	{nomor: "SKH/005/VIII/2025", pelapor: "Dewi Lestari", lokasi: "Jalan Perintis", tanggal: tanggalUji.AddDate(0, 0, 4)},
+}
+
+func TestCariSuratFTS(t *testing.T) {
+	repo := newRepoPencarian(t, suratPencarian)
+
+	tests := []struct {
+		query string
+		want  []int // id surat yang cocok, urut naik
+	}{
+		{"budi", []int{1, 3, 4}}, // awalan kata: Budiman ikut cocok
+		{"BUDI pasar", []int{1, 4}},
+		{"7371", []int{1}},        // isian barang (NIK) ikut diindeks
+		{"dd 1234", []int{4}},     // nomor polisi dipecah per kata
+		{"SKH/002", []int{2}},     // pemisah nomor surat bukan sintaks FTS5
+		{"Sîti", []int{2}},        // tanda diakritik diabaikan
+		{"budi OR siti", []int{}}, // OR dicari sebagai kata, bukan operator
+		{`budi" -`, []int{1, 3, 4}},
+		{"lestari santoso", []int{}},
+		{"-/", []int{1, 2, 3, 4, 5}}, // tanpa kata berarti tanpa saringan
+	}
+	for _, tt := range tests {
+		halaman, err := repo.CariSuratHalaman(model.SuratFilter{Query: tt.query, Urut: model.UrutNomor, Naik: true})
+		if err != nil {
+			t.Errorf("CariSuratHalaman(%q) error: %v", tt.query, err)
+			continue
+		}
+		if got := idSurat(halaman.Surats); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("CariSuratHalaman(%q) = %v, seharusnya %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestCariSuratCuplikan(t *testing.T) {
+	repo := newRepoPencarian(t, suratPencarian)
+
+	tests := []struct {
+		query string
+		id    int
+		sorot string // teks yang harus tampil diapit SorotAwal dan SorotAkhir
+	}{
+		{"santoso", 1, "Santoso"},
+		{"7371", 1, "7371011508940001"},
+		{"losari", 3, "Losari"},
+		{"siti", 2, "Siti"},
+	}
+	for _, tt := range tests {
+		halaman, err := repo.CariSuratHalaman(model.SuratFilter{Query: tt.query})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(halaman.Surats) != 1 || halaman.Surats[0].ID != tt.id {
+			t.Errorf("%q: hasil = %v, seharusnya [%d]", tt.query, idSurat(halaman.Surats), tt.id)
+			continue
+		}
+		cuplikan := halaman.Surats[0].Cuplikan
+		if !strings.Contains(cuplikan, model.SorotAwal+tt.sorot+model.SorotAkhir) {
+			t.Errorf("%q: cuplikan %q tidak menyorot %q", tt.query, cuplikan, tt.sorot)
+		}
+		if strings.Count(cuplikan, model.SorotAwal) != strings.Count(cuplikan, model.SorotAkhir) {
+			t.Errorf("%q: penanda sorot tidak berpasangan: %q", tt.query, cuplikan)
+		}
+	}
+
+	// Tanpa teks pencarian tidak ada cuplikan
+	halaman, err := repo.CariSuratHalaman(model.SuratFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range halaman.Surats {
+		if s.Cuplikan != "" {
+			t.Errorf("surat %d punya cuplikan %q tanpa pencarian", s.ID, s.Cuplikan)
+		}
+	}
+}
+
+// TestCariSuratHalamanKursor menelusuri semua halaman maju lalu mundur lewat kursor. Hasilnya harus
+// sama dengan daftar tanpa paginasi, termasuk untuk surat yang nilai urutnya kembar.
+func TestCariSuratHalamanKursor(t *testing.T) {
+	var daftar []suratUji
+	for i := 0; i < 11; i++ {
+		// Tiga surat per hari dan nama pelapor berulang agar urutan kembar ditentukan oleh id
+		daftar = append(daftar, suratUji{
+			nomor:   "SKH/" + string(rune('A'+i)) + "/2025",
+			pelapor: []string{"Budi", "Siti", "Budi Santoso"}[i%3],
+			lokasi:  "Pasar",
+			tanggal: tanggalUji.AddDate(0, 0, i/3),
+		})
+	}
+	repo := newRepoPencarian(t, daftar)
+
+	filters := map[string]model.SuratFilter{
+		"tanggal terbaru":      {},
+		"tanggal terlama":      {Urut: model.UrutTanggal, Naik: true},
+		"nomor naik":           {Urut: model.UrutNomor, Naik: true},
+		"nomor turun":          {Urut: model.UrutNomor},
+		"pelapor naik":         {Urut: model.UrutPelapor, Naik: true},
+		"relevansi":            {Query: "budi"},
+		"relevansi pasar":      {Query: "pasar"},
+		"pencarian urut nomor": {Query: "budi", Urut: model.UrutNomor, Naik: true},
+	}
+	for nama, f := range filters {
+		semua, err := repo.CariSuratHalaman(f)
+		if err != nil {
+			t.Fatalf("%s: %v", nama, err)
+		}
+		harus := idSurat(semua.Surats)
+		if len(harus) == 0 {
+			t.Fatalf("%s: tidak ada surat", nama)
+		}
+
+		for _, batas := range []int{1, 2, 4, len(harus), len(harus) + 1} {
+			f := f
+			f.Batas = batas
+
+			// Maju dari halaman pertama
+			var maju []int
+			var halamanMaju [][]int
+			var kursorAkhir string
+			for n := 0; ; n++ {
+				if n > len(harus) {
+					t.Fatalf("%s batas %d: kursor berikutnya tidak pernah habis", nama, batas)
+				}
+				h, err := repo.CariSuratHalaman(f)
+				if err != nil {
+					t.Fatalf("%s batas %d: %v", nama, batas, err)
+				}
+				if len(h.Surats) > batas {
+					t.Fatalf("%s batas %d: halaman berisi %d surat", nama, batas, len(h.Surats))
+				}
+				if (n == 0) != (h.Sebelumnya == "") {
+					t.Errorf("%s batas %d: halaman %d kursor sebelumnya = %q", nama, batas, n, h.Sebelumnya)
+				}
+				maju = append(maju, idSurat(h.Surats)...)
+				halamanMaju = append(halamanMaju, idSurat(h.Surats))
+				if h.Berikutnya == "" {
+					kursorAkhir = h.Sebelumnya
+					break
+				}
+				f.Setelah, f.Sebelum = h.Berikutnya, ""
+			}
+			if !reflect.DeepEqual(maju, harus) {
+				t.Errorf("%s batas %d: maju = %v, seharusnya %v", nama, batas, maju, harus)
+				continue
+			}
+
+			// Mundur dari halaman terakhir sampai halaman pertama
+			f.Setelah, f.Sebelum = "", kursorAkhir
+			for i := len(halamanMaju) - 2; i >= 0; i-- {
+				h, err := repo.CariSuratHalaman(f)
+				if err != nil {
+					t.Fatalf("%s batas %d: %v", nama, batas, err)
+				}
+				if got := idSurat(h.Surats); !reflect.DeepEqual(got, halamanMaju[i]) {
+					t.Errorf("%s batas %d: mundur ke halaman %d = %v, seharusnya %v", nama, batas, i, got, halamanMaju[i])
+				}
+				if h.Berikutnya == "" {
+					t.Errorf("%s batas %d: halaman %d tanpa kursor berikutnya", nama, batas, i)
+				}
+				if (i == 0) != (h.Sebelumnya == "") {
+					t.Errorf("%s batas %d: halaman %d kursor sebelumnya = %q", nama, batas, i, h.Sebelumnya)
+				}
+				f.Sebelum = h.Sebelumnya
+			}
+		}
+	}
+
+	// Kursor dari urutan lain atau yang rusak ditolak
+	h, err := repo.CariSuratHalaman(model.SuratFilter{Urut: model.UrutNomor, Batas: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rusak := []model.SuratFilter{
+		{Urut: model.UrutTanggal, Batas: 2, Setelah: h.Berikutnya},
+		{Urut: model.UrutNomor, Batas: 2, Sebelum: "bukan-kursor"},
+		{Query: "budi", Batas: 2, Setelah: kursor{Urut: model.UrutRelevansi, Nilai: "x", ID: 1}.encode()},
+	}
+	for _, f := range rusak {
+		if _, err := repo.CariSuratHalaman(f); !errors.Is(err, ErrKursorTidakValid) {
+			t.Errorf("filter %+v: error = %v, seharusnya ErrKursorTidakValid", f, err)
+		}
+	}
+}
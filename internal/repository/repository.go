@@ -2,6 +2,7 @@ package repository
 
 import (
 	"database/sql"
+	"fmt"
 	"skh_app/internal/model"
 	"strings" // <-- PERBAIKAN DI SINI
 	"time"
@@ -9,53 +10,95 @@ import (
 
 // Struct utama untuk semua interaksi database
 type SuratRepository struct {
-	DB *sql.DB
+	DB     *sql.DB
+	Lokasi *time.Location // zona waktu kantor untuk batas hari dan bulan
 }
 
-// Constructor untuk membuat instance repository baru
-func NewSuratRepository(db *sql.DB) *SuratRepository {
-	return &SuratRepository{DB: db}
+// queryer dipenuhi oleh *sql.DB maupun *sql.Tx, sehingga fungsi baca bisa dipakai di dalam transaksi
+type queryer interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+	Query(query string, args ...interface{}) (*sql.Rows, error)
 }
 
-func (r *SuratRepository) ResetNomorCounterIfEmpty() error {
+// Constructor untuk membuat instance repository baru. loc adalah zona waktu kantor.
+func NewSuratRepository(db *sql.DB, loc *time.Location) *SuratRepository {
+	return &SuratRepository{DB: db, Lokasi: loc}
+}
+
+// resetNomorCounterIfEmpty mengosongkan penghitung nomor kantor jika kantor itu belum punya surat sama sekali.
+// Dipanggil di dalam transaksi CreateSurat agar tidak bertabrakan dengan pembuatan surat lain.
+func resetNomorCounterIfEmpty(tx *sql.Tx, kantorID, tahun int) error {
 	var count int
-	err := r.DB.QueryRow("SELECT COUNT(id) FROM surat").Scan(&count)
-	if err != nil {
+	if err := tx.QueryRow("SELECT COUNT(id) FROM surat WHERE kantor_id = ?", kantorID).Scan(&count); err != nil {
 		return err
 	}
+	if count > 0 {
+		return nil
+	}
 
-	if count == 0 {
-		loc, _ := time.LoadLocation("Asia/Makassar")
-		tahunSekarang := time.Now().In(loc).Year()
+	// Reset nomor urut di penghitung dan pengaturan kantor
+	if _, err := tx.Exec("DELETE FROM nomor_counter WHERE kantor_id = ?", kantorID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE pengaturan SET last_nomor_surat = 0, last_nomor_year = ? WHERE id = ?", tahun, kantorID); err != nil {
+		return err
+	}
 
-		tx, err := r.DB.Begin()
-		if err != nil {
+	// Reset ID counter di tabel surat jika belum ada surat di kantor mana pun
+	if err := tx.QueryRow("SELECT COUNT(id) FROM surat").Scan(&count); err != nil || count > 0 {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE sqlite_sequence SET seq = 0 WHERE name = 'surat'"); err != nil {
+		if !strings.Contains(err.Error(), "no such table") {
 			return err
 		}
-		defer tx.Rollback()
+	}
+	return nil
+}
 
-		// Reset nomor urut di pengaturan
-		_, err = tx.Exec("UPDATE pengaturan SET last_nomor_surat = 0, last_nomor_year = ? WHERE id = 1", tahunSekarang)
-		if err != nil {
-			return err
-		}
+// GetNomorCounter mengembalikan nomor terakhir kantor yang sudah terpakai pada periode
+func (r *SuratRepository) GetNomorCounter(kantorID int, periode string) (int, error) {
+	var nomor int
+	err := r.DB.QueryRow("SELECT last_nomor FROM nomor_counter WHERE kantor_id = ? AND periode = ?", kantorID, periode).Scan(&nomor)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return nomor, err
+}
 
-		// Reset ID counter di tabel surat
-		_, err = tx.Exec("UPDATE sqlite_sequence SET seq = 0 WHERE name = 'surat'")
-		if err != nil {
-			if !strings.Contains(err.Error(), "no such table") {
-				return err
-			}
-		}
+// nextNomor menaikkan penghitung periode kantor secara atomik dan mengembalikan nomor baru
+func nextNomor(tx *sql.Tx, kantorID int, periode string) (int, error) {
+	var nomor int
+	err := tx.QueryRow(`
+		INSERT INTO nomor_counter (kantor_id, periode, last_nomor) VALUES (?, ?, 1)
+		ON CONFLICT(kantor_id, periode) DO UPDATE SET last_nomor = last_nomor + 1
+		RETURNING last_nomor`, kantorID, periode).Scan(&nomor)
+	return nomor, err
+}
 
-		return tx.Commit()
-	}
-	return nil
+// lanjutkanNomorTahunan menaikkan penghitung periode kantor ke nomor tertinggi yang sudah terpakai
+// pada tahun yang sama, baik dari penghitung tahunan maupun bulanan. Dipanggil saat reset nomor
+// diganti agar periode baru tidak mengulang nomor yang sudah dikeluarkan pada bulan berjalan.
+func lanjutkanNomorTahunan(tx *sql.Tx, kantorID int, periode string) error {
+	tahun := periode[:4]
+	_, err := tx.Exec(`
+		INSERT INTO nomor_counter (kantor_id, periode, last_nomor)
+		SELECT ?, ?, COALESCE(MAX(last_nomor), 0) FROM nomor_counter
+		WHERE kantor_id = ? AND (periode = ? OR periode LIKE ? || '-%')
+		ON CONFLICT(kantor_id, periode) DO UPDATE SET last_nomor = MAX(last_nomor, excluded.last_nomor)`,
+		kantorID, periode, kantorID, tahun, tahun,
+	)
+	return err
 }
 
 // --- FUNGSI PENGATURAN ---
 
-func (r *SuratRepository) GetPengaturan() (*model.Pengaturan, error) {
+// GetPengaturan mengambil pengaturan satu kantor. Kantor yang tidak ada mengembalikan sql.ErrNoRows.
+func (r *SuratRepository) GetPengaturan(kantorID int) (*model.Pengaturan, error) {
+	return getPengaturan(r.DB, kantorID)
+}
+
+func getPengaturan(q queryer, kantorID int) (*model.Pengaturan, error) {
 	pengaturan := &model.Pengaturan{
 		PejabatDetails:  &model.Petugas{},
 		PenerimaDetails: &model.Petugas{},
@@ -64,27 +107,29 @@ func (r *SuratRepository) GetPengaturan() (*model.Pengaturan, error) {
 		SELECT
 			p.id, p.kop_surat_1, p.kop_surat_2, p.kop_surat_3, p.logo_path,
 			p.format_nomor_surat, p.last_nomor_surat, p.last_nomor_year,
-			p.pejabat_id, p.penerima_id, p.wilayah, p.nama_kantor,
+			p.pejabat_id, p.penerima_id, p.wilayah, p.nama_kantor, p.kode_kantor, p.reset_nomor, p.masa_berlaku_hari,
+			p.batas_laporan_berulang, p.periode_laporan_berulang, p.batas_ubah_jam,
 			pejabat.nama, pejabat.pangkat, pejabat.nrp, pejabat.jabatan,
 			penerima.nama, penerima.pangkat, penerima.nrp, penerima.jabatan
 		FROM pengaturan p
 		LEFT JOIN petugas AS pejabat ON p.pejabat_id = pejabat.id
 		LEFT JOIN petugas AS penerima ON p.penerima_id = penerima.id
-		WHERE p.id = 1
+		WHERE p.id = ?
 	`
-	var kop1, kop2, kop3, logo, format, wilayah, kantor sql.NullString
-	var lastNomor, lastNomorYear, pejabatID, penerimaID sql.NullInt64
+	var kop1, kop2, kop3, logo, format, wilayah, kantor, kode, reset sql.NullString
+	var lastNomor, lastNomorYear, pejabatID, penerimaID, masaBerlaku, batasBerulang, periodeBerulang, batasUbah sql.NullInt64
 	var pejNama, pejPangkat, pejNRP, pejJabatan sql.NullString
 	var penNama, penPangkat, penNRP, penJabatan sql.NullString
 
-	err := r.DB.QueryRow(query).Scan(
+	err := q.QueryRow(query, kantorID).Scan(
 		&pengaturan.ID, &kop1, &kop2, &kop3, &logo, &format, &lastNomor, &lastNomorYear,
-		&pejabatID, &penerimaID, &wilayah, &kantor,
+		&pejabatID, &penerimaID, &wilayah, &kantor, &kode, &reset, &masaBerlaku,
+		&batasBerulang, &periodeBerulang, &batasUbah,
 		&pejNama, &pejPangkat, &pejNRP, &pejJabatan,
 		&penNama, &penPangkat, &penNRP, &penJabatan,
 	)
 
-	if err != nil && err != sql.ErrNoRows {
+	if err != nil {
 		return nil, err
 	}
 	pengaturan.KopSurat1 = kop1.String
@@ -94,6 +139,24 @@ func (r *SuratRepository) GetPengaturan() (*model.Pengaturan, error) {
 	pengaturan.FormatNomorSurat = format.String
 	pengaturan.Wilayah = wilayah.String
 	pengaturan.NamaKantor = kantor.String
+	pengaturan.KodeKantor = kode.String
+	pengaturan.ResetNomor = reset.String
+	if pengaturan.ResetNomor == "" {
+		pengaturan.ResetNomor = model.ResetTahunan
+	}
+	pengaturan.MasaBerlakuHari = int(masaBerlaku.Int64)
+	if pengaturan.MasaBerlakuHari < 1 {
+		pengaturan.MasaBerlakuHari = model.DefaultMasaBerlakuHari
+	}
+	pengaturan.BatasLaporanBerulang = int(batasBerulang.Int64)
+	if !batasBerulang.Valid {
+		pengaturan.BatasLaporanBerulang = model.DefaultBatasLaporanBerulang
+	}
+	pengaturan.PeriodeLaporanBerulang = int(periodeBerulang.Int64)
+	if pengaturan.PeriodeLaporanBerulang < 1 {
+		pengaturan.PeriodeLaporanBerulang = model.DefaultPeriodeLaporanBerulang
+	}
+	pengaturan.BatasUbahJam = int(batasUbah.Int64)
 	pengaturan.LastNomorSurat = int(lastNomor.Int64)
 	pengaturan.LastNomorYear = int(lastNomorYear.Int64)
 	pengaturan.PejabatID = int(pejabatID.Int64)
@@ -112,47 +175,175 @@ func (r *SuratRepository) GetPengaturan() (*model.Pengaturan, error) {
 	return pengaturan, nil
 }
 
-func (r *SuratRepository) UpdatePengaturan(p *model.Pengaturan) error {
+func (r *SuratRepository) UpdatePengaturan(p *model.Pengaturan, actor string) error {
 	var query string
 	var args []interface{}
 	baseQuery := `
 		UPDATE pengaturan SET 
 			kop_surat_1 = ?, kop_surat_2 = ?, kop_surat_3 = ?, 
 			format_nomor_surat = ?, pejabat_id = ?, penerima_id = ?,
-			wilayah = ?, nama_kantor = ?, last_nomor_surat = ?, last_nomor_year = ?`
+			wilayah = ?, nama_kantor = ?, last_nomor_surat = ?, last_nomor_year = ?,
+			kode_kantor = ?, reset_nomor = ?, masa_berlaku_hari = ?,
+			batas_laporan_berulang = ?, periode_laporan_berulang = ?, batas_ubah_jam = ?`
 	args = []interface{}{
 		p.KopSurat1, p.KopSurat2, p.KopSurat3, p.FormatNomorSurat,
 		p.PejabatID, p.PenerimaID, p.Wilayah, p.NamaKantor, p.LastNomorSurat, p.LastNomorYear,
+		p.KodeKantor, p.ResetNomor, p.MasaBerlakuHari,
+		p.BatasLaporanBerulang, p.PeriodeLaporanBerulang, p.BatasUbahJam,
 	}
 
 	if p.LogoPath != "" {
-		query = baseQuery + ", logo_path = ? WHERE id = 1"
+		query = baseQuery + ", logo_path = ?"
 		args = append(args, p.LogoPath)
 	} else {
-		query = baseQuery + " WHERE id = 1"
+		query = baseQuery
 	}
-	_, err := r.DB.Exec(query, args...)
-	return err
+	query += " WHERE id = ?"
+	args = append(args, p.ID)
+
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := getPengaturan(tx, p.ID)
+	if err != nil {
+		return err
+	}
+	if err := cekKodeKantor(tx, p); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return err
+	}
+	// Pergantian reset nomor di tengah tahun melanjutkan nomor tertinggi tahun berjalan
+	if before.ResetNomor != p.ResetNomor && !p.UbahNomorTerakhir && p.NomorPeriode != "" {
+		if err := lanjutkanNomorTahunan(tx, p.ID, p.NomorPeriode); err != nil {
+			return err
+		}
+	}
+	// Nomor terakhir yang diubah manual menjadi nilai penghitung periode yang sedang berjalan
+	if p.UbahNomorTerakhir && p.NomorPeriode != "" {
+		_, err = tx.Exec(`
+			INSERT INTO nomor_counter (kantor_id, periode, last_nomor) VALUES (?, ?, ?)
+			ON CONFLICT(kantor_id, periode) DO UPDATE SET last_nomor = excluded.last_nomor`,
+			p.ID, p.NomorPeriode, p.LastNomorSurat,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	after, err := getPengaturan(tx, p.ID)
+	if err != nil {
+		return err
+	}
+	if err := simpanRevisiPengaturan(tx, after, actor); err != nil {
+		return err
+	}
+	if err := writeAudit(tx, actor, model.EntityPengaturan, p.ID, model.AuditUpdate, before, after); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 // --- FUNGSI SURAT ---
 
-func (r *SuratRepository) CreateSurat(surat *model.SuratKeteranganHilang, nomorBaru int, nomorSuratLengkap string, tahunSekarang int) (int64, error) {
+// awalHariIni mengembalikan pukul 00:00 hari ini di zona waktu kantor. Surat dengan berlaku_sampai
+// sebelum waktu ini sudah kedaluwarsa.
+func (r *SuratRepository) awalHariIni() time.Time {
+	now := time.Now().In(r.Lokasi)
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, r.Lokasi)
+}
+
+// isKedaluwarsa: surat aktif yang hari terakhir berlakunya sudah lewat
+func isKedaluwarsa(s *model.SuratKeteranganHilang, hariIni time.Time) bool {
+	return !s.IsDibatalkan() && !s.BerlakuSampai.IsZero() && s.BerlakuSampai.Before(hariIni)
+}
+
+// nullInt menyimpan 0 sebagai NULL, dipakai untuk kolom foreign key opsional
+func nullInt(v int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(v), Valid: v != 0}
+}
+
+// cekSuratAsal memastikan surat asal boleh diperpanjang: masih aktif dan belum punya perpanjangan aktif.
+// Dijalankan di dalam transaksi CreateSurat agar dua perpanjangan bersamaan tidak sama-sama lolos.
+func cekSuratAsal(tx *sql.Tx, asalID int) error {
+	var status string
+	err := tx.QueryRow("SELECT status FROM surat WHERE id = ?", asalID).Scan(&status)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("surat asal tidak ditemukan")
+	}
+	if err != nil {
+		return err
+	}
+	if status == model.StatusDibatalkan {
+		return fmt.Errorf("surat yang sudah dibatalkan tidak dapat diperpanjang")
+	}
+
+	var nomor string
+	err = tx.QueryRow("SELECT nomor_surat FROM surat WHERE surat_asal_id = ? AND status = ? LIMIT 1", asalID, model.StatusAktif).Scan(&nomor)
+	if err == nil {
+		return fmt.Errorf("surat sudah diperpanjang dengan nomor %s", nomor)
+	}
+	if err != sql.ErrNoRows {
+		return err
+	}
+	return nil
+}
+
+// CreateSurat menyimpan surat baru. Nomor urut dialokasikan di dalam transaksi yang sama,
+// lalu formatNomor dipanggil untuk menyusun nomor surat lengkap yang disimpan ke surat.NomorSurat.
+func (r *SuratRepository) CreateSurat(surat *model.SuratKeteranganHilang, periode string, formatNomor func(nomor int) (string, error), actor string) (int64, error) {
 	tx, err := r.DB.Begin()
 	if err != nil {
 		return 0, err
 	}
 	defer tx.Rollback()
 
-	_, err = tx.Exec("UPDATE pengaturan SET last_nomor_surat = ?, last_nomor_year = ? WHERE id = 1", nomorBaru, tahunSekarang)
+	if surat.SuratAsalID != 0 {
+		if err := cekSuratAsal(tx, surat.SuratAsalID); err != nil {
+			return 0, err
+		}
+	}
+
+	tahun := surat.TanggalSurat.Year()
+	if err := resetNomorCounterIfEmpty(tx, surat.KantorID, tahun); err != nil {
+		return 0, err
+	}
+	nomorBaru, err := nextNomor(tx, surat.KantorID, periode)
+	if err != nil {
+		return 0, err
+	}
+	nomorSuratLengkap, err := formatNomor(nomorBaru)
+	if err != nil {
+		return 0, err
+	}
+	surat.NomorSurat = nomorSuratLengkap
+
+	pelaporID, err := simpanPelapor(tx, surat)
+	if err != nil {
+		return 0, fmt.Errorf("gagal menyimpan data pelapor: %w", err)
+	}
+
+	// Pengaturan tetap menyimpan nomor terakhir agar tampil di halaman pengaturan
+	_, err = tx.Exec("UPDATE pengaturan SET last_nomor_surat = ?, last_nomor_year = ? WHERE id = ?", nomorBaru, tahun, surat.KantorID)
 	if err != nil {
 		return 0, err
 	}
 
 	res, err := tx.Exec(`
-		INSERT INTO surat (nomor_surat, tanggal_surat, pelapor_nama, pelapor_ttl, pelapor_agama, pelapor_kelamin, pelapor_pekerjaan, pelapor_alamat, lokasi_hilang)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		INSERT INTO surat (nomor_surat, tanggal_surat, pelapor_nama, pelapor_ttl, pelapor_agama, pelapor_kelamin, pelapor_pekerjaan, pelapor_alamat, lokasi_hilang,
+			berlaku_sampai, surat_asal_id, penerima_id, pelapor_id, tanda_berulang,
+			pejabat_id, pejabat_nama, pejabat_pangkat, pejabat_nrp, pejabat_jabatan,
+			penerima_nama, penerima_pangkat, penerima_nrp, penerima_jabatan, kantor_id, pengaturan_revisi_id, kode_verifikasi)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?,
+			(SELECT MAX(id) FROM pengaturan_revisi WHERE kantor_id = ?), lower(hex(randomblob(16))))`,
 		nomorSuratLengkap, surat.TanggalSurat, surat.PelaporNama, surat.PelaporTTL, surat.PelaporAgama, surat.PelaporKelamin, surat.PelaporPekerjaan, surat.PelaporAlamat, surat.LokasiHilang,
+		surat.BerlakuSampai, nullInt(surat.SuratAsalID), nullInt(surat.PenerimaID), nullInt(pelaporID), surat.TandaBerulang,
+		nullInt(surat.PejabatID), surat.Pejabat.Nama, surat.Pejabat.Pangkat, surat.Pejabat.NRP, surat.Pejabat.Jabatan,
+		surat.Penerima.Nama, surat.Penerima.Pangkat, surat.Penerima.NRP, surat.Penerima.Jabatan,
+		surat.KantorID, surat.KantorID,
 	)
 	if err != nil {
 		return 0, err
@@ -167,23 +358,62 @@ func (r *SuratRepository) CreateSurat(surat *model.SuratKeteranganHilang, nomorB
 		}
 	}
 
+	after, err := r.getSuratByID(tx, int(suratID))
+	if err != nil {
+		return 0, err
+	}
+	if err := simpanRevisiSurat(tx, after, actor); err != nil {
+		return 0, err
+	}
+	if err := writeAudit(tx, actor, model.EntitySurat, int(suratID), model.AuditCreate, nil, after); err != nil {
+		return 0, err
+	}
+
 	return suratID, tx.Commit()
 }
 
-func (r *SuratRepository) UpdateSurat(surat *model.SuratKeteranganHilang) error {
+// UpdateSurat menyimpan perubahan isi surat sebagai revisi baru. Revisi sebelumnya tetap tersimpan
+// di surat_revisi sehingga isi surat yang pernah dicetak masih dapat dibandingkan. Perubahan yang
+// tidak mengubah isi surat tidak menambah revisi. Batas ubah surat diperiksa lagi di dalam transaksi,
+// jadi perubahan yang dikirim tepat setelah masa ubah lewat tetap ditolak.
+func (r *SuratRepository) UpdateSurat(surat *model.SuratKeteranganHilang, actor string) error {
 	tx, err := r.DB.Begin()
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
+	before, err := r.getSuratByID(tx, surat.ID)
+	if err != nil {
+		return err
+	}
+	if before.IsDibatalkan() {
+		return fmt.Errorf("surat yang sudah dibatalkan tidak dapat diubah")
+	}
+	pengaturan, err := getPengaturan(tx, before.KantorID)
+	if err != nil {
+		return err
+	}
+	if err := before.CekBolehUbah(pengaturan.BatasUbahJam, time.Now().In(r.Lokasi)); err != nil {
+		return err
+	}
+	if model.RevisiDariSurat(surat).SamaDengan(model.RevisiDariSurat(before)) {
+		return nil
+	}
+
+	pelaporID, err := simpanPelapor(tx, surat)
+	if err != nil {
+		return fmt.Errorf("gagal menyimpan data pelapor: %w", err)
+	}
+
 	_, err = tx.Exec(`
 		UPDATE surat SET 
-		tanggal_surat = ?, pelapor_nama = ?, pelapor_ttl = ?, pelapor_agama = ?, 
-		pelapor_kelamin = ?, pelapor_pekerjaan = ?, pelapor_alamat = ?, lokasi_hilang = ? 
+		pelapor_nama = ?, pelapor_ttl = ?, pelapor_agama = ?, 
+		pelapor_kelamin = ?, pelapor_pekerjaan = ?, pelapor_alamat = ?, lokasi_hilang = ?, pelapor_id = ?,
+		revisi = revisi + 1
 		WHERE id = ?`,
-		surat.TanggalSurat, surat.PelaporNama, surat.PelaporTTL, surat.PelaporAgama,
-		surat.PelaporKelamin, surat.PelaporPekerjaan, surat.PelaporAlamat, surat.LokasiHilang, surat.ID,
+		surat.PelaporNama, surat.PelaporTTL, surat.PelaporAgama,
+		surat.PelaporKelamin, surat.PelaporPekerjaan, surat.PelaporAlamat, surat.LokasiHilang, nullInt(pelaporID), surat.ID,
 	)
 	if err != nil {
 		return err
@@ -201,28 +431,134 @@ func (r *SuratRepository) UpdateSurat(surat *model.SuratKeteranganHilang) error
 		}
 	}
 
+	after, err := r.getSuratByID(tx, surat.ID)
+	if err != nil {
+		return err
+	}
+	after.AlasanUbah = surat.AlasanUbah
+	if err := simpanRevisiSurat(tx, after, actor); err != nil {
+		return err
+	}
+	if err := writeAudit(tx, actor, model.EntitySurat, surat.ID, model.AuditUpdate, before, after); err != nil {
+		return err
+	}
+
 	return tx.Commit()
 }
 
-func (r *SuratRepository) DeleteSurat(id int) error {
-	_, err := r.DB.Exec("DELETE FROM surat WHERE id = ?", id)
-	return err
+// simpanRevisiSurat mencatat isi surat saat ini sebagai revisi bernomor surat.Revisi
+func simpanRevisiSurat(tx *sql.Tx, surat *model.SuratKeteranganHilang, actor string) error {
+	rev := model.RevisiDariSurat(surat)
+	res, err := tx.Exec(`
+		INSERT INTO surat_revisi (surat_id, revisi, pelapor_nik, pelapor_nama, pelapor_ttl, pelapor_agama, pelapor_kelamin,
+			pelapor_pekerjaan, pelapor_alamat, lokasi_hilang, alasan, dibuat_oleh, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		rev.SuratID, rev.Revisi, rev.PelaporNIK, rev.PelaporNama, rev.PelaporTTL, rev.PelaporAgama, rev.PelaporKelamin,
+		rev.PelaporPekerjaan, rev.PelaporAlamat, rev.LokasiHilang, rev.Alasan, actor, time.Now(),
+	)
+	if err != nil {
+		return fmt.Errorf("gagal menyimpan revisi surat: %w", err)
+	}
+	revisiID, _ := res.LastInsertId()
+	for _, barang := range rev.BarangHilang {
+		_, err = tx.Exec("INSERT INTO surat_revisi_barang (revisi_id, jenis_barang, data) VALUES (?, ?, ?)", revisiID, barang.JenisBarang, barang.Data)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func (r *SuratRepository) GetSuratByID(id int) (*model.SuratKeteranganHilang, error) {
-	s := &model.SuratKeteranganHilang{}
-	querySurat := `SELECT id, nomor_surat, tanggal_surat, pelapor_nama, pelapor_ttl, pelapor_agama, pelapor_kelamin, pelapor_pekerjaan, pelapor_alamat, lokasi_hilang FROM surat WHERE id = ?`
+// GetRevisiSurat mengambil seluruh revisi isi surat, dimulai dari revisi 1
+func (r *SuratRepository) GetRevisiSurat(suratID int) ([]model.RevisiSurat, error) {
+	rows, err := r.DB.Query(`
+		SELECT id, surat_id, revisi, pelapor_nik, pelapor_nama, pelapor_ttl, pelapor_agama, pelapor_kelamin,
+			pelapor_pekerjaan, pelapor_alamat, lokasi_hilang, alasan, dibuat_oleh, created_at
+		FROM surat_revisi WHERE surat_id = ? ORDER BY revisi`, suratID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-	err := r.DB.QueryRow(querySurat, id).Scan(
-		&s.ID, &s.NomorSurat, &s.TanggalSurat, &s.PelaporNama, &s.PelaporTTL, &s.PelaporAgama,
-		&s.PelaporKelamin, &s.PelaporPekerjaan, &s.PelaporAlamat, &s.LokasiHilang,
+	var daftar []model.RevisiSurat
+	for rows.Next() {
+		var rev model.RevisiSurat
+		var dibuat sql.NullTime
+		if err := rows.Scan(&rev.ID, &rev.SuratID, &rev.Revisi, &rev.PelaporNIK, &rev.PelaporNama, &rev.PelaporTTL,
+			&rev.PelaporAgama, &rev.PelaporKelamin, &rev.PelaporPekerjaan, &rev.PelaporAlamat, &rev.LokasiHilang,
+			&rev.Alasan, &rev.DibuatOleh, &dibuat); err != nil {
+			return nil, err
+		}
+		rev.CreatedAt = dibuat.Time
+		daftar = append(daftar, rev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range daftar {
+		barangRows, err := r.DB.Query("SELECT id, jenis_barang, data FROM surat_revisi_barang WHERE revisi_id = ? ORDER BY id", daftar[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		for barangRows.Next() {
+			var b model.Barang
+			if err := barangRows.Scan(&b.ID, &b.JenisBarang, &b.Data); err != nil {
+				barangRows.Close()
+				return nil, err
+			}
+			daftar[i].BarangHilang = append(daftar[i].BarangHilang, b)
+		}
+		barangRows.Close()
+	}
+	return daftar, nil
+}
+
+// CancelSurat menandai surat sebagai dibatalkan. Data surat dan nomornya tetap disimpan.
+func (r *SuratRepository) CancelSurat(id int, alasan, oleh string, waktu time.Time, actor string) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := r.getSuratByID(tx, id)
+	if err != nil {
+		return err
+	}
+	res, err := tx.Exec(`
+		UPDATE surat SET status = ?, alasan_batal = ?, dibatalkan_oleh = ?, dibatalkan_pada = ?
+		WHERE id = ? AND status = ?`,
+		model.StatusDibatalkan, alasan, oleh, waktu, id, model.StatusAktif,
 	)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("surat sudah dibatalkan sebelumnya")
+	}
+	after, err := r.getSuratByID(tx, id)
+	if err != nil {
+		return err
+	}
+	if err := writeAudit(tx, actor, model.EntitySurat, id, model.AuditCancel, before, after); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *SuratRepository) GetSuratByID(id int) (*model.SuratKeteranganHilang, error) {
+	return r.getSuratByID(r.DB, id)
+}
+
+func (r *SuratRepository) getSuratByID(q queryer, id int) (*model.SuratKeteranganHilang, error) {
+	s, err := scanSurat(q.QueryRow(selectSurat+` WHERE s.id = ?`, id), r.awalHariIni())
 	if err != nil {
 		return nil, err
 	}
 
 	queryBarang := `SELECT id, jenis_barang, data FROM barang WHERE surat_id = ?`
-	rows, err := r.DB.Query(queryBarang, id)
+	rows, err := q.Query(queryBarang, id)
 	if err != nil {
 		return nil, err
 	}
@@ -235,56 +571,184 @@ func (r *SuratRepository) GetSuratByID(id int) (*model.SuratKeteranganHilang, er
 		}
 		s.BarangHilang = append(s.BarangHilang, b)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
 	return s, nil
 }
 
-func (r *SuratRepository) GetAllSurat(searchTerm string) ([]model.SuratKeteranganHilang, error) {
-	var surats []model.SuratKeteranganHilang
-	query := `SELECT id, nomor_surat, tanggal_surat, pelapor_nama FROM surat`
-	args := []interface{}{}
-	if searchTerm != "" {
-		query += " WHERE pelapor_nama LIKE ? OR nomor_surat LIKE ?"
-		likeTerm := "%" + searchTerm + "%"
-		args = append(args, likeTerm, likeTerm)
+// batasIDPerQuery membatasi jumlah ID dalam satu klausa IN agar jauh di bawah batas parameter SQLite
+const batasIDPerQuery = 500
+
+// GetSuratByIDs mengambil surat lengkap dengan barangnya untuk banyak ID sekaligus, dengan urutan
+// yang sama dengan ids. Setiap kelompok batasIDPerQuery ID dibaca dengan dua query (surat dan
+// barang), bukan dua query per surat. ID yang tidak ada dilewati.
+func (r *SuratRepository) GetSuratByIDs(ids []int) ([]model.SuratKeteranganHilang, error) {
+	hariIni := r.awalHariIni()
+	byID := make(map[int]*model.SuratKeteranganHilang, len(ids))
+	for awal := 0; awal < len(ids); awal += batasIDPerQuery {
+		kelompok := ids[awal:min(awal+batasIDPerQuery, len(ids))]
+		tanda := strings.TrimSuffix(strings.Repeat("?,", len(kelompok)), ",")
+		args := make([]interface{}, len(kelompok))
+		for i, id := range kelompok {
+			args[i] = id
+		}
+
+		rows, err := r.DB.Query(selectSurat+` WHERE s.id IN (`+tanda+`)`, args...)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			s, err := scanSurat(rows, hariIni)
+			if err != nil {
+				rows.Close()
+				return nil, err
+			}
+			// Surat dengan lebih dari satu perpanjangan aktif muncul lebih dari sekali
+			if _, ada := byID[s.ID]; !ada {
+				byID[s.ID] = s
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+
+		rows, err = r.DB.Query(`SELECT id, surat_id, jenis_barang, data FROM barang WHERE surat_id IN (`+tanda+`) ORDER BY id`, args...)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var b model.Barang
+			var suratID int
+			if err := rows.Scan(&b.ID, &suratID, &b.JenisBarang, &b.Data); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			if s, ok := byID[suratID]; ok {
+				s.BarangHilang = append(s.BarangHilang, b)
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
 	}
-	query += " ORDER BY id DESC"
-	rows, err := r.DB.Query(query, args...)
+
+	daftar := make([]model.SuratKeteranganHilang, 0, len(ids))
+	for _, id := range ids {
+		if s, ok := byID[id]; ok {
+			daftar = append(daftar, *s)
+		}
+	}
+	return daftar, nil
+}
+
+// selectSurat membaca satu surat lengkap tanpa barangnya; dipakai bersama scanSurat
+const selectSurat = `
+		SELECT s.id, s.nomor_surat, s.tanggal_surat, s.pelapor_nama, s.pelapor_ttl, s.pelapor_agama, s.pelapor_kelamin, s.pelapor_pekerjaan, s.pelapor_alamat, s.lokasi_hilang,
+			s.status, s.alasan_batal, s.dibatalkan_oleh, s.dibatalkan_pada, s.berlaku_sampai, s.surat_asal_id, asal.nomor_surat,
+			lanjut.id, lanjut.nomor_surat, s.penerima_id, s.pelapor_id, pel.nik, s.tanda_berulang,
+			s.pejabat_id, s.pejabat_nama, s.pejabat_pangkat, s.pejabat_nrp, s.pejabat_jabatan,
+			s.penerima_nama, s.penerima_pangkat, s.penerima_nrp, s.penerima_jabatan, s.pengaturan_revisi_id, s.kantor_id, s.revisi,
+			s.kode_verifikasi
+		FROM surat s
+		LEFT JOIN pelapor AS pel ON pel.id = s.pelapor_id
+		LEFT JOIN surat AS asal ON asal.id = s.surat_asal_id
+		LEFT JOIN surat AS lanjut ON lanjut.surat_asal_id = s.id AND lanjut.status = 'aktif'`
+
+// scanSurat membaca satu baris hasil selectSurat. hariIni dipakai untuk menandai surat kedaluwarsa.
+func scanSurat(row rowScanner, hariIni time.Time) (*model.SuratKeteranganHilang, error) {
+	s := &model.SuratKeteranganHilang{}
+	var alasan, oleh, asalNomor, lanjutNomor, nik sql.NullString
+	var pada, berlaku sql.NullTime
+	var asalID, lanjutID, penerimaID, pelaporID, pejabatID, revisiID, kantorID sql.NullInt64
+	err := row.Scan(
+		&s.ID, &s.NomorSurat, &s.TanggalSurat, &s.PelaporNama, &s.PelaporTTL, &s.PelaporAgama,
+		&s.PelaporKelamin, &s.PelaporPekerjaan, &s.PelaporAlamat, &s.LokasiHilang,
+		&s.Status, &alasan, &oleh, &pada, &berlaku, &asalID, &asalNomor,
+		&lanjutID, &lanjutNomor, &penerimaID, &pelaporID, &nik, &s.TandaBerulang,
+		&pejabatID, &s.Pejabat.Nama, &s.Pejabat.Pangkat, &s.Pejabat.NRP, &s.Pejabat.Jabatan,
+		&s.Penerima.Nama, &s.Penerima.Pangkat, &s.Penerima.NRP, &s.Penerima.Jabatan, &revisiID, &kantorID, &s.Revisi,
+		&s.KodeVerifikasi,
+	)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-	for rows.Next() {
-		var s model.SuratKeteranganHilang
-		if err := rows.Scan(&s.ID, &s.NomorSurat, &s.TanggalSurat, &s.PelaporNama); err != nil {
-			return nil, err
-		}
-		surats = append(surats, s)
+	s.AlasanBatal = alasan.String
+	s.DibatalkanOleh = oleh.String
+	s.DibatalkanPada = pada.Time
+	s.BerlakuSampai = berlaku.Time
+	s.SuratAsalID = int(asalID.Int64)
+	s.SuratAsalNomor = asalNomor.String
+	s.PerpanjanganID = int(lanjutID.Int64)
+	s.PerpanjanganNomor = lanjutNomor.String
+	s.PenerimaID = int(penerimaID.Int64)
+	s.PejabatID = int(pejabatID.Int64)
+	s.RevisiID = int(revisiID.Int64)
+	s.KantorID = int(kantorID.Int64)
+	s.Pejabat.ID = s.PejabatID
+	s.Penerima.ID = s.PenerimaID
+	s.PelaporID = int(pelaporID.Int64)
+	s.PelaporNIK = nik.String
+	s.Kedaluwarsa = isKedaluwarsa(s, hariIni)
+	return s, nil
+}
+
+// saringKantor mengembalikan syarat tambahan " AND <kolom> = ?" untuk statistik satu kantor;
+// kantorID 0 (semua kantor) tidak menambah syarat apa pun
+func saringKantor(kolom string, kantorID int, args []interface{}) (string, []interface{}) {
+	if kantorID == 0 {
+		return "", args
 	}
-	return surats, nil
+	return " AND " + kolom + " = ?", append(args, kantorID)
 }
 
-func (r *SuratRepository) GetTotalSurat() (int, error) {
+// Fungsi statistik di bawah menerima kantorID; 0 berarti gabungan semua kantor.
+
+func (r *SuratRepository) GetTotalSurat(kantorID int) (int, error) {
 	var count int
-	err := r.DB.QueryRow("SELECT COUNT(id) FROM surat").Scan(&count)
+	syarat, args := saringKantor("kantor_id", kantorID, nil)
+	err := r.DB.QueryRow("SELECT COUNT(id) FROM surat WHERE 1=1"+syarat, args...).Scan(&count)
 	return count, err
 }
 
-func (r *SuratRepository) GetTotalSuratBulanIni() (int, error) {
+func (r *SuratRepository) GetTotalSuratBulanIni(kantorID int) (int, error) {
 	var count int
-	loc, _ := time.LoadLocation("Asia/Makassar")
-	now := time.Now().In(loc)
-	firstDayOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc)
+	now := time.Now().In(r.Lokasi)
+	firstDayOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, r.Lokasi)
 	firstDayOfNextMonth := firstDayOfMonth.AddDate(0, 1, 0)
-	query := "SELECT COUNT(id) FROM surat WHERE tanggal_surat >= ? AND tanggal_surat < ?"
-	err := r.DB.QueryRow(query, firstDayOfMonth, firstDayOfNextMonth).Scan(&count)
+	syarat, args := saringKantor("kantor_id", kantorID, []interface{}{firstDayOfMonth, firstDayOfNextMonth})
+	query := "SELECT COUNT(id) FROM surat WHERE tanggal_surat >= ? AND tanggal_surat < ?" + syarat
+	err := r.DB.QueryRow(query, args...).Scan(&count)
 	return count, err
 }
 
-func (r *SuratRepository) GetBarangHilangStats() ([]model.BarangStat, error) {
+// GetTotalSuratBerlaku menghitung surat aktif yang masa berlakunya belum habis
+func (r *SuratRepository) GetTotalSuratBerlaku(kantorID int) (int, error) {
+	var count int
+	syarat, args := saringKantor("kantor_id", kantorID, []interface{}{model.StatusAktif, r.awalHariIni()})
+	query := "SELECT COUNT(id) FROM surat WHERE status = ? AND berlaku_sampai >= ?" + syarat
+	err := r.DB.QueryRow(query, args...).Scan(&count)
+	return count, err
+}
+
+// GetTotalSuratKedaluwarsa menghitung surat aktif yang masa berlakunya sudah habis
+func (r *SuratRepository) GetTotalSuratKedaluwarsa(kantorID int) (int, error) {
+	var count int
+	syarat, args := saringKantor("kantor_id", kantorID, []interface{}{model.StatusAktif, r.awalHariIni()})
+	query := "SELECT COUNT(id) FROM surat WHERE status = ? AND berlaku_sampai < ?" + syarat
+	err := r.DB.QueryRow(query, args...).Scan(&count)
+	return count, err
+}
+
+func (r *SuratRepository) GetBarangHilangStats(kantorID int) ([]model.BarangStat, error) {
 	var stats []model.BarangStat
-	query := `SELECT jenis_barang, COUNT(*) as total FROM barang GROUP BY jenis_barang ORDER BY total DESC`
-	rows, err := r.DB.Query(query)
+	syarat, args := saringKantor("s.kantor_id", kantorID, nil)
+	query := `SELECT b.jenis_barang, COUNT(*) as total FROM barang b JOIN surat s ON s.id = b.surat_id
+		WHERE 1=1` + syarat + ` GROUP BY b.jenis_barang ORDER BY total DESC`
+	rows, err := r.DB.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -301,16 +765,17 @@ func (r *SuratRepository) GetBarangHilangStats() ([]model.BarangStat, error) {
 }
 
 // Ganti fungsi lama dengan yang ini
-func (r *SuratRepository) GetSuratHarianStats() (map[string]int, error) {
+func (r *SuratRepository) GetSuratHarianStats(kantorID int) (map[string]int, error) {
 	stats := make(map[string]int)
-	// PERBAIKAN: Tambahkan modifier timezone di SELECT strftime
+	syarat, args := saringKantor("kantor_id", kantorID, []interface{}{r.awalHariIni().AddDate(0, 0, -6)})
+	// tanggal_surat disimpan dengan waktu lokal kantor, jadi 10 karakter pertamanya adalah tanggal lokal
 	query := `
-		SELECT strftime('%Y-%m-%d', tanggal_surat, 'localtime', '+8 hours') as tanggal, COUNT(id) as total 
+		SELECT substr(tanggal_surat, 1, 10) as tanggal, COUNT(id) as total 
 		FROM surat 
-		WHERE tanggal_surat >= date('now', '-6 days', 'localtime', '+8 hours') 
+		WHERE tanggal_surat >= ?` + syarat + `
 		GROUP BY tanggal
 	`
-	rows, err := r.DB.Query(query)
+	rows, err := r.DB.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -327,33 +792,85 @@ func (r *SuratRepository) GetSuratHarianStats() (map[string]int, error) {
 	return stats, nil
 }
 
-
 // --- FUNGSI MANAJEMEN PETUGAS ---
 
-func (r *SuratRepository) CreatePetugas(p *model.Petugas) error {
-	query := `INSERT INTO petugas (nama, pangkat, nrp, jabatan, tipe) VALUES (?, ?, ?, ?, ?)`
-	_, err := r.DB.Exec(query, p.Nama, p.Pangkat, p.NRP, p.Jabatan, p.Tipe)
-	return err
+func (r *SuratRepository) CreatePetugas(p *model.Petugas, actor string) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO petugas (nama, pangkat, nrp, jabatan, tipe, kantor_id) VALUES (?, ?, ?, ?, ?, ?)`
+	res, err := tx.Exec(query, p.Nama, p.Pangkat, p.NRP, p.Jabatan, p.Tipe, nullInt(p.KantorID))
+	if err != nil {
+		return err
+	}
+	id, _ := res.LastInsertId()
+	p.ID = int(id)
+
+	after, err := getPetugasByID(tx, p.ID)
+	if err != nil {
+		return err
+	}
+	if err := writeAudit(tx, actor, model.EntityPetugas, p.ID, model.AuditCreate, nil, after); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (r *SuratRepository) GetPetugasByID(id int) (*model.Petugas, error) {
+	return getPetugasByID(r.DB, id)
+}
+
+const kolomPetugas = `id, nama, pangkat, nrp, jabatan, tipe, kantor_id`
+
+func scanPetugas(row interface{ Scan(...interface{}) error }, p *model.Petugas) error {
+	var kantorID sql.NullInt64
+	if err := row.Scan(&p.ID, &p.Nama, &p.Pangkat, &p.NRP, &p.Jabatan, &p.Tipe, &kantorID); err != nil {
+		return err
+	}
+	p.KantorID = int(kantorID.Int64)
+	return nil
+}
+
+func getPetugasByID(q queryer, id int) (*model.Petugas, error) {
 	p := &model.Petugas{}
-	query := `SELECT id, nama, pangkat, nrp, jabatan, tipe FROM petugas WHERE id = ?`
-	err := r.DB.QueryRow(query, id).Scan(&p.ID, &p.Nama, &p.Pangkat, &p.NRP, &p.Jabatan, &p.Tipe)
+	err := scanPetugas(q.QueryRow(`SELECT `+kolomPetugas+` FROM petugas WHERE id = ?`, id), p)
 	return p, err
 }
 
-func (r *SuratRepository) GetAllPetugas() ([]model.Petugas, error) {
+// GetAllPetugas mengambil petugas yang dapat bertugas di kantor kantorID, termasuk petugas semua
+// kantor. kantorID 0 mengambil seluruh petugas.
+func (r *SuratRepository) GetAllPetugas(kantorID int) ([]model.Petugas, error) {
+	return r.daftarPetugas("", kantorID)
+}
+
+// GetPetugasByTipe seperti GetAllPetugas tetapi hanya petugas dengan tipe tersebut
+func (r *SuratRepository) GetPetugasByTipe(tipe string, kantorID int) ([]model.Petugas, error) {
+	return r.daftarPetugas(tipe, kantorID)
+}
+
+func (r *SuratRepository) daftarPetugas(tipe string, kantorID int) ([]model.Petugas, error) {
 	var allPetugas []model.Petugas
-	query := `SELECT id, nama, pangkat, nrp, jabatan, tipe FROM petugas ORDER BY nama ASC`
-	rows, err := r.DB.Query(query)
+	query := `SELECT ` + kolomPetugas + ` FROM petugas WHERE 1=1`
+	var args []interface{}
+	if tipe != "" {
+		query += ` AND tipe = ?`
+		args = append(args, tipe)
+	}
+	if kantorID != 0 {
+		query += ` AND (kantor_id = ? OR kantor_id IS NULL)`
+		args = append(args, kantorID)
+	}
+	rows, err := r.DB.Query(query+` ORDER BY nama ASC`, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 	for rows.Next() {
 		var p model.Petugas
-		if err := rows.Scan(&p.ID, &p.Nama, &p.Pangkat, &p.NRP, &p.Jabatan, &p.Tipe); err != nil {
+		if err := scanPetugas(rows, &p); err != nil {
 			return nil, err
 		}
 		allPetugas = append(allPetugas, p)
@@ -361,31 +878,47 @@ func (r *SuratRepository) GetAllPetugas() ([]model.Petugas, error) {
 	return allPetugas, nil
 }
 
-func (r *SuratRepository) GetPetugasByTipe(tipe string) ([]model.Petugas, error) {
-	var allPetugas []model.Petugas
-	query := `SELECT id, nama, pangkat, nrp, jabatan, tipe FROM petugas WHERE tipe = ? ORDER BY nama ASC`
-	rows, err := r.DB.Query(query, tipe)
+func (r *SuratRepository) UpdatePetugas(p *model.Petugas, actor string) error {
+	tx, err := r.DB.Begin()
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer rows.Close()
-	for rows.Next() {
-		var p model.Petugas
-		if err := rows.Scan(&p.ID, &p.Nama, &p.Pangkat, &p.NRP, &p.Jabatan, &p.Tipe); err != nil {
-			return nil, err
-		}
-		allPetugas = append(allPetugas, p)
+	defer tx.Rollback()
+
+	before, err := getPetugasByID(tx, p.ID)
+	if err != nil {
+		return err
 	}
-	return allPetugas, nil
+	query := `UPDATE petugas SET nama = ?, pangkat = ?, nrp = ?, jabatan = ?, tipe = ?, kantor_id = ? WHERE id = ?`
+	if _, err := tx.Exec(query, p.Nama, p.Pangkat, p.NRP, p.Jabatan, p.Tipe, nullInt(p.KantorID), p.ID); err != nil {
+		return err
+	}
+	after, err := getPetugasByID(tx, p.ID)
+	if err != nil {
+		return err
+	}
+	if err := writeAudit(tx, actor, model.EntityPetugas, p.ID, model.AuditUpdate, before, after); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-func (r *SuratRepository) UpdatePetugas(p *model.Petugas) error {
-	query := `UPDATE petugas SET nama = ?, pangkat = ?, nrp = ?, jabatan = ?, tipe = ? WHERE id = ?`
-	_, err := r.DB.Exec(query, p.Nama, p.Pangkat, p.NRP, p.Jabatan, p.Tipe, p.ID)
-	return err
-}
+func (r *SuratRepository) DeletePetugas(id int, actor string) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
 
-func (r *SuratRepository) DeletePetugas(id int) error {
-	_, err := r.DB.Exec("DELETE FROM petugas WHERE id = ?", id)
-	return err
+	before, err := getPetugasByID(tx, id)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM petugas WHERE id = ?", id); err != nil {
+		return err
+	}
+	if err := writeAudit(tx, actor, model.EntityPetugas, id, model.AuditDelete, before, nil); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
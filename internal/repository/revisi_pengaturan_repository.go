@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"skh_app/internal/model"
+	"time"
+)
+
+// --- FUNGSI REVISI PENGATURAN ---
+
+const kolomRevisi = `r.id, COALESCE(r.kantor_id, 0), r.kop_surat_1, r.kop_surat_2, r.kop_surat_3, r.logo_path, r.wilayah, r.nama_kantor,
+	r.dibuat_oleh, r.created_at`
+
+func scanRevisi(row interface{ Scan(...interface{}) error }, tambahan ...interface{}) (*model.RevisiPengaturan, error) {
+	rev := &model.RevisiPengaturan{}
+	var dibuat sql.NullTime
+	dest := append([]interface{}{&rev.ID, &rev.KantorID, &rev.KopSurat1, &rev.KopSurat2, &rev.KopSurat3, &rev.LogoPath,
+		&rev.Wilayah, &rev.NamaKantor, &rev.DibuatOleh, &dibuat}, tambahan...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	rev.CreatedAt = dibuat.Time
+	return rev, nil
+}
+
+// simpanRevisiPengaturan mencatat kop surat dan identitas kantor p sebagai revisi baru jika berbeda
+// dari revisi terakhir kantor itu. Surat kantor yang dibuat sesudahnya menunjuk ke revisi ini.
+func simpanRevisiPengaturan(tx *sql.Tx, p *model.Pengaturan, actor string) error {
+	terakhir, err := scanRevisi(tx.QueryRow(`SELECT `+kolomRevisi+` FROM pengaturan_revisi r
+		WHERE r.kantor_id = ? ORDER BY r.id DESC LIMIT 1`, p.ID))
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if terakhir != nil && terakhir.SamaDengan(p) {
+		return nil
+	}
+	_, err = tx.Exec(`
+		INSERT INTO pengaturan_revisi (kantor_id, kop_surat_1, kop_surat_2, kop_surat_3, logo_path, wilayah, nama_kantor, dibuat_oleh, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		p.ID, p.KopSurat1, p.KopSurat2, p.KopSurat3, p.LogoPath, p.Wilayah, p.NamaKantor, actor, time.Now().UTC(),
+	)
+	return err
+}
+
+// GetRevisiPengaturan mengambil satu revisi kop surat
+func (r *SuratRepository) GetRevisiPengaturan(id int) (*model.RevisiPengaturan, error) {
+	return scanRevisi(r.DB.QueryRow(`SELECT `+kolomRevisi+` FROM pengaturan_revisi r WHERE r.id = ?`, id))
+}
+
+// GetRiwayatPengaturan mengambil semua revisi kop surat satu kantor beserta jumlah suratnya,
+// yang terbaru lebih dulu
+func (r *SuratRepository) GetRiwayatPengaturan(kantorID int) ([]model.RevisiPengaturan, error) {
+	rows, err := r.DB.Query(`
+		SELECT `+kolomRevisi+`, (SELECT COUNT(*) FROM surat s WHERE s.pengaturan_revisi_id = r.id)
+		FROM pengaturan_revisi r WHERE r.kantor_id = ? ORDER BY r.id DESC`, kantorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var riwayat []model.RevisiPengaturan
+	for rows.Next() {
+		var jumlah int
+		rev, err := scanRevisi(rows, &jumlah)
+		if err != nil {
+			return nil, err
+		}
+		rev.JumlahSurat = jumlah
+		riwayat = append(riwayat, *rev)
+	}
+	return riwayat, rows.Err()
+}
@@ -0,0 +1,23 @@
+package repository
+
+// --- FUNGSI KUNCI RAHASIA ---
+
+// GetOrCreateSecret mengembalikan kunci rahasia bernama nama. Jika belum ada, nilai dari buat disimpan
+// lebih dulu; INSERT OR IGNORE menjaga agar dua proses yang bersamaan tetap memakai kunci yang sama.
+func (r *SuratRepository) GetOrCreateSecret(nama string, buat func() (string, error)) (string, error) {
+	var nilai string
+	err := r.DB.QueryRow("SELECT nilai FROM app_secret WHERE nama = ?", nama).Scan(&nilai)
+	if err == nil {
+		return nilai, nil
+	}
+
+	baru, err := buat()
+	if err != nil {
+		return "", err
+	}
+	if _, err := r.DB.Exec("INSERT OR IGNORE INTO app_secret (nama, nilai) VALUES (?, ?)", nama, baru); err != nil {
+		return "", err
+	}
+	err = r.DB.QueryRow("SELECT nilai FROM app_secret WHERE nama = ?", nama).Scan(&nilai)
+	return nilai, err
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"database/sql"
+	"skh_app/internal/model"
+	"time"
+)
+
+// --- FUNGSI PENGGUNA ---
+
+func (r *SuratRepository) CountUsers() (int, error) {
+	var count int
+	err := r.DB.QueryRow("SELECT COUNT(id) FROM users").Scan(&count)
+	return count, err
+}
+
+const kolomUser = `u.id, u.username, u.nama_lengkap, u.password_hash, u.role, u.aktif, u.kantor_id, u.created_at, u.wajib_ganti_password`
+
+func scanUser(row interface{ Scan(...interface{}) error }, u *model.User) error {
+	var kantorID sql.NullInt64
+	if err := row.Scan(&u.ID, &u.Username, &u.NamaLengkap, &u.PasswordHash, &u.Role, &u.Aktif, &kantorID, &u.CreatedAt, &u.WajibGantiPassword); err != nil {
+		return err
+	}
+	u.KantorID = int(kantorID.Int64)
+	return nil
+}
+
+func (r *SuratRepository) CreateUser(u *model.User) error {
+	query := `INSERT INTO users (username, nama_lengkap, password_hash, role, aktif, kantor_id, wajib_ganti_password) VALUES (?, ?, ?, ?, ?, ?, ?)`
+	res, err := r.DB.Exec(query, u.Username, u.NamaLengkap, u.PasswordHash, u.Role, u.Aktif, nullInt(u.KantorID), u.WajibGantiPassword)
+	if err != nil {
+		return err
+	}
+	id, _ := res.LastInsertId()
+	u.ID = int(id)
+	return nil
+}
+
+func (r *SuratRepository) GetUserByID(id int) (*model.User, error) {
+	u := &model.User{}
+	err := scanUser(r.DB.QueryRow(`SELECT `+kolomUser+` FROM users u WHERE u.id = ?`, id), u)
+	return u, err
+}
+
+func (r *SuratRepository) GetUserByUsername(username string) (*model.User, error) {
+	u := &model.User{}
+	err := scanUser(r.DB.QueryRow(`SELECT `+kolomUser+` FROM users u WHERE u.username = ?`, username), u)
+	return u, err
+}
+
+func (r *SuratRepository) GetAllUsers() ([]model.User, error) {
+	var users []model.User
+	rows, err := r.DB.Query(`SELECT ` + kolomUser + ` FROM users u ORDER BY u.username ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var u model.User
+		if err := scanUser(rows, &u); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, nil
+}
+
+func (r *SuratRepository) UpdateUser(u *model.User) error {
+	query := `UPDATE users SET username = ?, nama_lengkap = ?, password_hash = ?, role = ?, aktif = ?, kantor_id = ?, wajib_ganti_password = ? WHERE id = ?`
+	_, err := r.DB.Exec(query, u.Username, u.NamaLengkap, u.PasswordHash, u.Role, u.Aktif, nullInt(u.KantorID), u.WajibGantiPassword, u.ID)
+	return err
+}
+
+func (r *SuratRepository) DeleteUser(id int) error {
+	_, err := r.DB.Exec("DELETE FROM users WHERE id = ?", id)
+	return err
+}
+
+// --- FUNGSI SESI LOGIN ---
+
+func (r *SuratRepository) CreateSession(tokenHash string, userID int, expiresAt time.Time) error {
+	// Sekalian bersihkan sesi yang sudah kedaluwarsa
+	if _, err := r.DB.Exec("DELETE FROM sessions WHERE expires_at < ?", time.Now().UTC()); err != nil {
+		return err
+	}
+	_, err := r.DB.Exec("INSERT INTO sessions (token_hash, user_id, expires_at) VALUES (?, ?, ?)", tokenHash, userID, expiresAt)
+	return err
+}
+
+// GetUserBySession mengembalikan user pemilik sesi yang masih berlaku dan akunnya aktif
+func (r *SuratRepository) GetUserBySession(tokenHash string) (*model.User, error) {
+	u := &model.User{}
+	query := `
+		SELECT ` + kolomUser + `
+		FROM sessions s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.token_hash = ? AND s.expires_at > ? AND u.aktif = 1
+	`
+	err := scanUser(r.DB.QueryRow(query, tokenHash, time.Now().UTC()), u)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return u, err
+}
+
+func (r *SuratRepository) DeleteSession(tokenHash string) error {
+	_, err := r.DB.Exec("DELETE FROM sessions WHERE token_hash = ?", tokenHash)
+	return err
+}
+
+func (r *SuratRepository) DeleteSessionsByUser(userID int) error {
+	_, err := r.DB.Exec("DELETE FROM sessions WHERE user_id = ?", userID)
+	return err
+}
@@ -0,0 +1,297 @@
+package service
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"skh_app/internal/model"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SessionDuration adalah lama sesi login berlaku sejak user masuk
+const SessionDuration = 12 * time.Hour
+
+const (
+	passwordIterations = 310000
+	passwordKeyLength  = 32
+	defaultAdminUser   = "admin"
+
+	// passwordAdminLama adalah password admin awal versi lama yang masih tertulis di kode.
+	// Akun yang masih memakainya diwajibkan mengganti password saat login.
+	passwordAdminLama = "admin123"
+)
+
+// ErrInvalidLogin dikembalikan jika username atau password salah
+var ErrInvalidLogin = errors.New("username atau password salah")
+
+// AuthRepositoryInterface mendefinisikan fungsi database untuk akun dan sesi login
+type AuthRepositoryInterface interface {
+	CountUsers() (int, error)
+	CreateUser(*model.User) error
+	GetUserByID(id int) (*model.User, error)
+	GetUserByUsername(username string) (*model.User, error)
+	GetAllUsers() ([]model.User, error)
+	UpdateUser(*model.User) error
+	DeleteUser(id int) error
+	CreateSession(tokenHash string, userID int, expiresAt time.Time) error
+	GetUserBySession(tokenHash string) (*model.User, error)
+	DeleteSession(tokenHash string) error
+	DeleteSessionsByUser(userID int) error
+}
+
+// AuthService menangani login, sesi dan manajemen pengguna
+type AuthService struct {
+	repo AuthRepositoryInterface
+}
+
+// NewAuthService adalah constructor untuk AuthService
+func NewAuthService(repo AuthRepositoryInterface) *AuthService {
+	return &AuthService{repo: repo}
+}
+
+// EnsureDefaultAdmin membuat akun admin awal jika tabel users masih kosong. Password-nya dibuat
+// acak, hanya ditulis sekali ke log, dan wajib diganti saat login pertama.
+func (s *AuthService) EnsureDefaultAdmin() error {
+	count, err := s.repo.CountUsers()
+	if err != nil {
+		return fmt.Errorf("gagal menghitung pengguna: %w", err)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	password, err := randomPassword()
+	if err != nil {
+		return err
+	}
+	hash, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+	admin := &model.User{
+		Username:           defaultAdminUser,
+		NamaLengkap:        "Administrator",
+		PasswordHash:       hash,
+		Role:               model.RoleAdmin,
+		Aktif:              true,
+		WajibGantiPassword: true,
+	}
+	if err := s.repo.CreateUser(admin); err != nil {
+		return fmt.Errorf("gagal membuat admin awal: %w", err)
+	}
+	slog.Warn("Akun admin awal dibuat. Password ini tidak ditampilkan lagi dan wajib diganti saat login pertama.",
+		"username", defaultAdminUser, "password", password)
+	return nil
+}
+
+// Login memeriksa kredensial dan membuat sesi baru. Token yang dikembalikan disimpan di cookie.
+func (s *AuthService) Login(username, password string) (string, *model.User, error) {
+	user, err := s.repo.GetUserByUsername(strings.TrimSpace(username))
+	if err == sql.ErrNoRows {
+		return "", nil, ErrInvalidLogin
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("gagal mengambil data pengguna: %w", err)
+	}
+	if !user.Aktif || !checkPassword(user.PasswordHash, password) {
+		return "", nil, ErrInvalidLogin
+	}
+	if password == passwordAdminLama && !user.WajibGantiPassword {
+		user.WajibGantiPassword = true
+		if err := s.repo.UpdateUser(user); err != nil {
+			return "", nil, fmt.Errorf("gagal menandai pengguna wajib ganti password: %w", err)
+		}
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return "", nil, err
+	}
+	if err := s.repo.CreateSession(hashToken(token), user.ID, time.Now().UTC().Add(SessionDuration)); err != nil {
+		return "", nil, fmt.Errorf("gagal menyimpan sesi: %w", err)
+	}
+	return token, user, nil
+}
+
+// RedirectLokal mengembalikan next bila berupa path di aplikasi ini, selain itu "/". Alamat seperti
+// "//host" atau "/\host" ditolak karena browser membukanya sebagai alamat situs lain.
+func RedirectLokal(next string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return "/"
+	}
+	return next
+}
+
+// Logout menghapus sesi milik token
+func (s *AuthService) Logout(token string) error {
+	return s.repo.DeleteSession(hashToken(token))
+}
+
+// UserFromSession mengembalikan user pemilik token, atau nil jika sesi tidak berlaku
+func (s *AuthService) UserFromSession(token string) (*model.User, error) {
+	if token == "" {
+		return nil, nil
+	}
+	return s.repo.GetUserBySession(hashToken(token))
+}
+
+// GetAllUsers mengambil daftar semua pengguna
+func (s *AuthService) GetAllUsers() ([]model.User, error) {
+	return s.repo.GetAllUsers()
+}
+
+// GetUserByID mengambil satu pengguna
+func (s *AuthService) GetUserByID(id int) (*model.User, error) {
+	return s.repo.GetUserByID(id)
+}
+
+// SaveUser membuat atau memperbarui pengguna. Password kosong saat edit berarti tidak diubah.
+func (s *AuthService) SaveUser(u *model.User, password string) error {
+	u.Username = strings.TrimSpace(u.Username)
+	if u.Username == "" {
+		return fmt.Errorf("username wajib diisi")
+	}
+	if !model.IsValidRole(u.Role) {
+		return fmt.Errorf("peran %q tidak dikenal", u.Role)
+	}
+	if u.ID == 0 && password == "" {
+		return fmt.Errorf("password wajib diisi untuk pengguna baru")
+	}
+	if password != "" {
+		if len(password) < 6 {
+			return fmt.Errorf("password minimal 6 karakter")
+		}
+		hash, err := hashPassword(password)
+		if err != nil {
+			return err
+		}
+		u.PasswordHash = hash
+	}
+
+	if u.ID == 0 {
+		return s.repo.CreateUser(u)
+	}
+	if err := s.repo.UpdateUser(u); err != nil {
+		return err
+	}
+	// Paksa login ulang jika password diganti atau akun dinonaktifkan
+	if password != "" || !u.Aktif {
+		return s.repo.DeleteSessionsByUser(u.ID)
+	}
+	return nil
+}
+
+// GantiPassword mengganti password milik user sendiri setelah password lamanya dicocokkan, lalu
+// mengakhiri semua sesinya dan mengembalikan token sesi baru agar user tetap login.
+func (s *AuthService) GantiPassword(userID int, lama, baru string) (string, error) {
+	user, err := s.repo.GetUserByID(userID)
+	if err != nil {
+		return "", fmt.Errorf("gagal mengambil data pengguna: %w", err)
+	}
+	if !checkPassword(user.PasswordHash, lama) {
+		return "", fmt.Errorf("password lama salah")
+	}
+	if len(baru) < 6 {
+		return "", fmt.Errorf("password minimal 6 karakter")
+	}
+	if baru == lama || baru == passwordAdminLama {
+		return "", fmt.Errorf("password baru tidak boleh sama dengan password lama")
+	}
+	hash, err := hashPassword(baru)
+	if err != nil {
+		return "", err
+	}
+	user.PasswordHash = hash
+	user.WajibGantiPassword = false
+	if err := s.repo.UpdateUser(user); err != nil {
+		return "", fmt.Errorf("gagal menyimpan password: %w", err)
+	}
+	if err := s.repo.DeleteSessionsByUser(user.ID); err != nil {
+		return "", fmt.Errorf("gagal mengakhiri sesi lama: %w", err)
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	if err := s.repo.CreateSession(hashToken(token), user.ID, time.Now().UTC().Add(SessionDuration)); err != nil {
+		return "", fmt.Errorf("gagal menyimpan sesi: %w", err)
+	}
+	return token, nil
+}
+
+// DeleteUser menghapus pengguna beserta semua sesinya
+func (s *AuthService) DeleteUser(id int) error {
+	return s.repo.DeleteUser(id)
+}
+
+// --- Fungsi Helper ---
+
+// hashPassword menghasilkan string "pbkdf2-sha256$iterasi$salt$hash"
+func hashPassword(password string) (string, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, passwordIterations, passwordKeyLength)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("pbkdf2-sha256$%d$%s$%s", passwordIterations,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func checkPassword(encoded, password string) bool {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 4 || parts[0] != "pbkdf2-sha256" {
+		return false
+	}
+	iter, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	expected, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, iter, len(expected))
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(key, expected) == 1
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// randomPassword membuat password acak 16 karakter untuk admin awal
+func randomPassword() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,354 @@
+package service
+
+import (
+	"database/sql"
+	"skh_app/internal/model"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sesiPalsu adalah satu baris tabel sessions
+type sesiPalsu struct {
+	userID    int
+	expiresAt time.Time
+}
+
+// authRepoPalsu menyimpan pengguna dan sesi di memori. Seperti repository asli, sesi yang sudah
+// kedaluwarsa atau milik akun nonaktif tidak menghasilkan user.
+type authRepoPalsu struct {
+	users map[int]*model.User
+	sesi  map[string]sesiPalsu
+}
+
+func newAuthRepoPalsu() *authRepoPalsu {
+	return &authRepoPalsu{users: map[int]*model.User{}, sesi: map[string]sesiPalsu{}}
+}
+
+func (r *authRepoPalsu) CountUsers() (int, error) { return len(r.users), nil }
+
+func (r *authRepoPalsu) CreateUser(u *model.User) error {
+	u.ID = len(r.users) + 1
+	salinan := *u
+	r.users[u.ID] = &salinan
+	return nil
+}
+
+func (r *authRepoPalsu) GetUserByID(id int) (*model.User, error) {
+	u, ok := r.users[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	salinan := *u
+	return &salinan, nil
+}
+
+func (r *authRepoPalsu) GetUserByUsername(username string) (*model.User, error) {
+	for id, u := range r.users {
+		if u.Username == username {
+			return r.GetUserByID(id)
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *authRepoPalsu) GetAllUsers() ([]model.User, error) { return nil, nil }
+
+func (r *authRepoPalsu) UpdateUser(u *model.User) error {
+	salinan := *u
+	r.users[u.ID] = &salinan
+	return nil
+}
+
+func (r *authRepoPalsu) DeleteUser(id int) error {
+	delete(r.users, id)
+	return nil
+}
+
+func (r *authRepoPalsu) CreateSession(tokenHash string, userID int, expiresAt time.Time) error {
+	r.sesi[tokenHash] = sesiPalsu{userID: userID, expiresAt: expiresAt}
+	return nil
+}
+
+func (r *authRepoPalsu) GetUserBySession(tokenHash string) (*model.User, error) {
+	s, ok := r.sesi[tokenHash]
+	if !ok || !s.expiresAt.After(time.Now().UTC()) {
+		return nil, nil
+	}
+	u, err := r.GetUserByID(s.userID)
+	if err != nil || !u.Aktif {
+		return nil, nil
+	}
+	return u, nil
+}
+
+func (r *authRepoPalsu) DeleteSession(tokenHash string) error {
+	delete(r.sesi, tokenHash)
+	return nil
+}
+
+func (r *authRepoPalsu) DeleteSessionsByUser(userID int) error {
+	for hash, s := range r.sesi {
+		if s.userID == userID {
+			delete(r.sesi, hash)
+		}
+	}
+	return nil
+}
+
+// tambahUser menyimpan user aktif dengan password yang sudah di-hash
+func (r *authRepoPalsu) tambahUser(t *testing.T, username, password, role string) *model.User {
+	t.Helper()
+	hash, err := hashPassword(password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u := &model.User{Username: username, PasswordHash: hash, Role: role, Aktif: true}
+	if err := r.CreateUser(u); err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func TestHashPassword(t *testing.T) {
+	hash, err := hashPassword("rahasia99")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bagian := strings.Split(hash, "$")
+	if len(bagian) != 4 || bagian[0] != "pbkdf2-sha256" || bagian[1] != "310000" {
+		t.Fatalf("format hash tidak sesuai: %q", hash)
+	}
+	if strings.Contains(hash, "rahasia99") {
+		t.Fatalf("hash memuat password asli: %q", hash)
+	}
+	if lain, _ := hashPassword("rahasia99"); lain == hash {
+		t.Error("dua hash untuk password yang sama seharusnya berbeda salt-nya")
+	}
+
+	tests := []struct {
+		nama     string
+		encoded  string
+		password string
+		want     bool
+	}{
+		{"password benar", hash, "rahasia99", true},
+		{"password salah", hash, "rahasia98", false},
+		{"password kosong", hash, "", false},
+		{"beda huruf besar", hash, "Rahasia99", false},
+		{"hash kosong", "", "rahasia99", false},
+		{"algoritma lain", "bcrypt$" + strings.Join(bagian[1:], "$"), "rahasia99", false},
+		{"iterasi bukan angka", strings.Join([]string{bagian[0], "x", bagian[2], bagian[3]}, "$"), "rahasia99", false},
+		{"salt bukan base64", strings.Join([]string{bagian[0], bagian[1], "!!!", bagian[3]}, "$"), "rahasia99", false},
+		{"bagian kurang", strings.Join(bagian[:3], "$"), "rahasia99", false},
+		{"password polos", "rahasia99", "rahasia99", false},
+	}
+	for _, tt := range tests {
+		if got := checkPassword(tt.encoded, tt.password); got != tt.want {
+			t.Errorf("%s: checkPassword = %v, seharusnya %v", tt.nama, got, tt.want)
+		}
+	}
+}
+
+func TestLoginDanSesi(t *testing.T) {
+	repo := newAuthRepoPalsu()
+	operator := repo.tambahUser(t, "op1", "rahasia99", model.RoleOperator)
+	svc := NewAuthService(repo)
+
+	if _, _, err := svc.Login("op1", "salah"); err != ErrInvalidLogin {
+		t.Errorf("password salah: error = %v, seharusnya ErrInvalidLogin", err)
+	}
+	if _, _, err := svc.Login("tidakada", "rahasia99"); err != ErrInvalidLogin {
+		t.Errorf("username tidak ada: error = %v, seharusnya ErrInvalidLogin", err)
+	}
+
+	awal := time.Now().UTC()
+	token, user, err := svc.Login("  op1 ", "rahasia99")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.ID != operator.ID {
+		t.Fatalf("Login mengembalikan user %d, seharusnya %d", user.ID, operator.ID)
+	}
+	if len(repo.sesi) != 1 {
+		t.Fatalf("jumlah sesi = %d, seharusnya 1", len(repo.sesi))
+	}
+	s, ok := repo.sesi[hashToken(token)]
+	if !ok {
+		t.Fatal("sesi tidak disimpan dengan hash token")
+	}
+	if _, ada := repo.sesi[token]; ada {
+		t.Error("token disimpan tanpa di-hash")
+	}
+	if s.expiresAt.Before(awal.Add(SessionDuration)) || s.expiresAt.After(time.Now().UTC().Add(SessionDuration)) {
+		t.Errorf("sesi berakhir %v, seharusnya %v setelah login", s.expiresAt, SessionDuration)
+	}
+
+	if u, err := svc.UserFromSession(token); err != nil || u == nil || u.ID != operator.ID {
+		t.Fatalf("UserFromSession = %+v, %v", u, err)
+	}
+	if u, _ := svc.UserFromSession(""); u != nil {
+		t.Error("token kosong menghasilkan user")
+	}
+	if u, _ := svc.UserFromSession(token + "x"); u != nil {
+		t.Error("token lain menghasilkan user")
+	}
+
+	// Sesi yang sudah lewat masanya tidak berlaku lagi
+	s.expiresAt = time.Now().UTC().Add(-time.Second)
+	repo.sesi[hashToken(token)] = s
+	if u, _ := svc.UserFromSession(token); u != nil {
+		t.Error("sesi kedaluwarsa masih menghasilkan user")
+	}
+
+	token, _, err = svc.Login("op1", "rahasia99")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Logout(token); err != nil {
+		t.Fatal(err)
+	}
+	if u, _ := svc.UserFromSession(token); u != nil {
+		t.Error("sesi masih berlaku setelah logout")
+	}
+
+	// Akun nonaktif tidak bisa login
+	repo.users[operator.ID].Aktif = false
+	if _, _, err := svc.Login("op1", "rahasia99"); err != ErrInvalidLogin {
+		t.Errorf("akun nonaktif: error = %v, seharusnya ErrInvalidLogin", err)
+	}
+}
+
+func TestEnsureDefaultAdmin(t *testing.T) {
+	repo := newAuthRepoPalsu()
+	svc := NewAuthService(repo)
+	if err := svc.EnsureDefaultAdmin(); err != nil {
+		t.Fatal(err)
+	}
+	if len(repo.users) != 1 {
+		t.Fatalf("jumlah pengguna = %d, seharusnya 1", len(repo.users))
+	}
+	admin := repo.users[1]
+	if admin.Username != defaultAdminUser || admin.Role != model.RoleAdmin || !admin.WajibGantiPassword {
+		t.Errorf("admin awal tidak sesuai: %+v", admin)
+	}
+	if checkPassword(admin.PasswordHash, passwordAdminLama) {
+		t.Error("admin awal masih memakai password bawaan lama")
+	}
+
+	// Tidak ada admin kedua bila pengguna sudah ada
+	if err := svc.EnsureDefaultAdmin(); err != nil {
+		t.Fatal(err)
+	}
+	if len(repo.users) != 1 {
+		t.Errorf("jumlah pengguna = %d setelah EnsureDefaultAdmin kedua, seharusnya 1", len(repo.users))
+	}
+}
+
+func TestGantiPassword(t *testing.T) {
+	repo := newAuthRepoPalsu()
+	admin := repo.tambahUser(t, "admin", passwordAdminLama, model.RoleAdmin)
+	svc := NewAuthService(repo)
+
+	// Login dengan password bawaan lama menandai akun wajib ganti password
+	tokenLama, user, err := svc.Login("admin", passwordAdminLama)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !user.WajibGantiPassword || !repo.users[admin.ID].WajibGantiPassword {
+		t.Fatal("login dengan password bawaan lama tidak mewajibkan ganti password")
+	}
+
+	gagal := map[string][2]string{
+		"password lama salah":     {"salah", "rahasia99"},
+		"password terlalu pendek": {passwordAdminLama, "abc"},
+		"password sama":           {passwordAdminLama, passwordAdminLama},
+	}
+	for nama, pw := range gagal {
+		if _, err := svc.GantiPassword(admin.ID, pw[0], pw[1]); err == nil {
+			t.Errorf("%s: GantiPassword seharusnya gagal", nama)
+		}
+	}
+	if !repo.users[admin.ID].WajibGantiPassword {
+		t.Fatal("GantiPassword yang gagal menghapus tanda wajib ganti password")
+	}
+
+	token, err := svc.GantiPassword(admin.ID, passwordAdminLama, "rahasia99")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo.users[admin.ID].WajibGantiPassword {
+		t.Error("tanda wajib ganti password tidak dihapus")
+	}
+	if u, _ := svc.UserFromSession(tokenLama); u != nil {
+		t.Error("sesi lama masih berlaku setelah password diganti")
+	}
+	if u, _ := svc.UserFromSession(token); u == nil || u.ID != admin.ID {
+		t.Errorf("sesi baru tidak berlaku: %+v", u)
+	}
+	if _, _, err := svc.Login("admin", passwordAdminLama); err != ErrInvalidLogin {
+		t.Errorf("password lama masih bisa dipakai login: %v", err)
+	}
+	if _, _, err := svc.Login("admin", "rahasia99"); err != nil {
+		t.Errorf("password baru ditolak: %v", err)
+	}
+}
+
+func TestRedirectLokal(t *testing.T) {
+	tests := map[string]string{
+		"":                        "/",
+		"/":                       "/",
+		"/surat":                  "/surat",
+		"/surat/detail/7?tab=1":   "/surat/detail/7?tab=1",
+		"surat":                   "/",
+		"//evil.example":          "/",
+		"/\\evil.example":         "/",
+		"https://evil.example/":   "/",
+		"javascript:alert(1)":     "/",
+		" /surat":                 "/",
+		"http:/surat":             "/",
+		"/login?next=//evil.test": "/login?next=//evil.test",
+	}
+	for next, want := range tests {
+		if got := RedirectLokal(next); got != want {
+			t.Errorf("RedirectLokal(%q) = %q, seharusnya %q", next, got, want)
+		}
+	}
+}
+
+func TestHasRole(t *testing.T) {
+	users := map[string]*model.User{
+		model.RoleOperator:   {Role: model.RoleOperator},
+		model.RoleSupervisor: {Role: model.RoleSupervisor},
+		model.RoleAdmin:      {Role: model.RoleAdmin},
+		"tidak dikenal":      {Role: "tamu"},
+	}
+	tests := []struct {
+		user string
+		role string
+		want bool
+	}{
+		{model.RoleOperator, model.RoleOperator, true},
+		{model.RoleOperator, model.RoleSupervisor, false},
+		{model.RoleOperator, model.RoleAdmin, false},
+		{model.RoleSupervisor, model.RoleOperator, true},
+		{model.RoleSupervisor, model.RoleSupervisor, true},
+		{model.RoleSupervisor, model.RoleAdmin, false},
+		{model.RoleAdmin, model.RoleOperator, true},
+		{model.RoleAdmin, model.RoleSupervisor, true},
+		{model.RoleAdmin, model.RoleAdmin, true},
+		{"tidak dikenal", model.RoleOperator, false},
+		{model.RoleAdmin, "superadmin", false},
+		{model.RoleAdmin, "", false},
+	}
+	for _, tt := range tests {
+		if got := users[tt.user].HasRole(tt.role); got != tt.want {
+			t.Errorf("%s.HasRole(%q) = %v, seharusnya %v", tt.user, tt.role, got, tt.want)
+		}
+	}
+
+	var tamu *model.User
+	if tamu.HasRole(model.RoleOperator) {
+		t.Error("user nil dianggap punya peran operator")
+	}
+}
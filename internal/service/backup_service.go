@@ -0,0 +1,436 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"skh_app/internal/model"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrBackupTidakDitemukan = errors.New("file cadangan tidak ditemukan")
+	ErrArsipTidakValid      = errors.New("arsip cadangan tidak valid")
+)
+
+// polaBackup adalah nama arsip yang dibuat aplikasi: skh-backup-<tanggal>-<jam>-<jenis>.zip
+var polaBackup = regexp.MustCompile(`^skh-backup-(\d{8}-\d{6})-(otomatis|manual|pra-pulih)\.zip$`)
+
+const (
+	formatNamaBackup = "20060102-150405"
+	// Isi arsip: database, folder uploads dan keterangan
+	arsipDatabase = "skh.db"
+	arsipUploads  = "uploads/"
+	arsipManifest = "manifest.json"
+	// jedaUlangBackup adalah waktu tunggu sebelum mencoba lagi setelah cadangan otomatis gagal
+	jedaUlangBackup = 10 * time.Minute
+)
+
+// BackupRepositoryInterface mendefinisikan fungsi yang dibutuhkan dari database
+type BackupRepositoryInterface interface {
+	SalinDatabase(tujuan string) error
+	VersiSkema() (int, error)
+	PeriksaCadangan(path string) (int, error)
+	PulihkanDatabase(sumber, actor, arsip string) error
+	CatatBackup(actor string, arsip *model.ArsipBackup) error
+}
+
+// JadwalBackup mengatur cadangan otomatis. Dari semua cadangan di folder, yang disimpan adalah
+// cadangan terbaru pada masing-masing Harian hari, Mingguan minggu dan Bulanan bulan terakhir.
+type JadwalBackup struct {
+	Interval time.Duration // 0 berarti cadangan otomatis dimatikan
+	Harian   int
+	Mingguan int
+	Bulanan  int
+}
+
+// BackupService membuat, merotasi dan memulihkan arsip cadangan database beserta folder uploads
+type BackupService struct {
+	repo       BackupRepositoryInterface
+	loc        *time.Location
+	dir        string
+	uploadsDir string
+	Jadwal     JadwalBackup
+
+	mu sync.Mutex // hanya satu pencadangan atau pemulihan pada satu waktu
+}
+
+// NewBackupService adalah constructor untuk service cadangan. Arsip disimpan di dir.
+func NewBackupService(repo BackupRepositoryInterface, loc *time.Location, dir, uploadsDir string, jadwal JadwalBackup) *BackupService {
+	return &BackupService{repo: repo, loc: loc, dir: dir, uploadsDir: uploadsDir, Jadwal: jadwal}
+}
+
+// Folder adalah lokasi penyimpanan arsip cadangan
+func (s *BackupService) Folder() string {
+	return s.dir
+}
+
+// Buat membuat arsip cadangan baru lalu menghapus cadangan lama sesuai jadwal rotasi.
+// Cadangan yang dibuat pengguna (actor tidak kosong) dicatat di audit log.
+func (s *BackupService) Buat(jenis, actor string) (*model.ArsipBackup, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	arsip, err := s.buat(jenis)
+	if err != nil {
+		return nil, err
+	}
+	if actor != "" {
+		if err := s.repo.CatatBackup(actor, arsip); err != nil {
+			slog.Error("Gagal mencatat cadangan di audit log", "error", err)
+		}
+	}
+	if err := s.rotasi(); err != nil {
+		slog.Error("Gagal menghapus cadangan lama", "error", err)
+	}
+	return arsip, nil
+}
+
+func (s *BackupService) buat(jenis string) (*model.ArsipBackup, error) {
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return nil, fmt.Errorf("gagal menyiapkan folder cadangan: %w", err)
+	}
+	dibuat := time.Now().In(s.loc)
+	nama := fmt.Sprintf("skh-backup-%s-%s.zip", dibuat.Format(formatNamaBackup), jenis)
+	tujuan := filepath.Join(s.dir, nama)
+	if _, err := os.Stat(tujuan); err == nil {
+		return nil, fmt.Errorf("cadangan %s sudah ada, coba lagi beberapa detik lagi", nama)
+	}
+
+	// VACUUM INTO menolak file yang sudah ada, jadi sisa percobaan sebelumnya dihapus dulu
+	salinanDB := filepath.Join(s.dir, "."+nama+".db")
+	os.Remove(salinanDB)
+	defer os.Remove(salinanDB)
+	if err := s.repo.SalinDatabase(salinanDB); err != nil {
+		return nil, fmt.Errorf("gagal menyalin database: %w", err)
+	}
+	versi, err := s.repo.VersiSkema()
+	if err != nil {
+		return nil, err
+	}
+
+	// Arsip ditulis ke file sementara lalu di-rename, sehingga arsip setengah jadi tidak pernah terlihat
+	sementara := filepath.Join(s.dir, "."+nama+".tmp")
+	defer os.Remove(sementara)
+	f, err := os.Create(sementara)
+	if err != nil {
+		return nil, fmt.Errorf("gagal membuat file cadangan: %w", err)
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	if err := tambahKeArsip(zw, arsipDatabase, salinanDB); err != nil {
+		return nil, err
+	}
+	uploads, err := os.ReadDir(s.uploadsDir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("gagal membaca folder uploads: %w", err)
+	}
+	jumlah := 0
+	for _, u := range uploads {
+		if !u.Type().IsRegular() {
+			continue
+		}
+		if err := tambahKeArsip(zw, arsipUploads+u.Name(), filepath.Join(s.uploadsDir, u.Name())); err != nil {
+			return nil, err
+		}
+		jumlah++
+	}
+	manifest, err := zw.Create(arsipManifest)
+	if err != nil {
+		return nil, err
+	}
+	enc := json.NewEncoder(manifest)
+	enc.SetIndent("", "  ")
+	err = enc.Encode(model.ManifestBackup{Aplikasi: "skh_app", Dibuat: dibuat, Jenis: jenis, VersiSkema: versi, JumlahUnggah: jumlah})
+	if err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("gagal menulis arsip cadangan: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(sementara, tujuan); err != nil {
+		return nil, fmt.Errorf("gagal menyimpan arsip cadangan: %w", err)
+	}
+
+	info, err := os.Stat(tujuan)
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("Cadangan dibuat", "file", nama, "ukuran", info.Size(), "uploads", jumlah)
+	return &model.ArsipBackup{Nama: nama, Jenis: jenis, Dibuat: dibuat, Ukuran: info.Size()}, nil
+}
+
+// tambahKeArsip menyalin file sumber ke arsip dengan nama tersebut
+func tambahKeArsip(zw *zip.Writer, nama, sumber string) error {
+	src, err := os.Open(sumber)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = nama
+	header.Method = zip.Deflate
+	dst, err := zw.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		return fmt.Errorf("gagal menambahkan %s ke arsip: %w", nama, err)
+	}
+	return nil
+}
+
+// Daftar mengembalikan arsip cadangan di folder cadangan, yang terbaru lebih dulu
+func (s *BackupService) Daftar() ([]model.ArsipBackup, error) {
+	entries, err := os.ReadDir(s.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var daftar []model.ArsipBackup
+	for _, e := range entries {
+		m := polaBackup.FindStringSubmatch(e.Name())
+		if m == nil || !e.Type().IsRegular() {
+			continue
+		}
+		dibuat, err := time.ParseInLocation(formatNamaBackup, m[1], s.loc)
+		if err != nil {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		daftar = append(daftar, model.ArsipBackup{Nama: e.Name(), Jenis: m[2], Dibuat: dibuat, Ukuran: info.Size()})
+	}
+	sort.Slice(daftar, func(i, j int) bool { return daftar[i].Dibuat.After(daftar[j].Dibuat) })
+	return daftar, nil
+}
+
+// Path mengembalikan lokasi arsip cadangan bernama nama. Hanya nama arsip buatan aplikasi yang
+// diterima, sehingga nama dari URL tidak bisa menunjuk file lain.
+func (s *BackupService) Path(nama string) (string, error) {
+	if !polaBackup.MatchString(nama) {
+		return "", ErrBackupTidakDitemukan
+	}
+	p := filepath.Join(s.dir, nama)
+	if _, err := os.Stat(p); err != nil {
+		return "", ErrBackupTidakDitemukan
+	}
+	return p, nil
+}
+
+// rotasi menghapus cadangan yang tidak termasuk jatah harian, mingguan maupun bulanan.
+// Cadangan terbaru selalu disimpan. Cadangan pra-pulih tidak ikut dirotasi karena hanya di
+// sanalah keadaan sebelum pemulihan tersimpan; arsip itu dihapus sendiri oleh admin.
+func (s *BackupService) rotasi() error {
+	semua, err := s.Daftar()
+	if err != nil {
+		return err
+	}
+	var daftar []model.ArsipBackup
+	for _, a := range semua {
+		if a.Jenis != model.BackupPraPulih {
+			daftar = append(daftar, a)
+		}
+	}
+	simpan := map[string]bool{}
+	if len(daftar) > 0 {
+		simpan[daftar[0].Nama] = true
+	}
+	jatah := []struct {
+		jumlah int
+		kunci  func(t time.Time) string
+	}{
+		{s.Jadwal.Harian, func(t time.Time) string { return t.Format("2006-01-02") }},
+		{s.Jadwal.Mingguan, func(t time.Time) string {
+			tahun, minggu := t.ISOWeek()
+			return fmt.Sprintf("%d-%02d", tahun, minggu)
+		}},
+		{s.Jadwal.Bulanan, func(t time.Time) string { return t.Format("2006-01") }},
+	}
+	for _, j := range jatah {
+		periode := map[string]bool{}
+		for _, a := range daftar {
+			k := j.kunci(a.Dibuat)
+			if periode[k] {
+				continue
+			}
+			if len(periode) == j.jumlah {
+				break
+			}
+			periode[k] = true
+			simpan[a.Nama] = true
+		}
+	}
+
+	for _, a := range daftar {
+		if simpan[a.Nama] {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.dir, a.Nama)); err != nil {
+			return err
+		}
+		slog.Info("Cadangan lama dihapus", "file", a.Nama)
+	}
+	return nil
+}
+
+// Jadwalkan membuat cadangan otomatis setiap Jadwal.Interval sampai ctx selesai. Bila cadangan
+// terakhir sudah lebih tua dari interval (mis. komputer baru dinyalakan), cadangan langsung dibuat.
+func (s *BackupService) Jadwalkan(ctx context.Context) {
+	interval := s.Jadwal.Interval
+	if interval <= 0 {
+		return
+	}
+	slog.Info("Cadangan otomatis aktif", "folder", s.dir, "interval", interval)
+	gagal := false
+	for {
+		tunggu := time.Duration(0)
+		if gagal {
+			tunggu = min(interval, jedaUlangBackup)
+		} else if daftar, err := s.Daftar(); err == nil && len(daftar) > 0 {
+			tunggu = time.Until(daftar[0].Dibuat.Add(interval))
+		}
+		if tunggu > 0 {
+			t := time.NewTimer(tunggu)
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return
+			case <-t.C:
+			}
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		_, err := s.Buat(model.BackupOtomatis, "")
+		if gagal = err != nil; gagal {
+			slog.Error("Cadangan otomatis gagal", "error", err)
+		}
+	}
+}
+
+// Pulihkan mengganti database dan melengkapi folder uploads dari arsip cadangan. Database di
+// dalam arsip harus lolos PRAGMA integrity_check; sebelum ditimpa, keadaan sekarang dicadangkan
+// dulu dan arsip pengaman itu dikembalikan agar bisa dipakai untuk membatalkan pemulihan.
+func (s *BackupService) Pulihkan(arsip io.ReaderAt, ukuran int64, namaArsip, actor string) (*model.ArsipBackup, error) {
+	zr, err := zip.NewReader(arsip, ukuran)
+	if err != nil {
+		return nil, fmt.Errorf("%w: bukan file zip", ErrArsipTidakValid)
+	}
+	var fileDB *zip.File
+	var uploads []*zip.File
+	for _, f := range zr.File {
+		switch {
+		case f.Name == arsipDatabase:
+			fileDB = f
+		case strings.HasPrefix(f.Name, arsipUploads) && !f.FileInfo().IsDir():
+			nama := strings.TrimPrefix(f.Name, arsipUploads)
+			if nama == "" || nama != path.Base(nama) || strings.HasPrefix(nama, ".") || strings.Contains(nama, `\`) {
+				return nil, fmt.Errorf("%w: nama file %q di folder uploads tidak diizinkan", ErrArsipTidakValid, f.Name)
+			}
+			uploads = append(uploads, f)
+		}
+	}
+	if fileDB == nil {
+		return nil, fmt.Errorf("%w: tidak berisi %s", ErrArsipTidakValid, arsipDatabase)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return nil, fmt.Errorf("gagal menyiapkan folder cadangan: %w", err)
+	}
+	kerja, err := os.MkdirTemp(s.dir, ".pulih-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(kerja)
+
+	sumberDB := filepath.Join(kerja, arsipDatabase)
+	if err := ekstrak(fileDB, sumberDB); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrArsipTidakValid, err)
+	}
+	versi, err := s.repo.PeriksaCadangan(sumberDB)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrArsipTidakValid, err)
+	}
+	// Uploads diekstrak sekali ke folder kerja agar arsip yang rusak ketahuan sebelum apa pun ditimpa
+	for _, f := range uploads {
+		if err := ekstrak(f, filepath.Join(kerja, f.Name)); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrArsipTidakValid, err)
+		}
+	}
+
+	pengaman, err := s.buat(model.BackupPraPulih)
+	if err != nil {
+		return nil, fmt.Errorf("gagal membuat cadangan pengaman, pemulihan dibatalkan: %w", err)
+	}
+	if err := s.repo.PulihkanDatabase(sumberDB, actor, namaArsip); err != nil {
+		return nil, fmt.Errorf("%w (keadaan sebelumnya tersimpan di %s)", err, pengaman.Nama)
+	}
+
+	// File uploads hanya ditambah atau ditimpa; logo lain yang sudah ada dibiarkan
+	if err := os.MkdirAll(s.uploadsDir, 0o755); err != nil {
+		return nil, err
+	}
+	for _, f := range uploads {
+		nama := strings.TrimPrefix(f.Name, arsipUploads)
+		if err := ekstrak(f, filepath.Join(s.uploadsDir, nama)); err != nil {
+			return nil, fmt.Errorf("database sudah dipulihkan tetapi gagal menyalin uploads/%s: %w", nama, err)
+		}
+	}
+	slog.Info("Database dipulihkan dari cadangan", "arsip", namaArsip, "versi_skema", versi,
+		"uploads", len(uploads), "cadangan_pengaman", pengaman.Nama)
+	return pengaman, nil
+}
+
+// ekstrak menulis isi satu file dalam arsip ke tujuan
+func ekstrak(f *zip.File, tujuan string) error {
+	if err := os.MkdirAll(filepath.Dir(tujuan), 0o755); err != nil {
+		return err
+	}
+	src, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.Create(tujuan)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return fmt.Errorf("gagal membaca %s dari arsip: %w", f.Name, err)
+	}
+	return dst.Close()
+}
@@ -0,0 +1,280 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"skh_app/internal/model"
+	"skh_app/internal/validasi"
+	"strings"
+	"unicode/utf8"
+)
+
+// BarangRepositoryInterface mendefinisikan fungsi database untuk registri jenis barang
+type BarangRepositoryInterface interface {
+	GetAllJenisBarang() ([]model.JenisBarang, error)
+	GetJenisBarangByID(id int) (*model.JenisBarang, error)
+	CreateJenisBarang(j *model.JenisBarang, actor string) error
+	UpdateJenisBarang(j *model.JenisBarang, actor string) error
+}
+
+// BarangService mengelola jenis barang yang dapat dilaporkan hilang
+type BarangService struct {
+	repo BarangRepositoryInterface
+}
+
+// NewBarangService adalah constructor untuk BarangService.
+func NewBarangService(repo BarangRepositoryInterface) *BarangService {
+	return &BarangService{repo: repo}
+}
+
+var (
+	kunciFieldRegex = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+	tokenFrasaRegex = regexp.MustCompile(`\{([^{}]*)\}`)
+)
+
+// Registri mengembalikan semua jenis barang (termasuk nonaktif) untuk mencetak dan memvalidasi surat
+func (s *BarangService) Registri() (model.RegistriBarang, error) {
+	return loadRegistri(s.repo)
+}
+
+// JenisUntukForm mengembalikan jenis aktif ditambah jenis nonaktif yang sudah dipakai surat,
+// agar barang lama tetap dapat diedit.
+func (s *BarangService) JenisUntukForm(surat *model.SuratKeteranganHilang) ([]model.JenisBarang, error) {
+	list, err := s.repo.GetAllJenisBarang()
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil jenis barang: %w", err)
+	}
+	dipakai := jenisDipakai(surat)
+	out := list[:0]
+	for _, j := range list {
+		if j.Aktif || dipakai[j.Nama] {
+			out = append(out, j)
+		}
+	}
+	return out, nil
+}
+
+// GetAllJenis mengembalikan semua jenis barang untuk halaman admin
+func (s *BarangService) GetAllJenis() ([]model.JenisBarang, error) {
+	return s.repo.GetAllJenisBarang()
+}
+
+// SimpanJenis memvalidasi lalu membuat (ID 0) atau mengubah jenis barang
+func (s *BarangService) SimpanJenis(j *model.JenisBarang, actor string) error {
+	if err := validateJenisBarang(j); err != nil {
+		return err
+	}
+
+	if j.ID == 0 {
+		registri, err := loadRegistri(s.repo)
+		if err != nil {
+			return err
+		}
+		for nama := range registri {
+			if strings.EqualFold(nama, j.Nama) {
+				return fmt.Errorf("jenis barang %s sudah ada", nama)
+			}
+		}
+		if err := s.repo.CreateJenisBarang(j, actor); err != nil {
+			return fmt.Errorf("gagal menyimpan jenis barang: %w", err)
+		}
+		return nil
+	}
+
+	lama, err := s.repo.GetJenisBarangByID(j.ID)
+	if err != nil {
+		return fmt.Errorf("jenis barang tidak ditemukan")
+	}
+	j.Nama = lama.Nama
+	if err := s.repo.UpdateJenisBarang(j, actor); err != nil {
+		return fmt.Errorf("gagal mengupdate jenis barang: %w", err)
+	}
+	return nil
+}
+
+// jenisBarangLister dipenuhi oleh BarangRepositoryInterface maupun SuratRepositoryInterface
+type jenisBarangLister interface {
+	GetAllJenisBarang() ([]model.JenisBarang, error)
+}
+
+func loadRegistri(repo jenisBarangLister) (model.RegistriBarang, error) {
+	list, err := repo.GetAllJenisBarang()
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil jenis barang: %w", err)
+	}
+	registri := make(model.RegistriBarang, len(list))
+	for _, j := range list {
+		registri[j.Nama] = j
+	}
+	return registri, nil
+}
+
+func jenisDipakai(surat *model.SuratKeteranganHilang) map[string]bool {
+	dipakai := map[string]bool{}
+	if surat != nil {
+		for _, b := range surat.BarangHilang {
+			dipakai[b.JenisBarang] = true
+		}
+	}
+	return dipakai
+}
+
+// validateJenisBarang memeriksa definisi jenis barang yang diisi admin dan merapikan isiannya
+func validateJenisBarang(j *model.JenisBarang) error {
+	j.Nama = strings.TrimSpace(j.Nama)
+	j.FrasaCetak = strings.TrimSpace(j.FrasaCetak)
+	if j.Nama == "" {
+		return fmt.Errorf("nama jenis barang wajib diisi")
+	}
+	if utf8.RuneCountInString(j.Nama) > 50 {
+		return fmt.Errorf("nama jenis barang maksimal 50 karakter")
+	}
+	if len(j.Fields) == 0 {
+		return fmt.Errorf("jenis barang harus memiliki minimal satu isian")
+	}
+
+	kunci := map[string]bool{}
+	for i := range j.Fields {
+		f := &j.Fields[i]
+		f.Kunci = strings.TrimSpace(f.Kunci)
+		f.Label = strings.TrimSpace(f.Label)
+		f.Pola = strings.TrimSpace(f.Pola)
+
+		if !kunciFieldRegex.MatchString(f.Kunci) {
+			return fmt.Errorf("kunci isian ke-%d harus diawali huruf kecil dan hanya berisi huruf kecil, angka atau garis bawah", i+1)
+		}
+		if kunci[f.Kunci] {
+			return fmt.Errorf("kunci isian %s dipakai lebih dari sekali", f.Kunci)
+		}
+		kunci[f.Kunci] = true
+		if f.Label == "" {
+			return fmt.Errorf("label isian %s wajib diisi", f.Kunci)
+		}
+		if f.MaksPanjang < 0 {
+			return fmt.Errorf("panjang maksimal isian %s tidak boleh negatif", f.Kunci)
+		}
+
+		switch f.Tipe {
+		case model.FieldTeks, model.FieldTeksPanjang:
+			f.Pilihan = nil
+		case model.FieldPilihan:
+			var pilihan []string
+			for _, p := range f.Pilihan {
+				if p = strings.TrimSpace(p); p != "" {
+					pilihan = append(pilihan, p)
+				}
+			}
+			if len(pilihan) == 0 {
+				return fmt.Errorf("isian %s bertipe pilihan harus memiliki minimal satu pilihan", f.Kunci)
+			}
+			f.Pilihan = pilihan
+		default:
+			return fmt.Errorf("tipe isian %s tidak dikenal", f.Kunci)
+		}
+
+		if f.Validasi != "" && !validasi.IsAturan(f.Validasi) {
+			return fmt.Errorf("aturan validasi isian %s tidak dikenal", f.Kunci)
+		}
+		if f.Pola != "" {
+			if _, err := regexp.Compile(f.Pola); err != nil {
+				return fmt.Errorf("pola isian %s tidak valid: %v", f.Kunci, err)
+			}
+		}
+	}
+
+	for _, m := range tokenFrasaRegex.FindAllStringSubmatch(j.FrasaCetak, -1) {
+		if !kunci[m[1]] {
+			return fmt.Errorf("frasa cetak memakai {%s} yang bukan kunci isian", m[1])
+		}
+	}
+	return nil
+}
+
+// validateBarang memeriksa setiap barang terhadap registri dan menyimpan ulang Data hanya dengan
+// isian yang terdefinisi dalam bentuk bakunya. Kesalahan dicatat di errs dengan field "barang.<indeks>".
+// Jenis nonaktif hanya diterima jika ada di bolehNonaktif.
+func validateBarang(registri model.RegistriBarang, list []model.Barang, bolehNonaktif map[string]bool, errs validasi.Errors) {
+	for i := range list {
+		b := &list[i]
+		field := fmt.Sprintf("barang.%d", i)
+		j, ok := registri[b.JenisBarang]
+		if !ok {
+			errs.Add(field, fmt.Sprintf("barang ke-%d: jenis %q tidak dikenal", i+1, b.JenisBarang))
+			continue
+		}
+		if !j.Aktif && !bolehNonaktif[j.Nama] {
+			errs.Add(field, fmt.Sprintf("barang ke-%d: jenis %s sudah tidak dapat dipilih", i+1, j.Nama))
+			continue
+		}
+
+		// UseNumber agar nomor yang dikirim sebagai angka JSON tidak berubah menjadi notasi eksponen
+		var raw map[string]interface{}
+		if b.Data != "" {
+			dec := json.NewDecoder(strings.NewReader(b.Data))
+			dec.UseNumber()
+			if err := dec.Decode(&raw); err != nil {
+				errs.Add(field, fmt.Sprintf("barang ke-%d (%s): data tidak valid", i+1, j.Nama))
+				continue
+			}
+		}
+
+		data := make(map[string]string, len(j.Fields))
+		for _, f := range j.Fields {
+			var v string
+			if raw[f.Kunci] != nil {
+				v = strings.TrimSpace(fmt.Sprint(raw[f.Kunci]))
+			}
+			v, err := validateIsian(f, v)
+			if err != nil {
+				errs.Add(field, fmt.Sprintf("barang ke-%d (%s): %v", i+1, j.Nama, err))
+			}
+			data[f.Kunci] = v
+		}
+
+		normal, err := json.Marshal(data)
+		if err != nil {
+			errs.Add(field, fmt.Sprintf("barang ke-%d (%s): data tidak valid", i+1, j.Nama))
+			continue
+		}
+		b.Data = string(normal)
+	}
+}
+
+// validateIsian memeriksa satu nilai terhadap aturan field dan mengembalikan bentuk bakunya
+func validateIsian(f model.FieldBarang, v string) (string, error) {
+	if v == "" {
+		if f.Wajib {
+			return v, fmt.Errorf("%s wajib diisi", f.Label)
+		}
+		return v, nil
+	}
+	if f.Validasi != "" {
+		baku, err := validasi.Periksa(f.Validasi, v)
+		if err != nil {
+			return v, err
+		}
+		v = baku
+	}
+	if f.MaksPanjang > 0 && utf8.RuneCountInString(v) > f.MaksPanjang {
+		return v, fmt.Errorf("%s maksimal %d karakter", f.Label, f.MaksPanjang)
+	}
+	if f.Tipe == model.FieldPilihan {
+		for _, p := range f.Pilihan {
+			if p == v {
+				return v, nil
+			}
+		}
+		return v, fmt.Errorf("%s harus salah satu dari %s", f.Label, strings.Join(f.Pilihan, ", "))
+	}
+	if f.Pola != "" {
+		re, err := regexp.Compile(`^(?:` + f.Pola + `)$`)
+		if err != nil {
+			return v, fmt.Errorf("pola %s rusak, periksa pengaturan jenis barang", f.Label)
+		}
+		if !re.MatchString(v) {
+			return v, fmt.Errorf("format %s tidak sesuai", f.Label)
+		}
+	}
+	return v, nil
+}
@@ -0,0 +1,237 @@
+package service
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"skh_app/internal/model"
+	"skh_app/internal/xlsx"
+	"strings"
+	"time"
+)
+
+// Format file ekspor
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// ExportRepositoryInterface adalah fungsi database yang dibutuhkan ExportService
+type ExportRepositoryInterface interface {
+	CariSurat(f model.SuratFilter) ([]model.SuratKeteranganHilang, error)
+	GetSuratByIDs(ids []int) ([]model.SuratKeteranganHilang, error)
+	GetAllJenisBarang() ([]model.JenisBarang, error)
+}
+
+// ExportService menyusun register surat untuk diunduh sebagai CSV atau XLSX
+type ExportService struct {
+	repo ExportRepositoryInterface
+	loc  *time.Location
+}
+
+// NewExportService adalah constructor untuk ExportService. loc adalah zona waktu kantor.
+func NewExportService(repo ExportRepositoryInterface, loc *time.Location) *ExportService {
+	return &ExportService{repo: repo, loc: loc}
+}
+
+// exportBatch adalah jumlah surat yang isinya diambil sekaligus saat menulis register
+const exportBatch = 200
+
+// penulisTabel adalah tujuan baris register: CSV atau sheet XLSX
+type penulisTabel interface {
+	Judul(sel ...interface{}) error
+	Baris(sel ...interface{}) error
+	Close() error
+}
+
+// penulisCSV menulis CSV UTF-8 dengan BOM agar huruf non-ASCII terbaca benar di Excel
+type penulisCSV struct {
+	w *csv.Writer
+}
+
+func newPenulisCSV(w io.Writer) (*penulisCSV, error) {
+	if _, err := io.WriteString(w, "\ufeff"); err != nil {
+		return nil, err
+	}
+	return &penulisCSV{w: csv.NewWriter(w)}, nil
+}
+
+func (c *penulisCSV) Judul(sel ...interface{}) error { return c.Baris(sel...) }
+
+func (c *penulisCSV) Baris(sel ...interface{}) error {
+	rec := make([]string, len(sel))
+	for i, v := range sel {
+		if v != nil {
+			rec[i] = escapeFormula(fmt.Sprint(v))
+		}
+	}
+	return c.w.Write(rec)
+}
+
+// escapeFormula mendahului sel yang diawali = + - @ tab atau CR dengan tanda petik, agar isian
+// pelapor tidak dijalankan sebagai rumus saat CSV dibuka di Excel atau LibreOffice
+func escapeFormula(sel string) string {
+	if sel != "" && strings.ContainsRune("=+-@\t\r", rune(sel[0])) {
+		return "'" + sel
+	}
+	return sel
+}
+
+func (c *penulisCSV) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// Register menulis register surat yang cocok dengan filter (tanpa paginasi) ke w, satu baris per
+// surat atau satu baris per barang bila perBarang. Data ditulis bertahap sambil dibaca: isi surat
+// dan barangnya diambil per kelompok exportBatch surat.
+func (s *ExportService) Register(w io.Writer, format string, f model.SuratFilter, perBarang bool) error {
+	daftar, err := s.repo.CariSurat(f)
+	if err != nil {
+		return fmt.Errorf("gagal mengambil daftar surat: %w", err)
+	}
+	registri, err := loadRegistri(s.repo)
+	if err != nil {
+		return err
+	}
+
+	var tabel penulisTabel
+	switch format {
+	case FormatCSV:
+		if tabel, err = newPenulisCSV(w); err != nil {
+			return err
+		}
+	case FormatXLSX:
+		x := xlsx.NewWriter(w)
+		// Lebar kolom mengikuti urutan judul di bawah
+		lebar := []float64{5, 30, 18, 12, 18, 28, 18, 28, 12, 12, 20, 35, 30}
+		if perBarang {
+			lebar = append(lebar, 14, 50)
+		} else {
+			lebar = append(lebar, 60)
+		}
+		lebar = append(lebar, 28, 22, 28, 22, 35)
+		if err := x.Sheet("Register Surat", lebar...); err != nil {
+			return err
+		}
+		tabel = x
+	default:
+		return fmt.Errorf("format ekspor %q tidak dikenal", format)
+	}
+
+	judul := []interface{}{"No", "Nomor Surat", "Tanggal Surat", "Status", "Berlaku Sampai", "Nama Pelapor",
+		"NIK Pelapor", "Tempat/Tgl. Lahir", "Jenis Kelamin", "Agama", "Pekerjaan", "Alamat", "Lokasi Hilang"}
+	if perBarang {
+		judul = append(judul, "Jenis Barang", "Keterangan Barang")
+	} else {
+		judul = append(judul, "Barang Hilang")
+	}
+	judul = append(judul, "Pejabat Penandatangan", "Pangkat/NRP Pejabat", "Petugas Penerima", "Pangkat/NRP Penerima", "Catatan")
+	if err := tabel.Judul(judul...); err != nil {
+		return err
+	}
+
+	no := 0
+	for mulai := 0; mulai < len(daftar); mulai += exportBatch {
+		ids := make([]int, 0, exportBatch)
+		for _, ringkas := range daftar[mulai:min(mulai+exportBatch, len(daftar))] {
+			ids = append(ids, ringkas.ID)
+		}
+		lengkap, err := s.repo.GetSuratByIDs(ids)
+		if err != nil {
+			return fmt.Errorf("gagal mengambil isi surat: %w", err)
+		}
+		for j := range lengkap {
+			surat := &lengkap[j]
+			no++
+			awal := []interface{}{no, surat.NomorSurat, FormatTanggalIndo(surat.TanggalSurat), statusSurat(surat),
+				s.formatTanggal(surat.BerlakuSampai), surat.PelaporNama, surat.PelaporNIK, s.formatTTL(surat.PelaporTTL), surat.PelaporKelamin,
+				surat.PelaporAgama, surat.PelaporPekerjaan, surat.PelaporAlamat, surat.LokasiHilang}
+			akhir := []interface{}{}
+			akhir = append(akhir, identitasPetugas(surat.Pejabat)...)
+			akhir = append(akhir, identitasPetugas(surat.Penerima)...)
+			akhir = append(akhir, catatanSurat(surat))
+
+			if !perBarang {
+				var barang []string
+				for _, b := range surat.BarangHilang {
+					barang = append(barang, fmt.Sprintf("%s (%s)", b.JenisBarang, registri.Keterangan(b)))
+				}
+				baris := append(append(awal, strings.Join(barang, "; ")), akhir...)
+				if err := tabel.Baris(baris...); err != nil {
+					return err
+				}
+				continue
+			}
+			if len(surat.BarangHilang) == 0 {
+				if err := tabel.Baris(append(append(awal, "", ""), akhir...)...); err != nil {
+					return err
+				}
+			}
+			for _, b := range surat.BarangHilang {
+				baris := append(append(append([]interface{}{}, awal...), b.JenisBarang, registri.Keterangan(b)), akhir...)
+				if err := tabel.Baris(baris...); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return tabel.Close()
+}
+
+func (s *ExportService) formatTanggal(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return FormatTanggalIndo(t)
+}
+
+// formatTTL menulis ulang "Tempat, YYYY-MM-DD" menjadi "Tempat, 1 Januari 1990"
+func (s *ExportService) formatTTL(ttl string) string {
+	lahir, ok := tanggalLahirPelapor(ttl, s.loc)
+	if !ok {
+		return strings.Trim(ttl, ", ")
+	}
+	tempat := strings.TrimSpace(ttl[:strings.LastIndex(ttl, ",")])
+	if tempat == "" {
+		return FormatTanggalIndo(lahir)
+	}
+	return tempat + ", " + FormatTanggalIndo(lahir)
+}
+
+func statusSurat(s *model.SuratKeteranganHilang) string {
+	switch {
+	case s.IsDibatalkan():
+		return "Dibatalkan"
+	case s.Kedaluwarsa:
+		return "Kedaluwarsa"
+	}
+	return "Aktif"
+}
+
+func catatanSurat(s *model.SuratKeteranganHilang) string {
+	var catatan []string
+	if s.IsPerpanjangan() {
+		catatan = append(catatan, "Perpanjangan dari "+s.SuratAsalNomor)
+	}
+	if s.PerpanjanganNomor != "" {
+		catatan = append(catatan, "Diperpanjang dengan "+s.PerpanjanganNomor)
+	}
+	if s.IsDibatalkan() {
+		catatan = append(catatan, "Dibatalkan: "+s.AlasanBatal)
+	}
+	if s.TandaBerulang != "" {
+		catatan = append(catatan, "Laporan berulang: "+s.TandaBerulang)
+	}
+	return strings.Join(catatan, "; ")
+}
+
+// identitasPetugas mengembalikan kolom nama dan pangkat/NRP dari salinan petugas pada surat;
+// kosong jika surat tidak mencatat petugas
+func identitasPetugas(p model.Petugas) []interface{} {
+	pangkat := p.Pangkat
+	if p.NRP != "" {
+		pangkat = strings.TrimSpace(pangkat + " / NRP " + p.NRP)
+	}
+	return []interface{}{p.Nama, strings.TrimPrefix(pangkat, "/ ")}
+}
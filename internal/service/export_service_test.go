@@ -0,0 +1,81 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEscapeFormula(t *testing.T) {
+	tests := map[string]string{
+		"":                   "",
+		"Budi":               "Budi",
+		"=1+1":               "'=1+1",
+		"+62 812":            "'+62 812",
+		"-5":                 "'-5",
+		"@SUM(A1)":           "'@SUM(A1)",
+		"\t=1":               "'\t=1",
+		"\r=1":               "'\r=1",
+		"a=1":                "a=1",
+		" =1":                " =1",
+		"'=1":                "'=1",
+		"SKH/001/VIII/2025":  "SKH/001/VIII/2025",
+		"=HYPERLINK(\"x\")":  "'=HYPERLINK(\"x\")",
+		"Jl. Perintis No.-3": "Jl. Perintis No.-3",
+	}
+	for sel, want := range tests {
+		if got := escapeFormula(sel); got != want {
+			t.Errorf("escapeFormula(%q) = %q, seharusnya %q", sel, got, want)
+		}
+	}
+}
+
+func TestPenulisCSV(t *testing.T) {
+	var buf bytes.Buffer
+	c, err := newPenulisCSV(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Judul("No", "Nama Pelapor", "Lokasi"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Baris(1, `Budi "Kacamata", S.H.`, "Pasar\nSentral"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Baris(2, "=cmd|' /C calc'!A0", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Baris(-3, "@Siti", "+62"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "\ufeff" +
+		"No,Nama Pelapor,Lokasi\n" +
+		"1,\"Budi \"\"Kacamata\"\", S.H.\",\"Pasar\nSentral\"\n" +
+		"2,'=cmd|' /C calc'!A0,\n" +
+		"'-3,'@Siti,'+62\n"
+	if got := buf.String(); got != want {
+		t.Errorf("isi CSV:\n%q\nseharusnya:\n%q", got, want)
+	}
+
+	// Hasilnya bisa dibaca kembali oleh pembaca CSV biasa
+	r := csv.NewReader(strings.NewReader(strings.TrimPrefix(buf.String(), "\ufeff")))
+	rec, err := r.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	harus := [][]string{
+		{"No", "Nama Pelapor", "Lokasi"},
+		{"1", `Budi "Kacamata", S.H.`, "Pasar\nSentral"},
+		{"2", "'=cmd|' /C calc'!A0", ""},
+		{"'-3", "'@Siti", "'+62"},
+	}
+	if !reflect.DeepEqual(rec, harus) {
+		t.Errorf("CSV dibaca kembali = %q, seharusnya %q", rec, harus)
+	}
+}
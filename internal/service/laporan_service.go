@@ -0,0 +1,210 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"skh_app/internal/model"
+	"skh_app/internal/xlsx"
+	"sort"
+	"time"
+)
+
+// lokasiTeratas adalah jumlah lokasi kehilangan terbanyak yang dicantumkan dalam laporan
+const lokasiTeratas = 10
+
+// ErrPeriodeTidakValid dikembalikan bila periode, tahun atau bulan laporan tidak dikenal
+var ErrPeriodeTidakValid = errors.New("periode laporan tidak valid")
+
+// LaporanRepositoryInterface adalah fungsi database yang dibutuhkan LaporanService
+type LaporanRepositoryInterface interface {
+	RekapSurat(dari, sampai time.Time, kantorID int) (*model.RekapSurat, error)
+}
+
+// LaporanService menyusun laporan rekap bulanan dan tahunan
+type LaporanService struct {
+	repo LaporanRepositoryInterface
+	loc  *time.Location
+}
+
+// NewLaporanService adalah constructor untuk LaporanService. Batas bulan dan tahun laporan
+// mengikuti zona waktu loc.
+func NewLaporanService(repo LaporanRepositoryInterface, loc *time.Location) *LaporanService {
+	return &LaporanService{repo: repo, loc: loc}
+}
+
+// Buat menyusun laporan satu bulan (periode LaporanBulanan) atau satu tahun (LaporanTahunan)
+// beserta perbandingan dengan bulan atau tahun sebelumnya. bulan diabaikan untuk laporan tahunan.
+// kantorID 0 menyusun laporan gabungan semua kantor beserta rincian per kantor.
+func (s *LaporanService) Buat(periode string, tahun, bulan, kantorID int) (*model.Laporan, error) {
+	if tahun < 2000 || tahun > 9999 {
+		return nil, ErrPeriodeTidakValid
+	}
+	lap := &model.Laporan{Periode: periode, Tahun: tahun, KantorID: kantorID}
+	var dariLalu time.Time
+	switch periode {
+	case model.LaporanBulanan:
+		if bulan < 1 || bulan > 12 {
+			return nil, ErrPeriodeTidakValid
+		}
+		lap.Bulan = bulan
+		lap.Dari = time.Date(tahun, time.Month(bulan), 1, 0, 0, 0, 0, s.loc)
+		lap.Sampai = lap.Dari.AddDate(0, 1, 0)
+		dariLalu = lap.Dari.AddDate(0, -1, 0)
+		lap.Judul = fmt.Sprintf("%s %d", namaBulan[bulan], tahun)
+		lap.JudulPembanding = fmt.Sprintf("%s %d", namaBulan[dariLalu.Month()], dariLalu.Year())
+		lap.LabelWaktu = "Tanggal"
+	case model.LaporanTahunan:
+		lap.Dari = time.Date(tahun, time.January, 1, 0, 0, 0, 0, s.loc)
+		lap.Sampai = lap.Dari.AddDate(1, 0, 0)
+		dariLalu = lap.Dari.AddDate(-1, 0, 0)
+		lap.Judul = fmt.Sprintf("Tahun %d", tahun)
+		lap.JudulPembanding = fmt.Sprintf("Tahun %d", tahun-1)
+		lap.LabelWaktu = "Bulan"
+	default:
+		return nil, ErrPeriodeTidakValid
+	}
+
+	kini, err := s.repo.RekapSurat(lap.Dari, lap.Sampai, kantorID)
+	if err != nil {
+		return nil, fmt.Errorf("gagal menghitung rekap %s: %w", lap.Judul, err)
+	}
+	lalu, err := s.repo.RekapSurat(dariLalu, lap.Dari, kantorID)
+	if err != nil {
+		return nil, fmt.Errorf("gagal menghitung rekap %s: %w", lap.JudulPembanding, err)
+	}
+
+	lap.Ringkasan = []model.BarisLaporan{
+		{Label: "Surat diterbitkan", Jumlah: kini.Terbit, Pembanding: lalu.Terbit},
+		{Label: "Surat sah (tidak dibatalkan)", Jumlah: kini.Sah, Pembanding: lalu.Sah},
+		{Label: "Perpanjangan", Jumlah: kini.Perpanjangan, Pembanding: lalu.Perpanjangan},
+		{Label: "Barang dilaporkan hilang", Jumlah: jumlahSemua(kini.PerJenis), Pembanding: jumlahSemua(lalu.PerJenis)},
+		{Label: "Pembatalan", Jumlah: len(kini.Pembatalan), Pembanding: len(lalu.Pembatalan)},
+		{Label: "Cetak ulang (salinan)", Jumlah: kini.CetakUlang, Pembanding: lalu.CetakUlang},
+	}
+	lap.PerJenis = gabungRekap(kini.PerJenis, lalu.PerJenis, 0)
+	lap.PerKelamin = gabungRekap(kini.PerKelamin, lalu.PerKelamin, 0)
+	lap.PerPekerjaan = gabungRekap(kini.PerPekerjaan, lalu.PerPekerjaan, 0)
+	lap.LokasiTeratas = gabungRekap(kini.PerLokasi, lalu.PerLokasi, lokasiTeratas)
+	lap.PerWaktu = s.rekapWaktu(lap, kini.PerTanggal, lalu.PerTanggal)
+	// Rincian per kantor hanya berarti bila instalasi punya lebih dari satu kantor
+	if perKantor := gabungRekap(kini.PerKantor, lalu.PerKantor, 0); len(perKantor) > 1 {
+		lap.PerKantor = perKantor
+	}
+	lap.Pembatalan = kini.Pembatalan
+	return lap, nil
+}
+
+// rekapWaktu menyusun jumlah per tanggal (laporan bulanan) atau per bulan (tahunan). Pembandingnya
+// adalah tanggal yang sama pada bulan sebelumnya atau bulan yang sama pada tahun sebelumnya.
+func (s *LaporanService) rekapWaktu(lap *model.Laporan, kini, lalu map[string]int) []model.BarisLaporan {
+	var hasil []model.BarisLaporan
+	if lap.Periode == model.LaporanBulanan {
+		awalLalu := lap.Dari.AddDate(0, -1, 0)
+		for t := lap.Dari; t.Before(lap.Sampai); t = t.AddDate(0, 0, 1) {
+			baris := model.BarisLaporan{Label: fmt.Sprintf("%d", t.Day()), Jumlah: kini[t.Format("2006-01-02")]}
+			// Tanggal 31 tidak punya pembanding bila bulan sebelumnya lebih pendek
+			if t.Day() <= awalLalu.AddDate(0, 1, -1).Day() {
+				baris.Pembanding = lalu[awalLalu.AddDate(0, 0, t.Day()-1).Format("2006-01-02")]
+			}
+			hasil = append(hasil, baris)
+		}
+		return hasil
+	}
+
+	perBulan := func(perTanggal map[string]int) map[string]int {
+		m := map[string]int{}
+		for tanggal, n := range perTanggal {
+			if len(tanggal) >= 7 {
+				m[tanggal[5:7]] += n
+			}
+		}
+		return m
+	}
+	kiniBulan, laluBulan := perBulan(kini), perBulan(lalu)
+	for b := 1; b <= 12; b++ {
+		kunci := fmt.Sprintf("%02d", b)
+		hasil = append(hasil, model.BarisLaporan{Label: namaBulan[b], Jumlah: kiniBulan[kunci], Pembanding: laluBulan[kunci]})
+	}
+	return hasil
+}
+
+// gabungRekap menyatukan hitungan periode laporan dan pembanding, diurutkan dari jumlah terbanyak.
+// Bila batas > 0 hanya batas baris teratas periode laporan yang diambil.
+func gabungRekap(kini, lalu map[string]int, batas int) []model.BarisLaporan {
+	var hasil []model.BarisLaporan
+	for label, n := range kini {
+		hasil = append(hasil, model.BarisLaporan{Label: label, Jumlah: n, Pembanding: lalu[label]})
+	}
+	if batas == 0 {
+		for label, n := range lalu {
+			if _, ok := kini[label]; !ok {
+				hasil = append(hasil, model.BarisLaporan{Label: label, Pembanding: n})
+			}
+		}
+	}
+	sort.Slice(hasil, func(i, j int) bool {
+		if hasil[i].Jumlah != hasil[j].Jumlah {
+			return hasil[i].Jumlah > hasil[j].Jumlah
+		}
+		if hasil[i].Pembanding != hasil[j].Pembanding {
+			return hasil[i].Pembanding > hasil[j].Pembanding
+		}
+		return hasil[i].Label < hasil[j].Label
+	})
+	if batas > 0 && len(hasil) > batas {
+		hasil = hasil[:batas]
+	}
+	return hasil
+}
+
+func jumlahSemua(m map[string]int) int {
+	total := 0
+	for _, n := range m {
+		total += n
+	}
+	return total
+}
+
+// TulisXLSX menulis laporan sebagai workbook dengan satu sheet per bagian
+func (s *LaporanService) TulisXLSX(w io.Writer, lap *model.Laporan, p *model.Pengaturan) error {
+	x := xlsx.NewWriter(w)
+	for i, b := range lap.Bagian() {
+		if err := x.Sheet(b.Judul, 35, 18, 18, 12); err != nil {
+			return err
+		}
+		if i == 0 {
+			if err := x.Judul("LAPORAN SURAT KETERANGAN HILANG " + lap.Judul); err != nil {
+				return err
+			}
+			if err := x.Baris(p.NamaKantor); err != nil {
+				return err
+			}
+			if err := x.Baris(); err != nil {
+				return err
+			}
+		}
+		if err := x.Judul(b.Kolom, lap.Judul, lap.JudulPembanding, "Selisih"); err != nil {
+			return err
+		}
+		for _, baris := range b.Baris {
+			if err := x.Baris(baris.Label, baris.Jumlah, baris.Pembanding, baris.Selisih()); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := x.Sheet("Pembatalan", 5, 30, 18, 28, 18, 22, 45); err != nil {
+		return err
+	}
+	if err := x.Judul("No", "Nomor Surat", "Tanggal Surat", "Nama Pelapor", "Tanggal Batal", "Dibatalkan Oleh", "Alasan"); err != nil {
+		return err
+	}
+	for i, surat := range lap.Pembatalan {
+		if err := x.Baris(i+1, surat.NomorSurat, FormatTanggalIndo(surat.TanggalSurat), surat.PelaporNama,
+			FormatTanggalIndo(surat.DibatalkanPada), surat.DibatalkanOleh, surat.AlasanBatal); err != nil {
+			return err
+		}
+	}
+	return x.Close()
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"fmt"
+	"skh_app/internal/model"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Token yang dikenali di format nomor surat:
+//
+//	{NO}          nomor urut, 3 digit (001)
+//	{NO:n}        nomor urut dengan n digit, mis. {NO:4} -> 0001
+//	{THN}         tahun 4 digit (2025)
+//	{THN2}        tahun 2 digit (25)
+//	{BLN}         bulan 2 digit (08)
+//	{BLN_ROMAWI}  bulan dalam angka romawi (VIII)
+//	{TGL}         tanggal 2 digit (07)
+//	{KODE}        kode kantor dari pengaturan
+const (
+	defaultNomorDigit = 3
+	maxNomorDigit     = 10
+)
+
+// renderFormatNomor mengganti semua token di format dan mengembalikan error untuk token yang tidak dikenal
+func renderFormatNomor(format string, nomor int, t time.Time, kodeKantor string) (string, error) {
+	var sb strings.Builder
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		if c == '}' {
+			return "", fmt.Errorf("kurung kurawal '}' tanpa pasangan di posisi %d", i+1)
+		}
+		if c != '{' {
+			sb.WriteByte(c)
+			continue
+		}
+
+		end := strings.IndexByte(format[i:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("token di posisi %d tidak ditutup dengan '}'", i+1)
+		}
+		token := format[i+1 : i+end]
+		i += end
+
+		switch {
+		case token == "NO":
+			sb.WriteString(fmt.Sprintf("%0*d", defaultNomorDigit, nomor))
+		case strings.HasPrefix(token, "NO:"):
+			digit, err := strconv.Atoi(strings.TrimPrefix(token, "NO:"))
+			if err != nil || digit < 1 || digit > maxNomorDigit {
+				return "", fmt.Errorf("jumlah digit pada {%s} harus antara 1 dan %d", token, maxNomorDigit)
+			}
+			sb.WriteString(fmt.Sprintf("%0*d", digit, nomor))
+		case token == "THN":
+			sb.WriteString(fmt.Sprintf("%04d", t.Year()))
+		case token == "THN2":
+			sb.WriteString(fmt.Sprintf("%02d", t.Year()%100))
+		case token == "BLN":
+			sb.WriteString(fmt.Sprintf("%02d", int(t.Month())))
+		case token == "BLN_ROMAWI":
+			sb.WriteString(toRoman(int(t.Month())))
+		case token == "TGL":
+			sb.WriteString(fmt.Sprintf("%02d", t.Day()))
+		case token == "KODE":
+			sb.WriteString(kodeKantor)
+		default:
+			return "", fmt.Errorf("token {%s} tidak dikenal", token)
+		}
+	}
+	return sb.String(), nil
+}
+
+// ValidateFormatNomor memeriksa format nomor surat sebelum disimpan ke pengaturan
+func ValidateFormatNomor(format, resetNomor, kodeKantor string) error {
+	if strings.TrimSpace(format) == "" {
+		return fmt.Errorf("format nomor surat wajib diisi")
+	}
+	if _, err := renderFormatNomor(format, 1, time.Now(), kodeKantor); err != nil {
+		return fmt.Errorf("format nomor surat tidak valid: %w", err)
+	}
+	if !strings.Contains(format, "{NO}") && !strings.Contains(format, "{NO:") {
+		return fmt.Errorf("format nomor surat wajib memuat {NO} atau {NO:n}")
+	}
+	// Tanpa tahun/bulan, nomor yang sama akan terulang setelah penghitung direset
+	if !strings.Contains(format, "{THN}") && !strings.Contains(format, "{THN2}") {
+		return fmt.Errorf("format nomor surat wajib memuat {THN} atau {THN2}")
+	}
+	if resetNomor == model.ResetBulanan && !strings.Contains(format, "{BLN}") && !strings.Contains(format, "{BLN_ROMAWI}") {
+		return fmt.Errorf("reset bulanan membutuhkan {BLN} atau {BLN_ROMAWI} di format nomor surat")
+	}
+	if strings.Contains(format, "{KODE}") && strings.TrimSpace(kodeKantor) == "" {
+		return fmt.Errorf("format memakai {KODE} tetapi kode kantor belum diisi")
+	}
+	return nil
+}
+
+// generateNomorSurat menyusun nomor surat lengkap. Aturan ValidateFormatNomor hanya ditegakkan saat
+// pengaturan disimpan; di sini hanya format yang tidak bisa disusun yang ditolak.
+func generateNomorSurat(format string, nomor int, t time.Time, kodeKantor string) (string, error) {
+	if strings.TrimSpace(format) == "" {
+		return "", fmt.Errorf("format nomor surat belum diisi di pengaturan")
+	}
+	hasil, err := renderFormatNomor(format, nomor, t, kodeKantor)
+	if err != nil {
+		return "", fmt.Errorf("format nomor surat tidak valid: %w", err)
+	}
+	return hasil, nil
+}
+
+func toRoman(num int) string {
+	romans := map[int]string{
+		1: "I", 2: "II", 3: "III", 4: "IV", 5: "V", 6: "VI",
+		7: "VII", 8: "VIII", 9: "IX", 10: "X", 11: "XI", 12: "XII",
+	}
+	return romans[num]
+}
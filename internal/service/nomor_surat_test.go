@@ -0,0 +1,85 @@
+package service
+
+import (
+	"skh_app/internal/model"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderFormatNomor(t *testing.T) {
+	tanggal := time.Date(2025, time.August, 7, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		format string
+		nomor  int
+		kode   string
+		want   string
+		galat  string // potongan pesan error; kosong bila format harus berhasil
+	}{
+		{format: "SKH/{NO}/{BLN_ROMAWI}/{THN}", nomor: 7, want: "SKH/007/VIII/2025"},
+		{format: "{NO:5}-{THN2}", nomor: 42, want: "00042-25"},
+		{format: "{NO:1}", nomor: 1234, want: "1234"},
+		{format: "{TGL}.{BLN}.{THN}/{NO}", nomor: 1, want: "07.08.2025/001"},
+		{format: "SKH/{NO}/{KODE}/{THN}", nomor: 3, kode: "RES-MKS", want: "SKH/003/RES-MKS/2025"},
+		{format: "{KODE}{NO}", nomor: 3, want: "003"},
+		{format: "tanpa token", nomor: 1, want: "tanpa token"},
+		{format: "", nomor: 1, want: ""},
+		{format: "SKH/{NO}/{TAHUN}", nomor: 1, galat: "token {TAHUN} tidak dikenal"},
+		{format: "SKH/{no}", nomor: 1, galat: "token {no} tidak dikenal"},
+		{format: "SKH/{NO", nomor: 1, galat: "tidak ditutup"},
+		{format: "SKH/NO}", nomor: 1, galat: "tanpa pasangan di posisi 7"},
+		{format: "{NO:0}", nomor: 1, galat: "harus antara 1 dan 10"},
+		{format: "{NO:11}", nomor: 1, galat: "harus antara 1 dan 10"},
+		{format: "{NO:x}", nomor: 1, galat: "harus antara 1 dan 10"},
+	}
+	for _, tt := range tests {
+		got, err := renderFormatNomor(tt.format, tt.nomor, tanggal, tt.kode)
+		if tt.galat != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.galat) {
+				t.Errorf("renderFormatNomor(%q) error = %v, seharusnya memuat %q", tt.format, err, tt.galat)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("renderFormatNomor(%q) error: %v", tt.format, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("renderFormatNomor(%q) = %q, seharusnya %q", tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestValidateFormatNomor(t *testing.T) {
+	tests := []struct {
+		format string
+		reset  string
+		kode   string
+		galat  string // potongan pesan error; kosong bila format harus diterima
+	}{
+		{format: "SKH/{NO}/{BLN_ROMAWI}/{THN}", reset: model.ResetTahunan},
+		{format: "SKH/{NO:4}/{THN2}", reset: model.ResetTahunan},
+		{format: "SKH/{NO}/{BLN}/{THN}", reset: model.ResetBulanan},
+		{format: "SKH/{NO}/{BLN_ROMAWI}/{THN2}", reset: model.ResetBulanan},
+		{format: "SKH/{NO}/{KODE}/{THN}", reset: model.ResetTahunan, kode: "RES-MKS"},
+		{format: "", reset: model.ResetTahunan, galat: "wajib diisi"},
+		{format: "   ", reset: model.ResetTahunan, galat: "wajib diisi"},
+		{format: "SKH/{NO}/{TAHUN}", reset: model.ResetTahunan, galat: "tidak valid"},
+		{format: "SKH/{BLN}/{THN}", reset: model.ResetTahunan, galat: "wajib memuat {NO}"},
+		{format: "SKH/{NO}/{BLN}", reset: model.ResetTahunan, galat: "wajib memuat {THN}"},
+		{format: "SKH/{NO}/{THN}", reset: model.ResetBulanan, galat: "reset bulanan"},
+		{format: "SKH/{NO}/{KODE}/{THN}", reset: model.ResetTahunan, kode: " ", galat: "kode kantor belum diisi"},
+	}
+	for _, tt := range tests {
+		err := ValidateFormatNomor(tt.format, tt.reset, tt.kode)
+		if tt.galat == "" {
+			if err != nil {
+				t.Errorf("ValidateFormatNomor(%q, %q) error: %v", tt.format, tt.reset, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.galat) {
+			t.Errorf("ValidateFormatNomor(%q, %q) error = %v, seharusnya memuat %q", tt.format, tt.reset, err, tt.galat)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"skh_app/internal/model"
+	"strings"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// RenderLaporan menulis laporan rekap ke w sebagai PDF A4 dengan kop surat dari pengaturan
+// dan tanda tangan pejabat penandatangan.
+func (s *PDFService) RenderLaporan(w io.Writer, lap *model.Laporan, p *model.Pengaturan, dicetak string) error {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetAutoPageBreak(true, pdfMargin+5)
+	pdf.SetTitle("Laporan Surat Keterangan Hilang "+lap.Judul, true)
+	pdf.AddUTF8FontFromBytes(pdfFont, "", s.fontRegular)
+	pdf.AddUTF8FontFromBytes(pdfFont, "B", s.fontBold)
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-pdfMargin - 3)
+		pdf.SetFont(pdfFont, "", pdfFontSize-2)
+		pdf.CellFormat(0, pdfLineHeight, fmt.Sprintf("Laporan %s - halaman %d", lap.Judul, pdf.PageNo()), "", 0, "R", false, 0, "")
+	})
+	pdf.AddPage()
+
+	pageW, _ := pdf.GetPageSize()
+	lebar := pageW - 2*pdfMargin
+	drawKop(pdf, p, lebar)
+	if p.LogoPath != "" {
+		if err := s.drawLogo(pdf, p.LogoPath, pageW); err != nil {
+			return err
+		}
+	}
+
+	judul := "LAPORAN SURAT KETERANGAN HILANG"
+	pdf.SetFont(pdfFont, "B", pdfFontSize+1)
+	pdf.CellFormat(lebar, pdfLineHeight+1, judul, "", 1, "C", false, 0, "")
+	judulW := pdf.GetStringWidth(judul)
+	pdf.Line((pageW-judulW)/2, pdf.GetY(), (pageW+judulW)/2, pdf.GetY())
+	pdf.SetFont(pdfFont, "", pdfFontSize)
+	pdf.CellFormat(lebar, pdfLineHeight, "Periode: "+strings.ToUpper(lap.Judul), "", 1, "C", false, 0, "")
+	pdf.CellFormat(lebar, pdfLineHeight, "Pembanding: "+lap.JudulPembanding, "", 1, "C", false, 0, "")
+	pdf.Ln(4)
+
+	bagian := lap.Bagian()
+	for i, b := range bagian {
+		tabelLaporan(pdf, lebar, fmt.Sprintf("%s. %s", angkaRomawi[i], b.Judul), b.Kolom, lap, b.Baris)
+	}
+
+	// Daftar pembatalan
+	judulBagian(pdf, lebar, angkaRomawi[len(bagian)]+". Pembatalan Surat", 2)
+	if len(lap.Pembatalan) == 0 {
+		pdf.CellFormat(lebar, pdfLineHeight, "Tidak ada surat yang dibatalkan pada periode ini.", "", 1, "L", false, 0, "")
+	} else {
+		kolom := []float64{8, 58, 32, 46, lebar - 144}
+		pdf.SetFont(pdfFont, "B", pdfFontSize-1)
+		for i, j := range []string{"No", "Nomor Surat", "Tgl. Batal", "Pelapor", "Alasan"} {
+			pdf.CellFormat(kolom[i], pdfLineHeight+1, j, "1", 0, "C", false, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetFont(pdfFont, "", pdfFontSize-1.5)
+		for i, surat := range lap.Pembatalan {
+			sel := []string{fmt.Sprintf("%d", i+1), surat.NomorSurat, FormatTanggalIndo(surat.DibatalkanPada), surat.PelaporNama, surat.AlasanBatal}
+			barisTabel(pdf, kolom, sel)
+		}
+		pdf.SetFontSize(pdfFontSize)
+	}
+	pdf.Ln(6)
+
+	// Tanda tangan pejabat di kanan
+	pejabat := p.PejabatDetails
+	if pejabat == nil {
+		pejabat = &model.Petugas{}
+	}
+	ttdW := lebar * 0.45
+	ttdX := pdfMargin + lebar - ttdW
+	_, pageH := pdf.GetPageSize()
+	if pdf.GetY()+45 > pageH-pdfMargin-5 {
+		pdf.AddPage()
+	}
+	pdf.SetFontSize(pdfFontSize - 0.5)
+	pdf.SetX(ttdX)
+	pdf.CellFormat(ttdW, pdfLineHeight, p.Wilayah+", "+dicetak, "", 1, "C", false, 0, "")
+	blokTandaTangan(pdf, ttdX, pdf.GetY(), ttdW, "a.n. KEPALA KEPOLISIAN "+strings.ToUpper(p.KopSurat3), pejabat)
+
+	return pdf.Output(w)
+}
+
+// angkaRomawi menomori bagian laporan
+var angkaRomawi = []string{"I", "II", "III", "IV", "V", "VI", "VII", "VIII", "IX", "X"}
+
+// judulBagian menulis judul bagian laporan dan pindah halaman bila sisa halaman tidak cukup
+// untuk judul beserta minBaris baris pertama tabelnya
+func judulBagian(pdf *gofpdf.Fpdf, lebar float64, judul string, minBaris int) {
+	_, pageH := pdf.GetPageSize()
+	if pdf.GetY()+float64(minBaris+2)*(pdfLineHeight+1) > pageH-pdfMargin-5 {
+		pdf.AddPage()
+	}
+	pdf.SetFont(pdfFont, "B", pdfFontSize)
+	pdf.CellFormat(lebar, pdfLineHeight+1, judul, "", 1, "L", false, 0, "")
+	pdf.SetFont(pdfFont, "", pdfFontSize)
+}
+
+// tabelLaporan menulis satu tabel rekap: label, jumlah periode laporan, pembanding dan selisih
+func tabelLaporan(pdf *gofpdf.Fpdf, lebar float64, judul, kolomLabel string, lap *model.Laporan, baris []model.BarisLaporan) {
+	judulBagian(pdf, lebar, judul, 3)
+	angkaW := 32.0
+	kolom := []float64{lebar - 3*angkaW, angkaW, angkaW, angkaW}
+
+	pdf.SetFont(pdfFont, "B", pdfFontSize-1)
+	for i, j := range []string{kolomLabel, lap.Judul, lap.JudulPembanding, "Selisih"} {
+		pdf.CellFormat(kolom[i], pdfLineHeight+1, j, "1", 0, "C", false, 0, "")
+	}
+	pdf.Ln(-1)
+	pdf.SetFont(pdfFont, "", pdfFontSize-1)
+	if len(baris) == 0 {
+		pdf.CellFormat(lebar, pdfLineHeight+1, "Tidak ada data", "1", 1, "C", false, 0, "")
+	}
+	for _, b := range baris {
+		pdf.CellFormat(kolom[0], pdfLineHeight+1, b.Label, "1", 0, "L", false, 0, "")
+		pdf.CellFormat(kolom[1], pdfLineHeight+1, fmt.Sprintf("%d", b.Jumlah), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(kolom[2], pdfLineHeight+1, fmt.Sprintf("%d", b.Pembanding), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(kolom[3], pdfLineHeight+1, fmt.Sprintf("%+d", b.Selisih()), "1", 1, "R", false, 0, "")
+	}
+	pdf.SetFontSize(pdfFontSize)
+	pdf.Ln(4)
+}
+
+// barisTabel menulis satu baris tabel yang selnya boleh lebih dari satu baris teks
+func barisTabel(pdf *gofpdf.Fpdf, kolom []float64, sel []string) {
+	tinggi := 0.0
+	for i, teks := range sel {
+		if n := float64(len(pdf.SplitText(teks, kolom[i]-2))); n*pdfLineHeight > tinggi {
+			tinggi = n * pdfLineHeight
+		}
+	}
+	if tinggi == 0 {
+		tinggi = pdfLineHeight
+	}
+	_, pageH := pdf.GetPageSize()
+	if pdf.GetY()+tinggi > pageH-pdfMargin-5 {
+		pdf.AddPage()
+	}
+	x, y := pdf.GetXY()
+	for i, teks := range sel {
+		pdf.Rect(x, y, kolom[i], tinggi, "D")
+		pdf.SetXY(x, y)
+		pdf.MultiCell(kolom[i], pdfLineHeight, teks, "", "L", false)
+		x += kolom[i]
+	}
+	pdf.SetXY(pdfMargin, y+tinggi)
+}
@@ -0,0 +1,331 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"skh_app/internal/model"
+	"strings"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+const (
+	pdfFont       = "DejaVuMono"
+	pdfFontSize   = 10
+	pdfLineHeight = 5
+	pdfMargin     = 10
+)
+
+// PDFService menyusun surat keterangan hilang menjadi PDF ukuran legal tanpa bantuan browser
+type PDFService struct {
+	fontRegular []byte
+	fontBold    []byte
+	uploadsDir  string
+}
+
+// NewPDFService memuat font dari fonts (biasanya web.Files) dan memakai uploadsDir untuk membaca logo
+func NewPDFService(fonts fs.FS, uploadsDir string) (*PDFService, error) {
+	regular, err := fs.ReadFile(fonts, "static/fonts/DejaVuSansMono.ttf")
+	if err != nil {
+		return nil, fmt.Errorf("gagal memuat font PDF: %w", err)
+	}
+	bold, err := fs.ReadFile(fonts, "static/fonts/DejaVuSansMono-Bold.ttf")
+	if err != nil {
+		return nil, fmt.Errorf("gagal memuat font PDF tebal: %w", err)
+	}
+	return &PDFService{fontRegular: regular, fontBold: bold, uploadsDir: uploadsDir}, nil
+}
+
+// RenderSurat menulis PDF surat ke w dengan tata letak yang sama seperti halaman cetak.
+// registri dipakai untuk menyusun uraian barang. qrPNG adalah gambar QR code verifikasi;
+// boleh nil jika tidak ingin dicetak. cetak adalah catatan cetakan ini; selain cetakan asli,
+// kaki halaman diberi tanda SALINAN beserta nomornya.
+func (s *PDFService) RenderSurat(w io.Writer, surat *model.SuratKeteranganHilang, p *model.Pengaturan, registri model.RegistriBarang, qrPNG []byte, cetak *model.CetakSurat) error {
+	pdf := gofpdf.New("P", "mm", "Legal", "")
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetAutoPageBreak(true, pdfMargin)
+	pdf.SetTitle("Surat Keterangan Hilang "+surat.NomorSurat, true)
+	pdf.AddUTF8FontFromBytes(pdfFont, "", s.fontRegular)
+	pdf.AddUTF8FontFromBytes(pdfFont, "B", s.fontBold)
+
+	if surat.IsDibatalkan() {
+		pdf.SetHeaderFunc(func() { drawWatermarkBatal(pdf) })
+	}
+	if cetak != nil && !cetak.IsAsli() {
+		// Ditulis di dalam margin bawah agar tidak menimpa isi surat
+		tanda := fmt.Sprintf("SALINAN ke-%d - dicetak %s oleh %s", cetak.Salinan,
+			cetak.CreatedAt.Format("02-01-2006 15:04"), cetak.DicetakOleh)
+		pdf.SetFooterFunc(func() {
+			pdf.SetY(-pdfMargin + 2)
+			pdf.SetFont(pdfFont, "B", pdfFontSize-2)
+			pdf.CellFormat(0, 4, tanda, "", 0, "C", false, 0, "")
+		})
+	}
+	pdf.AddPage()
+
+	pageW, _ := pdf.GetPageSize()
+	lebar := pageW - 2*pdfMargin
+	pejabat := &surat.Pejabat
+	penerima := &surat.Penerima
+
+	// QR code verifikasi di kanan atas, sejajar dengan kop surat
+	if len(qrPNG) > 0 {
+		const qrW = 25
+		opt := gofpdf.ImageOptions{ImageType: "PNG"}
+		pdf.RegisterImageOptionsReader("qr_verifikasi", opt, bytes.NewReader(qrPNG))
+		if pdf.Err() {
+			return fmt.Errorf("gagal memuat QR code: %w", pdf.Error())
+		}
+		qrX := pageW - pdfMargin - qrW
+		pdf.ImageOptions("qr_verifikasi", qrX, pdfMargin, qrW, qrW, false, opt, 0, "")
+		pdf.SetFont(pdfFont, "", 6)
+		pdf.SetXY(qrX-5, pdfMargin+qrW)
+		pdf.CellFormat(qrW+10, 3, "Pindai untuk verifikasi", "", 0, "C", false, 0, "")
+		pdf.SetXY(pdfMargin, pdfMargin)
+	}
+
+	drawKop(pdf, p, lebar)
+
+	// Logo, judul dan nomor
+	if p.LogoPath != "" {
+		if err := s.drawLogo(pdf, p.LogoPath, pageW); err != nil {
+			return err
+		}
+	}
+	pdf.SetFont(pdfFont, "B", pdfFontSize+1)
+	pdf.CellFormat(lebar, pdfLineHeight+1, "SURAT KETERANGAN HILANG", "", 1, "C", false, 0, "")
+	judulW := pdf.GetStringWidth("SURAT KETERANGAN HILANG")
+	pdf.Line((pageW-judulW)/2, pdf.GetY(), (pageW+judulW)/2, pdf.GetY())
+	pdf.SetFont(pdfFont, "", pdfFontSize)
+	pdf.CellFormat(lebar, pdfLineHeight, "Nomor: "+surat.NomorSurat, "", 1, "C", false, 0, "")
+	if surat.Revisi > 1 {
+		pdf.SetFontSize(pdfFontSize - 1)
+		pdf.CellFormat(lebar, pdfLineHeight, fmt.Sprintf("Revisi ke-%d", surat.Revisi), "", 1, "C", false, 0, "")
+		pdf.SetFontSize(pdfFontSize)
+	}
+	if surat.IsPerpanjangan() {
+		pdf.SetFontSize(pdfFontSize - 1)
+		pdf.CellFormat(lebar, pdfLineHeight, "Perpanjangan dari Surat Nomor: "+surat.SuratAsalNomor, "", 1, "C", false, 0, "")
+		pdf.SetFontSize(pdfFontSize)
+	}
+	pdf.Ln(4)
+
+	paragrafStrip(pdf, lebar, "---- Yang bertanda tangan dibawah ini a.n KEPALA KEPOLISIAN "+p.KopSurat3+", menerangkan dengan benar bahwa:")
+	pdf.Ln(3)
+
+	// Identitas pelapor
+	pelapor := [][2]string{
+		{"Nama", strings.ToUpper(surat.PelaporNama)},
+		{"TTL", surat.PelaporTTL},
+		{"Agama", surat.PelaporAgama},
+		{"Jenis kelamin", surat.PelaporKelamin},
+		{"Pekerjaan", surat.PelaporPekerjaan},
+		{"Alamat", surat.PelaporAlamat},
+	}
+	for _, baris := range pelapor {
+		pdf.SetX(pdfMargin + 6)
+		pdf.CellFormat(36, pdfLineHeight, baris[0], "", 0, "L", false, 0, "")
+		pdf.CellFormat(4, pdfLineHeight, ":", "", 0, "L", false, 0, "")
+		if baris[0] == "Nama" {
+			pdf.SetFont(pdfFont, "B", pdfFontSize)
+		}
+		pdf.MultiCell(lebar-46, pdfLineHeight, baris[1], "", "L", false)
+		pdf.SetFont(pdfFont, "", pdfFontSize)
+	}
+	pdf.Ln(3)
+
+	paragrafStrip(pdf, lebar, "Yang bersangkutan tersebut di atas benar telah datang di "+p.NamaKantor+" dan melaporkan bahwa telah kehilangan surat berharga berupa:")
+	pdf.Ln(2)
+
+	// Daftar barang hilang
+	for i, b := range surat.BarangHilang {
+		pdf.SetX(pdfMargin + 6)
+		pdf.CellFormat(8, pdfLineHeight, fmt.Sprintf("%d.", i+1), "", 0, "L", false, 0, "")
+		pdf.SetFont(pdfFont, "B", pdfFontSize)
+		jenisW := pdf.GetStringWidth(b.JenisBarang+", ") + 1
+		pdf.CellFormat(jenisW, pdfLineHeight, b.JenisBarang+",", "", 0, "L", false, 0, "")
+		pdf.SetFont(pdfFont, "", pdfFontSize)
+		pdf.MultiCell(lebar-14-jenisW, pdfLineHeight, registri.Keterangan(b), "", "L", false)
+	}
+	pdf.Ln(3)
+
+	paragrafStrip(pdf, lebar, "---- Surat/kartu tersebut hilang di sekitar "+surat.LokasiHilang+", dan sudah dilakukan pencarian namun sampai dikeluarkan Surat Keterangan ini belum ditemukan.")
+	pdf.Ln(3)
+
+	// Tanda tangan pelapor di sepertiga kanan
+	kolomW := lebar / 3
+	kananX := pdfMargin + lebar - kolomW
+	pdf.SetX(kananX)
+	pdf.CellFormat(kolomW, pdfLineHeight, "Yang Bermohon", "", 1, "C", false, 0, "")
+	pdf.Ln(12)
+	pdf.SetX(kananX)
+	pdf.SetFont(pdfFont, "B", pdfFontSize)
+	pdf.CellFormat(kolomW, pdfLineHeight, strings.ToUpper(surat.PelaporNama), "", 1, "C", false, 0, "")
+	garisBawahTengah(pdf, kananX, kolomW, strings.ToUpper(surat.PelaporNama))
+	pdf.SetFont(pdfFont, "", pdfFontSize)
+	pdf.Ln(6)
+
+	paragrafStrip(pdf, lebar, "---- Demikian Surat Keterangan ini dibuat dengan sebenar-benarnya dan dapat dipergunakan sebagaimana perlunya.")
+	pdf.Ln(3)
+
+	pdf.SetFont(pdfFont, "B", pdfFontSize)
+	pdf.CellFormat(lebar, pdfLineHeight, "Tindakan Yang Diambil :", "", 1, "L", false, 0, "")
+	pdf.SetFont(pdfFont, "", pdfFontSize)
+	tindakan := []string{
+		"Menerima laporan dan membuat Surat Keterangan Kehilangan barang guna seperlunya;",
+		fmt.Sprintf("Surat keterangan kehilangan ini berlaku selama %d (%s) hari, berlaku mulai tanggal dikeluarkan sampai dengan tanggal %s;",
+			surat.MasaBerlaku(), Terbilang(surat.MasaBerlaku()), FormatTanggalIndo(surat.BerlakuSampai)),
+		"Surat Keterangan ini bukan sebagai pengganti surat yang hilang tetapi berguna untuk mengurus kembali surat yang hilang.",
+	}
+	for i, t := range tindakan {
+		pdf.CellFormat(6, pdfLineHeight, fmt.Sprintf("%d.", i+1), "", 0, "L", false, 0, "")
+		pdf.MultiCell(lebar-6, pdfLineHeight, t, "", "L", false)
+	}
+	pdf.Ln(4)
+
+	// Tempat dan tanggal, lalu blok tanda tangan pejabat dan penerima laporan
+	ttdW := lebar * 0.45
+	ttdKananX := pdfMargin + lebar - ttdW
+	pdf.SetFontSize(pdfFontSize - 0.5)
+	pdf.SetX(ttdKananX)
+	pdf.CellFormat(ttdW, pdfLineHeight, p.Wilayah+", "+FormatTanggalIndo(surat.TanggalSurat), "", 1, "C", false, 0, "")
+
+	// Blok tanda tangan tidak boleh terpotong ke halaman berikutnya
+	_, pageH := pdf.GetPageSize()
+	if pdf.GetY()+45 > pageH-pdfMargin {
+		pdf.AddPage()
+	}
+	atasY := pdf.GetY()
+	blokTandaTangan(pdf, pdfMargin, atasY, ttdW,
+		"a.n. KEPALA KEPOLISIAN "+strings.ToUpper(p.KopSurat3), pejabat)
+	blokTandaTangan(pdf, ttdKananX, atasY, ttdW, "Penerima Laporan", penerima)
+	pdf.SetFontSize(pdfFontSize)
+
+	if surat.IsDibatalkan() {
+		pdf.Ln(6)
+		catatan := "SURAT INI TELAH DIBATALKAN DAN TIDAK BERLAKU\n" +
+			"Tanggal pembatalan: " + FormatTanggalIndo(surat.DibatalkanPada) + "\n" +
+			"Dibatalkan oleh: " + surat.DibatalkanOleh + "\n" +
+			"Alasan: " + surat.AlasanBatal
+		pdf.SetFontSize(pdfFontSize - 1)
+		pdf.SetLineWidth(0.5)
+		pdf.MultiCell(lebar, pdfLineHeight, catatan, "1", "L", false)
+		pdf.SetLineWidth(0.2)
+	}
+
+	return pdf.Output(w)
+}
+
+// drawKop menulis kop surat di kiri atas, lebar 35% dan bergaris bawah
+func drawKop(pdf *gofpdf.Fpdf, p *model.Pengaturan, lebar float64) {
+	kopW := lebar * 0.35
+	pdf.SetFont(pdfFont, "B", pdfFontSize)
+	for _, baris := range []string{p.KopSurat1, p.KopSurat2, p.KopSurat3} {
+		pdf.CellFormat(kopW, pdfLineHeight, baris, "", 2, "C", false, 0, "")
+	}
+	pdf.SetLineWidth(0.5)
+	pdf.Line(pdfMargin, pdf.GetY()+1, pdfMargin+kopW, pdf.GetY()+1)
+	pdf.SetLineWidth(0.2)
+	pdf.Ln(5)
+}
+
+// drawLogo menggambar logo di tengah halaman. LogoPath berbentuk /static/uploads/<nama file>.
+// Logo selalu di-encode ulang ke PNG karena gofpdf tidak mendukung semua varian PNG/JPEG (mis. interlaced).
+func (s *PDFService) drawLogo(pdf *gofpdf.Fpdf, logoPath string, pageW float64) error {
+	nama := filepath.Base(logoPath)
+	f, err := os.Open(filepath.Join(s.uploadsDir, nama))
+	if err != nil {
+		// Logo yang hilang dari disk tidak menggagalkan pembuatan surat
+		return nil
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil
+	}
+	// NRGBA memastikan PNG 8-bit; png.Encode bisa menghasilkan 16-bit yang ditolak gofpdf
+	rgba := image.NewNRGBA(img.Bounds())
+	draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, rgba); err != nil {
+		return fmt.Errorf("gagal menyiapkan logo %s: %w", nama, err)
+	}
+
+	opt := gofpdf.ImageOptions{ImageType: "PNG"}
+	pdf.RegisterImageOptionsReader(nama, opt, &buf)
+	if pdf.Err() {
+		return fmt.Errorf("gagal memuat logo %s: %w", nama, pdf.Error())
+	}
+	const logoW, logoH = 16, 13
+	pdf.ImageOptions(nama, (pageW-logoW)/2, pdf.GetY(), logoW, logoH, true, opt, 0, "")
+	pdf.Ln(1)
+	return nil
+}
+
+// paragrafStrip menulis paragraf dan mengisi sisa baris terakhir dengan tanda strip seperti surat ketikan
+func paragrafStrip(pdf *gofpdf.Fpdf, lebar float64, teks string) {
+	baris := pdf.SplitText(teks, lebar)
+	if len(baris) == 0 {
+		return
+	}
+	akhir := len(baris) - 1
+	baris[akhir] += " "
+	for pdf.GetStringWidth(baris[akhir]+"-") < lebar-2 {
+		baris[akhir] += "-"
+	}
+	for _, b := range baris {
+		pdf.CellFormat(lebar, pdfLineHeight, b, "", 1, "L", false, 0, "")
+	}
+}
+
+// garisBawahTengah menggarisbawahi teks yang dicetak rata tengah pada kolom x..x+w di baris sebelumnya
+func garisBawahTengah(pdf *gofpdf.Fpdf, x, w float64, teks string) {
+	tw := pdf.GetStringWidth(teks)
+	y := pdf.GetY() - 0.5
+	pdf.Line(x+(w-tw)/2, y, x+(w+tw)/2, y)
+}
+
+// blokTandaTangan menggambar satu kolom tanda tangan mulai dari (x, y)
+func blokTandaTangan(pdf *gofpdf.Fpdf, x, y, w float64, judul string, petugas *model.Petugas) {
+	pdf.SetXY(x, y)
+	pdf.MultiCell(w, pdfLineHeight, judul, "", "C", false)
+	pdf.SetX(x)
+	pdf.MultiCell(w, pdfLineHeight, strings.ToUpper(petugas.Jabatan), "", "C", false)
+	pdf.Ln(20)
+
+	nama := strings.ToUpper(petugas.Nama)
+	pdf.SetX(x)
+	pdf.SetFont(pdfFont, "B", pdfFontSize-0.5)
+	pdf.CellFormat(w, pdfLineHeight, nama, "", 1, "C", false, 0, "")
+	garisBawahTengah(pdf, x, w, nama)
+	pdf.SetFont(pdfFont, "", pdfFontSize-0.5)
+	pdf.SetX(x)
+	pdf.CellFormat(w, pdfLineHeight, petugas.Pangkat+" NRP "+petugas.NRP, "", 1, "C", false, 0, "")
+}
+
+// drawWatermarkBatal menulis tanda DIBATALKAN miring dan transparan di tengah halaman
+func drawWatermarkBatal(pdf *gofpdf.Fpdf) {
+	pageW, pageH := pdf.GetPageSize()
+	pdf.TransformBegin()
+	pdf.SetAlpha(0.25, "Normal")
+	pdf.SetTextColor(220, 38, 38)
+	pdf.SetFont(pdfFont, "B", 72)
+	pdf.TransformRotate(35, pageW/2, pageH/2)
+	teks := "DIBATALKAN"
+	tw := pdf.GetStringWidth(teks)
+	pdf.Text((pageW-tw)/2, pageH/2+8, teks)
+	pdf.TransformEnd()
+	pdf.SetAlpha(1, "Normal")
+	pdf.SetTextColor(0, 0, 0)
+	pdf.SetFont(pdfFont, "", pdfFontSize)
+}
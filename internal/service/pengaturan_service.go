@@ -7,33 +7,91 @@ import (
 	"os"
 	"path/filepath"
 	"skh_app/internal/model"
+	"strings"
 	"time"
 )
 
 // PengaturanRepositoryInterface mendefinisikan fungsi yang dibutuhkan dari database
 type PengaturanRepositoryInterface interface {
-	GetPengaturan() (*model.Pengaturan, error)
-	UpdatePengaturan(*model.Pengaturan) error
+	GetPengaturan(kantorID int) (*model.Pengaturan, error)
+	UpdatePengaturan(p *model.Pengaturan, actor string) error
+	GetNomorCounter(kantorID int, periode string) (int, error)
+	GetDaftarKantor() ([]model.Kantor, error)
+	CreateKantor(p *model.Pengaturan, actor string) error
+	GetPetugasByID(id int) (*model.Petugas, error)
 }
 
 // PengaturanService menangani logika bisnis untuk pengaturan
 type PengaturanService struct {
-	repo PengaturanRepositoryInterface
+	repo       PengaturanRepositoryInterface
+	loc        *time.Location
+	uploadsDir string
 }
 
-// NewPengaturanService adalah constructor untuk service pengaturan
-func NewPengaturanService(repo PengaturanRepositoryInterface) *PengaturanService {
-	return &PengaturanService{repo: repo}
+// NewPengaturanService adalah constructor untuk service pengaturan. Logo yang diunggah disimpan di uploadsDir.
+func NewPengaturanService(repo PengaturanRepositoryInterface, loc *time.Location, uploadsDir string) *PengaturanService {
+	return &PengaturanService{repo: repo, loc: loc, uploadsDir: uploadsDir}
+}
+
+// NomorTerakhir mengembalikan nomor urut terakhir kantor yang terpakai pada periode berjalan
+func (s *PengaturanService) NomorTerakhir(p *model.Pengaturan) (int, error) {
+	return s.repo.GetNomorCounter(p.ID, model.NomorPeriode(p.ResetNomor, time.Now().In(s.loc)))
+}
+
+// PreviewNomor memvalidasi format dan mengembalikan contoh nomor surat berikutnya untuk kantor kantorID
+func (s *PengaturanService) PreviewNomor(kantorID int, format, resetNomor, kodeKantor string) (string, error) {
+	if err := ValidateFormatNomor(format, resetNomor, kodeKantor); err != nil {
+		return "", err
+	}
+	now := time.Now().In(s.loc)
+	terakhir, err := s.repo.GetNomorCounter(kantorID, model.NomorPeriode(resetNomor, now))
+	if err != nil {
+		return "", fmt.Errorf("gagal membaca nomor terakhir: %w", err)
+	}
+	return generateNomorSurat(format, terakhir+1, now, kodeKantor)
 }
 
 // UpdatePengaturan berisi logika untuk update data dan menyimpan file logo
-func (s *PengaturanService) UpdatePengaturan(p *model.Pengaturan, logoFile multipart.File, logoHandler *multipart.FileHeader) (*model.Pengaturan, error) {
+func (s *PengaturanService) UpdatePengaturan(p *model.Pengaturan, logoFile multipart.File, logoHandler *multipart.FileHeader, actor string) (*model.Pengaturan, error) {
+	// 0. Validasi format nomor sebelum apa pun disimpan
+	if p.ResetNomor != model.ResetBulanan {
+		p.ResetNomor = model.ResetTahunan
+	}
+	if err := ValidateFormatNomor(p.FormatNomorSurat, p.ResetNomor, p.KodeKantor); err != nil {
+		return nil, err
+	}
+	daftar, err := s.repo.GetDaftarKantor()
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil daftar kantor: %w", err)
+	}
+	if len(daftar) > 1 {
+		if err := formatNomorBanyakKantor(p); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.cekPetugasKantor(p); err != nil {
+		return nil, err
+	}
+	p.NomorPeriode = model.NomorPeriode(p.ResetNomor, time.Now().In(s.loc))
+	if p.MasaBerlakuHari < 1 || p.MasaBerlakuHari > model.MaxMasaBerlakuHari {
+		return nil, fmt.Errorf("masa berlaku surat harus antara 1 dan %d hari", model.MaxMasaBerlakuHari)
+	}
+	if p.BatasLaporanBerulang < 0 || p.BatasLaporanBerulang > model.MaxBatasLaporanBerulang {
+		return nil, fmt.Errorf("batas laporan berulang harus antara 0 dan %d kali", model.MaxBatasLaporanBerulang)
+	}
+	if p.PeriodeLaporanBerulang < 1 || p.PeriodeLaporanBerulang > model.MaxPeriodeLaporanBerulang {
+		return nil, fmt.Errorf("periode laporan berulang harus antara 1 dan %d hari", model.MaxPeriodeLaporanBerulang)
+	}
+	if p.BatasUbahJam < 0 || p.BatasUbahJam > model.MaxBatasUbahJam {
+		return nil, fmt.Errorf("batas ubah surat harus antara 0 dan %d jam", model.MaxBatasUbahJam)
+	}
+
 	// 1. Logika penyimpanan file
 	if logoFile != nil {
 		defer logoFile.Close()
-		
+
 		newFileName := fmt.Sprintf("%d_%s", time.Now().UnixNano(), logoHandler.Filename)
-		uploadPath := filepath.Join("web", "static", "uploads", newFileName)
+		uploadPath := filepath.Join(s.uploadsDir, newFileName)
 
 		// Buat file di server
 		dst, err := os.Create(uploadPath)
@@ -46,20 +104,106 @@ func (s *PengaturanService) UpdatePengaturan(p *model.Pengaturan, logoFile multi
 		if _, err := io.Copy(dst, logoFile); err != nil {
 			return nil, fmt.Errorf("gagal menyalin file: %w", err)
 		}
-		
+
 		// Set path logo baru untuk disimpan ke database
 		p.LogoPath = "/static/uploads/" + newFileName
 	}
-	
+
 	// Jika tahun belum diset, set ke tahun sekarang
 	if p.LastNomorYear == 0 {
-		p.LastNomorYear = time.Now().Year()
+		p.LastNomorYear = time.Now().In(s.loc).Year()
 	}
-	
+
 	// 2. Panggil repository untuk menyimpan semua perubahan ke database
-	if err := s.repo.UpdatePengaturan(p); err != nil {
+	if err := s.repo.UpdatePengaturan(p, actor); err != nil {
 		return nil, fmt.Errorf("gagal menyimpan pengaturan ke database: %w", err)
 	}
 
 	return p, nil
 }
+
+// formatNomorBanyakKantor memastikan nomor surat kantor p tidak bisa sama dengan nomor kantor lain.
+// Nomor surat unik di seluruh instalasi, jadi format setiap kantor wajib memuat kode kantornya.
+func formatNomorBanyakKantor(p *model.Pengaturan) error {
+	if !strings.Contains(p.FormatNomorSurat, "{KODE}") || strings.TrimSpace(p.KodeKantor) == "" {
+		return fmt.Errorf("format nomor surat %s wajib memuat {KODE} dan kode kantor wajib diisi, agar nomor surat antar kantor tidak bentrok",
+			namaKantor(p))
+	}
+	return nil
+}
+
+func namaKantor(p *model.Pengaturan) string {
+	if p.NamaKantor != "" {
+		return p.NamaKantor
+	}
+	return fmt.Sprintf("Kantor %d", p.ID)
+}
+
+// cekPetugasKantor memastikan pejabat dan penerima bawaan kantor adalah petugas kantor itu
+// atau petugas semua kantor
+func (s *PengaturanService) cekPetugasKantor(p *model.Pengaturan) error {
+	for _, id := range []int{p.PejabatID, p.PenerimaID} {
+		if id == 0 {
+			continue
+		}
+		petugas, err := s.repo.GetPetugasByID(id)
+		if err != nil {
+			return fmt.Errorf("petugas %d tidak ditemukan", id)
+		}
+		if !petugas.BertugasDi(p.ID) {
+			return fmt.Errorf("%s bukan petugas %s", petugas.Nama, namaKantor(p))
+		}
+	}
+	return nil
+}
+
+// TambahKantor membuat kantor baru dengan nama dan kode kantor tersebut. Kop surat, logo, format
+// nomor dan aturan lain disalin dari kantor utama agar bisa langsung disesuaikan; penghitung nomor
+// kantor baru mulai dari nol. Karena nomor surat harus unik, semua kantor wajib memakai {KODE}.
+func (s *PengaturanService) TambahKantor(nama, kode, actor string) (*model.Pengaturan, error) {
+	nama, kode = strings.TrimSpace(nama), strings.TrimSpace(kode)
+	if nama == "" {
+		return nil, fmt.Errorf("nama kantor wajib diisi")
+	}
+	if kode == "" {
+		return nil, fmt.Errorf("kode kantor wajib diisi")
+	}
+	daftar, err := s.repo.GetDaftarKantor()
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil daftar kantor: %w", err)
+	}
+	for _, k := range daftar {
+		p, err := s.repo.GetPengaturan(k.ID)
+		if err != nil {
+			return nil, fmt.Errorf("gagal mengambil pengaturan %s: %w", k.Nama, err)
+		}
+		if err := formatNomorBanyakKantor(p); err != nil {
+			return nil, fmt.Errorf("ubah dulu pengaturan yang ada: %w", err)
+		}
+	}
+
+	utama, err := s.repo.GetPengaturan(model.KantorUtama)
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil pengaturan kantor utama: %w", err)
+	}
+	baru := &model.Pengaturan{
+		KopSurat1:              utama.KopSurat1,
+		KopSurat2:              utama.KopSurat2,
+		KopSurat3:              strings.ToUpper(nama),
+		LogoPath:               utama.LogoPath,
+		FormatNomorSurat:       utama.FormatNomorSurat,
+		Wilayah:                utama.Wilayah,
+		NamaKantor:             nama,
+		KodeKantor:             kode,
+		ResetNomor:             utama.ResetNomor,
+		MasaBerlakuHari:        utama.MasaBerlakuHari,
+		BatasLaporanBerulang:   utama.BatasLaporanBerulang,
+		PeriodeLaporanBerulang: utama.PeriodeLaporanBerulang,
+		BatasUbahJam:           utama.BatasUbahJam,
+		LastNomorYear:          time.Now().In(s.loc).Year(),
+	}
+	if err := s.repo.CreateKantor(baru, actor); err != nil {
+		return nil, fmt.Errorf("gagal menambah kantor: %w", err)
+	}
+	return baru, nil
+}
@@ -1,24 +1,35 @@
 package service
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"skh_app/internal/model"
+	"skh_app/internal/validasi"
 	"strings"
-	"time"
 	"sync"
+	"time"
 )
 
 // SuratRepositoryInterface mendefinisikan fungsi-fungsi database yang dibutuhkan oleh service ini.
 type SuratRepositoryInterface interface {
-	GetPengaturan() (*model.Pengaturan, error)
-	CreateSurat(surat *model.SuratKeteranganHilang, nomorBaru int, nomorSuratLengkap string, tahunSekarang int) (int64, error)
-	ResetNomorCounterIfEmpty() error
-
-	// --- TAMBAHKAN 4 METHOD DI BAWAH INI ---
-	GetTotalSurat() (int, error)
-	GetTotalSuratBulanIni() (int, error)
-	GetBarangHilangStats() ([]model.BarangStat, error)
-	GetSuratHarianStats() (map[string]int, error)
+	GetPengaturan(kantorID int) (*model.Pengaturan, error)
+	CreateSurat(surat *model.SuratKeteranganHilang, periode string, formatNomor func(nomor int) (string, error), actor string) (int64, error)
+	CancelSurat(id int, alasan, oleh string, waktu time.Time, actor string) error
+	UpdateSurat(surat *model.SuratKeteranganHilang, actor string) error
+	GetSuratByID(id int) (*model.SuratKeteranganHilang, error)
+	GetAllJenisBarang() ([]model.JenisBarang, error)
+	GetPetugasByID(id int) (*model.Petugas, error)
+	HitungLaporanPelapor(nik string, sejak time.Time) (map[string]int, error)
+	CatatCetak(c *model.CetakSurat, render func(*model.CetakSurat) error) error
+
+	// Statistik dashboard; kantorID 0 berarti semua kantor
+	GetTotalSurat(kantorID int) (int, error)
+	GetTotalSuratBulanIni(kantorID int) (int, error)
+	GetTotalSuratBerlaku(kantorID int) (int, error)
+	GetTotalSuratKedaluwarsa(kantorID int) (int, error)
+	GetBarangHilangStats(kantorID int) ([]model.BarangStat, error)
+	GetSuratHarianStats(kantorID int) (map[string]int, error)
 }
 
 // SuratService adalah service layer yang berisi logika bisnis.
@@ -27,12 +38,9 @@ type SuratService struct {
 	loc  *time.Location
 }
 
-// NewSuratService adalah constructor untuk SuratService.
-func NewSuratService(repo SuratRepositoryInterface) *SuratService {
-	loc, err := time.LoadLocation("Asia/Makassar")
-	if err != nil {
-		loc = time.Local
-	}
+// NewSuratService adalah constructor untuk SuratService. loc adalah zona waktu kantor
+// yang menentukan tanggal surat dan periode penomoran.
+func NewSuratService(repo SuratRepositoryInterface, loc *time.Location) *SuratService {
 	return &SuratService{
 		repo: repo,
 		loc:  loc,
@@ -40,43 +48,57 @@ func NewSuratService(repo SuratRepositoryInterface) *SuratService {
 }
 
 // CreateNewSurat berisi semua logika bisnis untuk membuat surat.
-func (s *SuratService) CreateNewSurat(suratData *model.SuratKeteranganHilang) (*model.SuratKeteranganHilang, error) {
-	// 1. Validasi awal
-	if suratData.PelaporNama == "" || suratData.LokasiHilang == "" {
-		return nil, fmt.Errorf("nama pelapor dan lokasi hilang wajib diisi")
+// actor adalah username yang dicatat di audit log.
+func (s *SuratService) CreateNewSurat(suratData *model.SuratKeteranganHilang, actor string) (*model.SuratKeteranganHilang, error) {
+	// 1. Validasi awal. Perpanjangan menyalin barang surat asal, jadi jenis yang kini nonaktif tetap diterima
+	var bolehNonaktif map[string]bool
+	if suratData.IsPerpanjangan() {
+		bolehNonaktif = jenisDipakai(suratData)
 	}
-
-	// 2. Periksa dan reset counter
-	if err := s.repo.ResetNomorCounterIfEmpty(); err != nil {
-		return nil, fmt.Errorf("gagal memeriksa dan mereset nomor surat: %w", err)
+	if err := s.validateSurat(suratData, bolehNonaktif); err != nil {
+		return nil, err
 	}
 
-	// 3. Ambil pengaturan
-	pengaturan, err := s.repo.GetPengaturan()
+	// 2. Ambil pengaturan kantor penerbit untuk format nomor
+	if suratData.KantorID == 0 {
+		suratData.KantorID = model.KantorUtama
+	}
+	pengaturan, err := s.repo.GetPengaturan(suratData.KantorID)
+	if errors.Is(err, sql.ErrNoRows) {
+		errs := validasi.Errors{}
+		errs.Add("kantor_id", "kantor tidak ditemukan")
+		return nil, errs
+	}
 	if err != nil {
 		return nil, fmt.Errorf("gagal mengambil pengaturan: %w", err)
 	}
 
-	// 4. Logika Bisnis Penomoran
+	// 3. Lengkapi data surat yang akan disimpan
 	tanggalSurat := time.Now().In(s.loc)
-	nomorBaru := 0
-	tahunSekarang := tanggalSurat.Year()
-
-	if tahunSekarang > pengaturan.LastNomorYear {
-		nomorBaru = 1
-	} else {
-		nomorBaru = pengaturan.LastNomorSurat + 1
+	// Format yang tidak bisa disusun ditolak sebelum nomor urut dialokasikan
+	if _, err := generateNomorSurat(pengaturan.FormatNomorSurat, 1, tanggalSurat, pengaturan.KodeKantor); err != nil {
+		return nil, fmt.Errorf("periksa pengaturan: %w", err)
 	}
-
-	// 5. Buat string nomor surat lengkap
-	nomorLengkap := generateNomorSurat(pengaturan.FormatNomorSurat, nomorBaru, tanggalSurat)
-
-	// 6. Lengkapi data surat yang akan disimpan
-	suratData.NomorSurat = nomorLengkap
 	suratData.TanggalSurat = tanggalSurat
+	suratData.BerlakuSampai = model.HitungBerlakuSampai(tanggalSurat, pengaturan.MasaBerlakuHari)
+	if err := s.salinPetugas(suratData, pengaturan); err != nil {
+		return nil, err
+	}
+	if !suratData.IsPerpanjangan() {
+		tanda, err := s.tandaBerulang(suratData, pengaturan)
+		if err != nil {
+			return nil, fmt.Errorf("gagal memeriksa laporan berulang: %w", err)
+		}
+		suratData.TandaBerulang = tanda
+	}
 
-	// 7. Panggil repository untuk menyimpan
-	suratID, err := s.repo.CreateSurat(suratData, nomorBaru, nomorLengkap, tahunSekarang)
+	// 4. Simpan. Nomor urut dialokasikan secara atomik di dalam transaksi repository,
+	// sehingga dua pembuatan surat bersamaan tidak mendapat nomor yang sama.
+	formatNomor := func(nomor int) (string, error) {
+		return generateNomorSurat(pengaturan.FormatNomorSurat, nomor, tanggalSurat, pengaturan.KodeKantor)
+	}
+	periode := model.NomorPeriode(pengaturan.ResetNomor, tanggalSurat)
+	suratID, err := s.repo.CreateSurat(suratData, periode, formatNomor, actor)
 	if err != nil {
 		return nil, fmt.Errorf("gagal menyimpan surat ke database: %w", err)
 	}
@@ -85,39 +107,212 @@ func (s *SuratService) CreateNewSurat(suratData *model.SuratKeteranganHilang) (*
 	return suratData, nil
 }
 
+// salinPetugas menyalin identitas pejabat penandatangan dan petugas penerima ke surat. Petugas yang
+// dipilih di form (petugas bertugas) dipakai bila ada, selain itu petugas dari pengaturan kantor.
+// Petugas kantor lain ditolak.
+func (s *SuratService) salinPetugas(surat *model.SuratKeteranganHilang, p *model.Pengaturan) error {
+	if surat.PejabatID == 0 {
+		surat.PejabatID = p.PejabatID
+	}
+	if surat.PenerimaID == 0 {
+		surat.PenerimaID = p.PenerimaID
+	}
+	errs := validasi.Errors{}
+	salin := func(id int, field, label string, tujuan *model.Petugas) {
+		*tujuan = model.Petugas{}
+		if id == 0 {
+			return
+		}
+		petugas, err := s.repo.GetPetugasByID(id)
+		if err != nil {
+			errs.Add(field, label+" tidak ditemukan")
+			return
+		}
+		if !petugas.BertugasDi(surat.KantorID) {
+			errs.Add(field, label+" bukan petugas kantor ini")
+			return
+		}
+		*tujuan = *petugas
+	}
+	salin(surat.PejabatID, "pejabat_id", "pejabat penandatangan", &surat.Pejabat)
+	salin(surat.PenerimaID, "penerima_id", "petugas penerima", &surat.Penerima)
+	return errs.ErrOrNil()
+}
+
+// tandaBerulang menghitung laporan kehilangan pelapor yang sama per jenis barang dalam periode
+// pengaturan, termasuk surat yang sedang dibuat. Jenis yang melewati batas dicatat sebagai tanda
+// agar petugas memeriksa kemungkinan penyalahgunaan surat kehilangan.
+func (s *SuratService) tandaBerulang(surat *model.SuratKeteranganHilang, p *model.Pengaturan) (string, error) {
+	if surat.PelaporNIK == "" || p.BatasLaporanBerulang < 1 {
+		return "", nil
+	}
+	sejak := surat.TanggalSurat.AddDate(0, 0, -p.PeriodeLaporanBerulang)
+	jumlah, err := s.repo.HitungLaporanPelapor(surat.PelaporNIK, sejak)
+	if err != nil {
+		return "", err
+	}
+	var tanda []string
+	sudah := map[string]bool{}
+	for _, b := range surat.BarangHilang {
+		if sudah[b.JenisBarang] {
+			continue
+		}
+		sudah[b.JenisBarang] = true
+		if n := jumlah[b.JenisBarang] + 1; n > p.BatasLaporanBerulang {
+			tanda = append(tanda, fmt.Sprintf("%s dilaporkan hilang %d kali dalam %d hari", b.JenisBarang, n, p.PeriodeLaporanBerulang))
+		}
+	}
+	return strings.Join(tanda, "; "), nil
+}
+
+// UpdateSurat memvalidasi lalu menyimpan perubahan data pelapor dan barang pada surat yang sudah terbit
+func (s *SuratService) UpdateSurat(surat *model.SuratKeteranganHilang, actor string) error {
+	lama, err := s.repo.GetSuratByID(surat.ID)
+	if err != nil {
+		return fmt.Errorf("surat tidak ditemukan")
+	}
+	if err := s.CekBolehUbah(lama); err != nil {
+		return err
+	}
+	surat.AlasanUbah = strings.TrimSpace(surat.AlasanUbah)
+	if err := s.validateSurat(surat, jenisDipakai(lama)); err != nil {
+		return err
+	}
+	if surat.AlasanUbah == "" {
+		return validasi.Errors{"alasan": "alasan perubahan wajib diisi"}
+	}
+	if err := s.repo.UpdateSurat(surat, actor); err != nil {
+		return fmt.Errorf("gagal mengupdate surat: %w", err)
+	}
+	return nil
+}
+
+// CekBolehUbah menolak perubahan surat yang masa ubahnya (batas_ubah_jam pada pengaturan
+// kantor penerbit) sudah lewat
+func (s *SuratService) CekBolehUbah(surat *model.SuratKeteranganHilang) error {
+	p, err := s.repo.GetPengaturan(surat.KantorID)
+	if err != nil {
+		return fmt.Errorf("gagal memuat pengaturan: %w", err)
+	}
+	return surat.CekBolehUbah(p.BatasUbahJam, time.Now().In(s.loc))
+}
+
+// CatatCetak membuat cetakan surat dalam format tertentu lewat render lalu mencatatnya atas nama actor.
+// render menerima catatan cetak beserta nomor salinannya; bila render gagal, cetakan tidak dicatat.
+// Cetakan pertama adalah surat asli; mencetak ulang wajib disertai alasan
+// (repository.ErrAlasanCetakUlang bila kosong).
+func (s *SuratService) CatatCetak(surat *model.SuratKeteranganHilang, format, alasan, actor string, render func(*model.CetakSurat) error) (*model.CetakSurat, error) {
+	c := &model.CetakSurat{
+		SuratID:     surat.ID,
+		Format:      format,
+		Revisi:      surat.Revisi,
+		Alasan:      strings.TrimSpace(alasan),
+		DicetakOleh: actor,
+		CreatedAt:   time.Now().In(s.loc),
+	}
+	if err := s.repo.CatatCetak(c, render); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// PerpanjangSurat menerbitkan surat baru (nomor dan masa berlaku baru) yang menunjuk ke surat asal.
+// Surat asal tidak diubah sama sekali.
+func (s *SuratService) PerpanjangSurat(asalID int, actor string) (*model.SuratKeteranganHilang, error) {
+	asal, err := s.repo.GetSuratByID(asalID)
+	if err != nil {
+		return nil, fmt.Errorf("surat asal tidak ditemukan")
+	}
+	if asal.IsDibatalkan() {
+		return nil, fmt.Errorf("surat yang sudah dibatalkan tidak dapat diperpanjang")
+	}
+	if asal.PerpanjanganID != 0 {
+		return nil, fmt.Errorf("surat sudah diperpanjang dengan nomor %s", asal.PerpanjanganNomor)
+	}
+
+	baru := &model.SuratKeteranganHilang{
+		PelaporNIK:       asal.PelaporNIK,
+		PelaporNama:      asal.PelaporNama,
+		PelaporTTL:       asal.PelaporTTL,
+		PelaporAgama:     asal.PelaporAgama,
+		PelaporKelamin:   asal.PelaporKelamin,
+		PelaporPekerjaan: asal.PelaporPekerjaan,
+		PelaporAlamat:    asal.PelaporAlamat,
+		LokasiHilang:     asal.LokasiHilang,
+		SuratAsalID:      asal.ID,
+		SuratAsalNomor:   asal.NomorSurat,
+		KantorID:         asal.KantorID,
+	}
+	for _, b := range asal.BarangHilang {
+		baru.BarangHilang = append(baru.BarangHilang, model.Barang{JenisBarang: b.JenisBarang, Data: b.Data})
+	}
+	return s.CreateNewSurat(baru, actor)
+}
+
+// CancelSurat membatalkan surat dengan alasan dan petugas yang membatalkan.
+// Nomor surat tetap tercatat sehingga urutan penomoran tidak berlubang.
+func (s *SuratService) CancelSurat(id int, alasan string, petugasID int, actor string) error {
+	alasan = strings.TrimSpace(alasan)
+	if alasan == "" {
+		return fmt.Errorf("alasan pembatalan wajib diisi")
+	}
+	if petugasID == 0 {
+		return fmt.Errorf("petugas yang membatalkan wajib dipilih")
+	}
+	petugas, err := s.repo.GetPetugasByID(petugasID)
+	if err != nil {
+		return fmt.Errorf("petugas yang membatalkan tidak ditemukan")
+	}
+
+	oleh := fmt.Sprintf("%s %s NRP %s", petugas.Pangkat, petugas.Nama, petugas.NRP)
+	if err := s.repo.CancelSurat(id, alasan, strings.TrimSpace(oleh), time.Now().In(s.loc), actor); err != nil {
+		return fmt.Errorf("gagal membatalkan surat: %w", err)
+	}
+	return nil
+}
+
 // GetDashboardData mengambil semua data yang diperlukan untuk dashboard dan memprosesnya.
-func (s *SuratService) GetDashboardData() (*model.DashboardData, error) {
+// kantorID 0 menggabungkan statistik semua kantor.
+func (s *SuratService) GetDashboardData(kantorID int) (*model.DashboardData, error) {
 	// 1. Panggil semua repository yang dibutuhkan.
 	// Kita bisa gunakan goroutine agar pemanggilan ke DB berjalan bersamaan untuk efisiensi.
-	var totalSurat, totalBulanIni int
+	var totalSurat, totalBulanIni, totalBerlaku, totalKedaluwarsa int
 	var barangStats []model.BarangStat
 	var harianStats map[string]int
-	var errTotal, errBulan, errBarang, errHarian error
-	
+	var errTotal, errBulan, errBerlaku, errKedaluwarsa, errBarang, errHarian error
+
 	var wg sync.WaitGroup
-	wg.Add(4)
+	wg.Add(6)
 
 	go func() {
 		defer wg.Done()
-		totalSurat, errTotal = s.repo.GetTotalSurat()
+		totalSurat, errTotal = s.repo.GetTotalSurat(kantorID)
 	}()
 	go func() {
 		defer wg.Done()
-		totalBulanIni, errBulan = s.repo.GetTotalSuratBulanIni()
+		totalBulanIni, errBulan = s.repo.GetTotalSuratBulanIni(kantorID)
 	}()
 	go func() {
 		defer wg.Done()
-		barangStats, errBarang = s.repo.GetBarangHilangStats()
+		totalBerlaku, errBerlaku = s.repo.GetTotalSuratBerlaku(kantorID)
 	}()
 	go func() {
 		defer wg.Done()
-		harianStats, errHarian = s.repo.GetSuratHarianStats()
+		totalKedaluwarsa, errKedaluwarsa = s.repo.GetTotalSuratKedaluwarsa(kantorID)
+	}()
+	go func() {
+		defer wg.Done()
+		barangStats, errBarang = s.repo.GetBarangHilangStats(kantorID)
+	}()
+	go func() {
+		defer wg.Done()
+		harianStats, errHarian = s.repo.GetSuratHarianStats(kantorID)
 	}()
 
 	wg.Wait() // Tunggu semua panggilan ke database selesai
 
 	// Cek jika ada error dari salah satu panggilan
-	if errTotal != nil || errBulan != nil || errBarang != nil || errHarian != nil {
+	if errTotal != nil || errBulan != nil || errBerlaku != nil || errKedaluwarsa != nil || errBarang != nil || errHarian != nil {
 		// Di sini Anda bisa mencatat error spesifiknya jika perlu
 		return nil, fmt.Errorf("gagal mengambil data statistik untuk dashboard")
 	}
@@ -147,33 +342,16 @@ func (s *SuratService) GetDashboardData() (*model.DashboardData, error) {
 
 	// 3. Kembalikan data dalam satu struct yang rapi dan siap pakai
 	dashboardData := &model.DashboardData{
-		TotalSurat:    totalSurat,
-		TotalBulanIni: totalBulanIni,
-		StatLabels:    statLabels,
-		StatData:      statData,
-		HarianLabels:  harianLabels,
-		HarianData:    harianData,
+		KantorID:         kantorID,
+		TotalSurat:       totalSurat,
+		TotalBulanIni:    totalBulanIni,
+		TotalBerlaku:     totalBerlaku,
+		TotalKedaluwarsa: totalKedaluwarsa,
+		StatLabels:       statLabels,
+		StatData:         statData,
+		HarianLabels:     harianLabels,
+		HarianData:       harianData,
 	}
 
 	return dashboardData, nil
 }
-
-// --- Fungsi Helper (tetap sama) ---
-func generateNomorSurat(format string, nomor int, t time.Time) string {
-	tahun := fmt.Sprintf("%d", t.Year())
-	bulanRomawi := toRoman(int(t.Month()))
-	r := strings.NewReplacer(
-		"{NO}", fmt.Sprintf("%03d", nomor),
-		"{THN}", tahun,
-		"{BLN_ROMAWI}", bulanRomawi,
-	)
-	return r.Replace(format)
-}
-
-func toRoman(num int) string {
-	romans := map[int]string{
-		1: "I", 2: "II", 3: "III", 4: "IV", 5: "V", 6: "VI",
-		7: "VII", 8: "VIII", 9: "IX", 10: "X", 11: "XI", 12: "XII",
-	}
-	return romans[num]
-}
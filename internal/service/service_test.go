@@ -0,0 +1,83 @@
+//go:build sqlite_fts5
+
+package service
+
+import (
+	"path/filepath"
+	"skh_app/internal/model"
+	"skh_app/internal/repository"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestCreateNewSuratParalel membuat ratusan surat bersamaan di database sementara yang dibuka
+// dengan DSN produksi (_txlock=immediate). Setiap surat harus mendapat nomor urut sendiri,
+// tanpa nomor yang terlewat maupun terpakai dua kali.
+func TestCreateNewSuratParalel(t *testing.T) {
+	const jumlah = 200
+
+	loc := time.UTC
+	db, err := repository.ConnectDatabase(filepath.Join(t.TempDir(), "skh.db"), loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { repository.CloseDatabase(db) })
+
+	// Nomor urut ditaruh di depan agar mudah dibaca kembali dari nomor surat
+	if _, err := db.Exec(`UPDATE pengaturan SET format_nomor_surat = '{NO:4}/{THN}' WHERE id = ?`, model.KantorUtama); err != nil {
+		t.Fatal(err)
+	}
+	svc := NewSuratService(repository.NewSuratRepository(db, loc), loc)
+
+	nomor := make([]string, jumlah)
+	errs := make([]error, jumlah)
+	var wg sync.WaitGroup
+	for i := 0; i < jumlah; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			surat, err := svc.CreateNewSurat(&model.SuratKeteranganHilang{
+				PelaporNama:  "Pelapor " + strconv.Itoa(i),
+				LokasiHilang: "Pasar Sentral",
+			}, "test")
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			nomor[i] = surat.NomorSurat
+		}(i)
+	}
+	wg.Wait()
+
+	terpakai := make(map[int]int, jumlah)
+	for i := 0; i < jumlah; i++ {
+		if errs[i] != nil {
+			t.Fatalf("surat ke-%d gagal dibuat: %v", i, errs[i])
+		}
+		urut, _, _ := strings.Cut(nomor[i], "/")
+		n, err := strconv.Atoi(urut)
+		if err != nil {
+			t.Fatalf("nomor surat %q tidak diawali nomor urut", nomor[i])
+		}
+		if j, ada := terpakai[n]; ada {
+			t.Errorf("nomor urut %d dipakai surat ke-%d dan ke-%d", n, j, i)
+		}
+		terpakai[n] = i
+	}
+	for n := 1; n <= jumlah; n++ {
+		if _, ada := terpakai[n]; !ada {
+			t.Errorf("nomor urut %d terlewat", n)
+		}
+	}
+
+	var tersimpan int
+	if err := db.QueryRow(`SELECT COUNT(DISTINCT nomor_surat) FROM surat`).Scan(&tersimpan); err != nil {
+		t.Fatal(err)
+	}
+	if tersimpan != jumlah {
+		t.Errorf("surat tersimpan = %d, seharusnya %d", tersimpan, jumlah)
+	}
+}
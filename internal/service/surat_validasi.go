@@ -0,0 +1,117 @@
+package service
+
+import (
+	"fmt"
+	"skh_app/internal/model"
+	"skh_app/internal/validasi"
+	"strings"
+	"time"
+)
+
+// validateSurat memeriksa data pelapor dan barang sebelum surat disimpan. Semua kesalahan
+// dikumpulkan per field (validasi.Errors) agar form dapat menandai setiap isian yang salah.
+func (s *SuratService) validateSurat(surat *model.SuratKeteranganHilang, bolehNonaktif map[string]bool) error {
+	errs := validasi.Errors{}
+
+	surat.PelaporNama = strings.TrimSpace(surat.PelaporNama)
+	surat.LokasiHilang = strings.TrimSpace(surat.LokasiHilang)
+	if surat.PelaporNama == "" {
+		errs.Add("pelapor_nama", "nama pelapor wajib diisi")
+	}
+	if surat.LokasiHilang == "" {
+		errs.Add("lokasi_hilang", "lokasi hilang wajib diisi")
+	}
+	switch surat.PelaporKelamin {
+	case "", model.KelaminLakiLaki, model.KelaminPerempuan:
+	default:
+		errs.Add("pelapor_kelamin", "jenis kelamin harus Laki-laki atau Perempuan")
+	}
+	lahir, adaLahir := tanggalLahirPelapor(surat.PelaporTTL, s.loc)
+	if adaLahir && lahir.After(time.Now().In(s.loc)) {
+		errs.Add("tanggal_lahir", "tanggal lahir tidak boleh setelah hari ini")
+	}
+	if surat.PelaporNIK = strings.TrimSpace(surat.PelaporNIK); surat.PelaporNIK != "" {
+		if nik, err := validasi.ParseNIK(surat.PelaporNIK); err != nil {
+			errs.Add("pelapor_nik", err.Error())
+		} else {
+			surat.PelaporNIK = nik.Nomor
+			if adaLahir && !nik.CocokTanggalLahir(lahir) {
+				errs.Add("pelapor_nik", fmt.Sprintf("tanggal lahir pada NIK (%02d-%02d-%02d) tidak sama dengan tanggal lahir pelapor",
+					nik.HariLahir, nik.BulanLahir, nik.TahunLahir))
+			}
+			if k := kelaminNIK(nik); surat.PelaporKelamin != "" && surat.PelaporKelamin != k {
+				errs.Add("pelapor_nik", fmt.Sprintf("NIK milik %s, sedangkan pelapor %s", k, surat.PelaporKelamin))
+			}
+		}
+	}
+
+	registri, err := loadRegistri(s.repo)
+	if err != nil {
+		return err
+	}
+	validateBarang(registri, surat.BarangHilang, bolehNonaktif, errs)
+
+	// Barang dengan NIK tercetak "a.n. Pelapor", jadi tanggal lahir dan jenis kelamin yang
+	// tersandi di NIK harus sama dengan data pelapor. NIK pelapor yang belum diisi diambil dari
+	// barang tersebut agar surat tetap terhubung ke data induk pelapor.
+	nikBarang := ""
+	for i, b := range surat.BarangHilang {
+		j, ok := registri[b.JenisBarang]
+		if !ok {
+			continue
+		}
+		data := b.DataMap()
+		for _, f := range j.Fields {
+			if f.Validasi != validasi.AturanNIK || data[f.Kunci] == "" {
+				continue
+			}
+			nik, err := validasi.ParseNIK(data[f.Kunci])
+			if err != nil {
+				continue // sudah dicatat oleh validateBarang
+			}
+			field := fmt.Sprintf("barang.%d", i)
+			if surat.PelaporNIK != "" && errs["pelapor_nik"] == "" && nik.Nomor != surat.PelaporNIK {
+				errs.Add(field, fmt.Sprintf("barang ke-%d (%s): %s tidak sama dengan NIK pelapor", i+1, j.Nama, f.Label))
+			}
+			if nikBarang == "" {
+				nikBarang = nik.Nomor
+			}
+			if adaLahir && !nik.CocokTanggalLahir(lahir) {
+				errs.Add(field, fmt.Sprintf("barang ke-%d (%s): tanggal lahir pada %s (%02d-%02d-%02d) tidak sama dengan tanggal lahir pelapor",
+					i+1, j.Nama, f.Label, nik.HariLahir, nik.BulanLahir, nik.TahunLahir))
+			}
+			if k := kelaminNIK(nik); surat.PelaporKelamin != "" && surat.PelaporKelamin != k {
+				errs.Add(field, fmt.Sprintf("barang ke-%d (%s): %s milik %s, sedangkan pelapor %s",
+					i+1, j.Nama, f.Label, k, surat.PelaporKelamin))
+			}
+		}
+	}
+	if surat.PelaporNIK == "" {
+		surat.PelaporNIK = nikBarang
+	}
+
+	return errs.ErrOrNil()
+}
+
+// kelaminNIK mengembalikan jenis kelamin yang tersandi di NIK
+func kelaminNIK(nik *validasi.NIK) string {
+	if nik.Perempuan {
+		return model.KelaminPerempuan
+	}
+	return model.KelaminLakiLaki
+}
+
+// tanggalLahirPelapor membaca tanggal dari PelaporTTL berbentuk "Tempat, YYYY-MM-DD"
+func tanggalLahirPelapor(ttl string, loc *time.Location) (time.Time, bool) {
+	i := strings.LastIndex(ttl, ",")
+	if i < 0 {
+		return time.Time{}, false
+	}
+	tanggal := strings.TrimSpace(ttl[i+1:])
+	for _, layout := range []string{"2006-01-02", "02-01-2006"} {
+		if t, err := time.ParseInLocation(layout, tanggal, loc); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
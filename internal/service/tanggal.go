@@ -0,0 +1,22 @@
+package service
+
+import (
+	"fmt"
+	"time"
+)
+
+var namaBulan = []string{"", "Januari", "Februari", "Maret", "April", "Mei", "Juni", "Juli", "Agustus", "September", "Oktober", "November", "Desember"}
+
+// FormatTanggalIndo mengubah waktu menjadi tanggal bahasa Indonesia, mis. "7 Agustus 2025".
+// Tanggal diambil dari zona waktu yang dibawa t; waktu dari database sudah dalam zona waktu kantor.
+func FormatTanggalIndo(t time.Time) string {
+	return fmt.Sprintf("%d %s %d", t.Day(), namaBulan[t.Month()], t.Year())
+}
+
+// NamaBulan mengembalikan nama bulan bahasa Indonesia untuk 1-12
+func NamaBulan(bulan int) string {
+	if bulan < 1 || bulan > 12 {
+		return ""
+	}
+	return namaBulan[bulan]
+}
@@ -0,0 +1,37 @@
+package service
+
+import "strings"
+
+var angkaSatuan = []string{"", "satu", "dua", "tiga", "empat", "lima", "enam", "tujuh", "delapan", "sembilan", "sepuluh", "sebelas"}
+
+// Terbilang mengubah angka menjadi kata dalam bahasa Indonesia, mis. 15 -> "lima belas"
+func Terbilang(n int) string {
+	if n == 0 {
+		return "nol"
+	}
+	if n < 0 {
+		return "minus " + Terbilang(-n)
+	}
+	return strings.TrimSpace(terbilang(n))
+}
+
+func terbilang(n int) string {
+	switch {
+	case n < 12:
+		return angkaSatuan[n]
+	case n < 20:
+		return angkaSatuan[n-10] + " belas"
+	case n < 100:
+		return angkaSatuan[n/10] + " puluh " + terbilang(n%10)
+	case n < 200:
+		return "seratus " + terbilang(n-100)
+	case n < 1000:
+		return angkaSatuan[n/100] + " ratus " + terbilang(n%100)
+	case n < 2000:
+		return "seribu " + terbilang(n-1000)
+	case n < 1000000:
+		return terbilang(n/1000) + " ribu " + terbilang(n%1000)
+	default:
+		return terbilang(n/1000000) + " juta " + terbilang(n%1000000)
+	}
+}
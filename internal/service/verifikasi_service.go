@@ -0,0 +1,152 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"skh_app/internal/model"
+	"strconv"
+	"strings"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+const (
+	verifikasiSecretName = "verifikasi_surat"
+	// verifikasiMACBytes memotong HMAC agar QR code tetap kecil; 96 bit masih jauh dari bisa ditebak
+	verifikasiMACBytes = 12
+)
+
+// VerifikasiRepositoryInterface mendefinisikan fungsi database yang dibutuhkan untuk verifikasi surat
+type VerifikasiRepositoryInterface interface {
+	GetSuratByID(id int) (*model.SuratKeteranganHilang, error)
+	GetPengaturan(kantorID int) (*model.Pengaturan, error)
+	GetOrCreateSecret(nama string, buat func() (string, error)) (string, error)
+}
+
+// VerifikasiService membuat dan memeriksa token QR code yang tercetak di surat
+type VerifikasiService struct {
+	repo   VerifikasiRepositoryInterface
+	secret []byte
+}
+
+// NewVerifikasiService memuat kunci HMAC dari database, atau membuatnya jika belum ada
+func NewVerifikasiService(repo VerifikasiRepositoryInterface) (*VerifikasiService, error) {
+	secret, err := repo.GetOrCreateSecret(verifikasiSecretName, func() (string, error) {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			return "", err
+		}
+		return hex.EncodeToString(b), nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("gagal memuat kunci verifikasi: %w", err)
+	}
+	key, err := hex.DecodeString(secret)
+	if err != nil {
+		return nil, fmt.Errorf("kunci verifikasi rusak: %w", err)
+	}
+	return &VerifikasiService{repo: repo, secret: key}, nil
+}
+
+// sign menghitung HMAC atas id dan kode verifikasi surat. Keduanya tidak pernah berubah sejak surat
+// terbit, jadi token yang sudah tercetak tetap sah meski isi surat direvisi.
+func (s *VerifikasiService) sign(surat *model.SuratKeteranganHilang) []byte {
+	mac := hmac.New(sha256.New, s.secret)
+	fmt.Fprintf(mac, "v2|%d|%s", surat.ID, surat.KodeVerifikasi)
+	return mac.Sum(nil)[:verifikasiMACBytes]
+}
+
+// signLama menghitung HMAC versi sebelum ada kode verifikasi, atas id, nomor surat, tanggal surat
+// dan nama pelapor. Hanya dipakai memeriksa QR code yang tercetak sebelum migrasi 028.
+func (s *VerifikasiService) signLama(surat *model.SuratKeteranganHilang) []byte {
+	mac := hmac.New(sha256.New, s.secret)
+	fmt.Fprintf(mac, "%d|%s|%d|%s", surat.ID, surat.NomorSurat, surat.TanggalSurat.Unix(),
+		strings.ToUpper(strings.TrimSpace(surat.PelaporNama)))
+	return mac.Sum(nil)[:verifikasiMACBytes]
+}
+
+// Token mengembalikan token verifikasi berbentuk "<id>.<hmac base64url>"
+func (s *VerifikasiService) Token(surat *model.SuratKeteranganHilang) string {
+	return strconv.Itoa(surat.ID) + "." + base64.RawURLEncoding.EncodeToString(s.sign(surat))
+}
+
+// QRCode mengubah url menjadi gambar PNG QR code berukuran size piksel
+func (s *VerifikasiService) QRCode(url string, size int) ([]byte, error) {
+	png, err := qrcode.Encode(url, qrcode.Medium, size)
+	if err != nil {
+		return nil, fmt.Errorf("gagal membuat QR code: %w", err)
+	}
+	return png, nil
+}
+
+// Verifikasi memeriksa token dan mengembalikan status surat. Token yang tidak sah diperlakukan
+// sama dengan surat yang tidak ada, agar halaman publik tidak membocorkan ID surat yang valid.
+func (s *VerifikasiService) Verifikasi(token string) (*model.HasilVerifikasi, error) {
+	tidakDitemukan := &model.HasilVerifikasi{Status: model.VerifikasiTidakDitemukan}
+
+	idStr, macStr, ok := strings.Cut(token, ".")
+	if !ok {
+		return tidakDitemukan, nil
+	}
+	id, err := strconv.Atoi(idStr)
+	if err != nil || id <= 0 {
+		return tidakDitemukan, nil
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(macStr)
+	if err != nil {
+		return tidakDitemukan, nil
+	}
+
+	surat, err := s.repo.GetSuratByID(id)
+	if err != nil {
+		return tidakDitemukan, nil
+	}
+	// Surat tanpa kode verifikasi tidak pernah mendapat token versi baru
+	sah := surat.KodeVerifikasi != "" && hmac.Equal(mac, s.sign(surat))
+	if !sah && !hmac.Equal(mac, s.signLama(surat)) {
+		return tidakDitemukan, nil
+	}
+
+	pengaturan, err := s.repo.GetPengaturan(surat.KantorID)
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil pengaturan: %w", err)
+	}
+
+	hasil := &model.HasilVerifikasi{
+		NomorSurat:        surat.NomorSurat,
+		TanggalSurat:      surat.TanggalSurat,
+		BerlakuSampai:     surat.BerlakuSampai,
+		PelaporSamaran:    samarkanNama(surat.PelaporNama),
+		NamaKantor:        pengaturan.NamaKantor,
+		SuratAsalNomor:    surat.SuratAsalNomor,
+		PerpanjanganNomor: surat.PerpanjanganNomor,
+	}
+	for _, b := range surat.BarangHilang {
+		hasil.JenisBarang = append(hasil.JenisBarang, b.JenisBarang)
+	}
+
+	switch {
+	case surat.IsDibatalkan():
+		hasil.Status = model.VerifikasiDibatalkan
+		hasil.DibatalkanPada = surat.DibatalkanPada
+	case surat.Kedaluwarsa:
+		hasil.Status = model.VerifikasiKedaluwarsa
+	default:
+		hasil.Status = model.VerifikasiBerlaku
+	}
+	return hasil, nil
+}
+
+// samarkanNama hanya menyisakan huruf pertama setiap kata, mis. "Budi Santoso" -> "B*** S******"
+func samarkanNama(nama string) string {
+	kata := strings.Fields(strings.ToUpper(nama))
+	for i, k := range kata {
+		r := []rune(k)
+		kata[i] = string(r[0]) + strings.Repeat("*", len(r)-1)
+	}
+	return strings.Join(kata, " ")
+}
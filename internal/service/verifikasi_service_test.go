@@ -0,0 +1,207 @@
+package service
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"skh_app/internal/model"
+	"strings"
+	"testing"
+	"time"
+)
+
+// verifikasiRepoPalsu menyimpan surat dan kunci rahasia di memori
+type verifikasiRepoPalsu struct {
+	surat  map[int]*model.SuratKeteranganHilang
+	secret map[string]string
+}
+
+func (r *verifikasiRepoPalsu) GetSuratByID(id int) (*model.SuratKeteranganHilang, error) {
+	s, ok := r.surat[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	salinan := *s
+	return &salinan, nil
+}
+
+func (r *verifikasiRepoPalsu) GetPengaturan(kantorID int) (*model.Pengaturan, error) {
+	return &model.Pengaturan{ID: kantorID, NamaKantor: "Polres Uji"}, nil
+}
+
+func (r *verifikasiRepoPalsu) GetOrCreateSecret(nama string, buat func() (string, error)) (string, error) {
+	if s, ok := r.secret[nama]; ok {
+		return s, nil
+	}
+	s, err := buat()
+	if err != nil {
+		return "", err
+	}
+	r.secret[nama] = s
+	return s, nil
+}
+
+func newVerifikasiUji(t *testing.T) (*VerifikasiService, *verifikasiRepoPalsu) {
+	t.Helper()
+	repo := &verifikasiRepoPalsu{
+		surat: map[int]*model.SuratKeteranganHilang{
+			7: {
+				ID:             7,
+				KantorID:       model.KantorUtama,
+				KodeVerifikasi: "3f9c2a7d51e84b06a1c5d7e9f0b2c4d6",
+				NomorSurat:     "SKH/007/VIII/2025",
+				TanggalSurat:   time.Date(2025, time.August, 7, 10, 0, 0, 0, time.UTC),
+				PelaporNama:    "Budi Santoso",
+				BarangHilang:   []model.Barang{{JenisBarang: "KTP"}},
+			},
+			8: {ID: 8, KantorID: model.KantorUtama, NomorSurat: "SKH/008/VIII/2025", PelaporNama: "Siti", KodeVerifikasi: "8e1d0c4b7a9f2e6d5c3b1a0f9e8d7c6b"},
+		},
+		secret: map[string]string{},
+	}
+	svc, err := NewVerifikasiService(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return svc, repo
+}
+
+func TestVerifikasiTokenBolakBalik(t *testing.T) {
+	svc, repo := newVerifikasiUji(t)
+
+	token := svc.Token(repo.surat[7])
+	if !strings.HasPrefix(token, "7.") {
+		t.Fatalf("token %q tidak diawali ID surat", token)
+	}
+	hasil, err := svc.Verifikasi(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hasil.Status != model.VerifikasiBerlaku {
+		t.Fatalf("status = %q, seharusnya %q", hasil.Status, model.VerifikasiBerlaku)
+	}
+	if hasil.NomorSurat != "SKH/007/VIII/2025" || hasil.PelaporSamaran != "B*** S******" || hasil.NamaKantor != "Polres Uji" {
+		t.Errorf("hasil verifikasi tidak sesuai: %+v", hasil)
+	}
+	if len(hasil.JenisBarang) != 1 || hasil.JenisBarang[0] != "KTP" {
+		t.Errorf("jenis barang = %v, seharusnya [KTP]", hasil.JenisBarang)
+	}
+
+	// Kunci dimuat ulang dari repository, jadi token tetap sah setelah aplikasi dijalankan ulang
+	svcBaru, err := NewVerifikasiService(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hasil, err := svcBaru.Verifikasi(token); err != nil || hasil.Status != model.VerifikasiBerlaku {
+		t.Errorf("token ditolak setelah service dibuat ulang: %+v, %v", hasil, err)
+	}
+
+	repo.surat[7].Status = model.StatusDibatalkan
+	if hasil, err := svc.Verifikasi(token); err != nil || hasil.Status != model.VerifikasiDibatalkan {
+		t.Errorf("surat dibatalkan: status = %+v, %v", hasil, err)
+	}
+	repo.surat[7].Status = ""
+	repo.surat[7].Kedaluwarsa = true
+	if hasil, err := svc.Verifikasi(token); err != nil || hasil.Status != model.VerifikasiKedaluwarsa {
+		t.Errorf("surat kedaluwarsa: status = %+v, %v", hasil, err)
+	}
+}
+
+func TestVerifikasiTokenDiubah(t *testing.T) {
+	svc, repo := newVerifikasiUji(t)
+	token := svc.Token(repo.surat[7])
+	_, mac, _ := strings.Cut(token, ".")
+
+	macRusak := []byte(mac)
+	if macRusak[0] == 'A' {
+		macRusak[0] = 'B'
+	} else {
+		macRusak[0] = 'A'
+	}
+	lain := &verifikasiRepoPalsu{surat: repo.surat, secret: map[string]string{}}
+	svcLain, err := NewVerifikasiService(lain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	macPendek, _ := base64.RawURLEncoding.DecodeString(mac)
+
+	tests := map[string]string{
+		"kosong":               "",
+		"tanpa titik":          "7" + mac,
+		"id bukan angka":       "x." + mac,
+		"id nol":               "0." + mac,
+		"id negatif":           "-7." + mac,
+		"id surat lain":        "8." + mac,
+		"id tidak ada":         "99." + mac,
+		"mac bukan base64":     "7.!!!",
+		"mac diubah":           "7." + string(macRusak),
+		"mac dipotong":         "7." + base64.RawURLEncoding.EncodeToString(macPendek[:len(macPendek)-1]),
+		"mac kosong":           "7.",
+		"kunci lain":           svcLain.Token(repo.surat[7]),
+		"mac ditambah padding": token + "==",
+	}
+	for nama, tok := range tests {
+		hasil, err := svc.Verifikasi(tok)
+		if err != nil {
+			t.Errorf("%s: error %v", nama, err)
+			continue
+		}
+		if hasil.Status != model.VerifikasiTidakDitemukan || hasil.NomorSurat != "" {
+			t.Errorf("%s: token %q diterima: %+v", nama, tok, hasil)
+		}
+	}
+
+	// Kode verifikasi yang berbeda, mis. surat lain yang mendapat ID sama setelah data dipulihkan
+	// dari cadangan, membuat token tidak sah
+	asli := *repo.surat[7]
+	repo.surat[7].KodeVerifikasi = "00000000000000000000000000000000"
+	if hasil, err := svc.Verifikasi(token); err != nil || hasil.Status != model.VerifikasiTidakDitemukan {
+		t.Errorf("kode verifikasi lain: token masih diterima: %+v, %v", hasil, err)
+	}
+	*repo.surat[7] = asli
+}
+
+func TestVerifikasiSuratDirevisi(t *testing.T) {
+	svc, repo := newVerifikasiUji(t)
+	token := svc.Token(repo.surat[7])
+
+	// Isi surat yang direvisi setelah dicetak tidak membuat QR code yang tercetak menjadi tidak sah
+	s := repo.surat[7]
+	s.NomorSurat = "SKH/070/VIII/2025"
+	s.TanggalSurat = s.TanggalSurat.Add(time.Hour)
+	s.PelaporNama = "Budi Santosa"
+	s.Revisi = 2
+	hasil, err := svc.Verifikasi(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hasil.Status != model.VerifikasiBerlaku || hasil.NomorSurat != "SKH/070/VIII/2025" || hasil.PelaporSamaran != "B*** S******" {
+		t.Errorf("surat direvisi: hasil verifikasi = %+v", hasil)
+	}
+	if lagi := svc.Token(s); lagi != token {
+		t.Errorf("token berubah setelah surat direvisi: %q, seharusnya %q", lagi, token)
+	}
+}
+
+func TestVerifikasiTokenLama(t *testing.T) {
+	svc, repo := newVerifikasiUji(t)
+	s := repo.surat[7]
+	tokenLama := "7." + base64.RawURLEncoding.EncodeToString(svc.signLama(s))
+
+	// QR code yang tercetak sebelum ada kode verifikasi tetap sah selama datanya belum diubah
+	if hasil, err := svc.Verifikasi(tokenLama); err != nil || hasil.Status != model.VerifikasiBerlaku {
+		t.Errorf("token lama ditolak: %+v, %v", hasil, err)
+	}
+	s.PelaporNama = "  budi santoso "
+	if hasil, err := svc.Verifikasi(tokenLama); err != nil || hasil.Status != model.VerifikasiBerlaku {
+		t.Errorf("token lama, nama pelapor dinormalisasi: token ditolak: %+v, %v", hasil, err)
+	}
+	s.PelaporNama = "Budi Santosa"
+	if hasil, err := svc.Verifikasi(tokenLama); err != nil || hasil.Status != model.VerifikasiTidakDitemukan {
+		t.Errorf("token lama, nama pelapor diubah: token masih diterima: %+v, %v", hasil, err)
+	}
+
+	// Surat tanpa kode verifikasi tidak menerima token versi baru
+	s.KodeVerifikasi = ""
+	if hasil, err := svc.Verifikasi(svc.Token(s)); err != nil || hasil.Status != model.VerifikasiTidakDitemukan {
+		t.Errorf("surat tanpa kode verifikasi: token diterima: %+v, %v", hasil, err)
+	}
+}
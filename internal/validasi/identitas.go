@@ -0,0 +1,187 @@
+package validasi
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// kodeProvinsi adalah dua digit pertama NIK yang dipakai Dukcapil
+var kodeProvinsi = map[string]string{
+	"11": "Aceh", "12": "Sumatera Utara", "13": "Sumatera Barat", "14": "Riau", "15": "Jambi",
+	"16": "Sumatera Selatan", "17": "Bengkulu", "18": "Lampung", "19": "Kepulauan Bangka Belitung",
+	"21": "Kepulauan Riau", "31": "DKI Jakarta", "32": "Jawa Barat", "33": "Jawa Tengah",
+	"34": "DI Yogyakarta", "35": "Jawa Timur", "36": "Banten", "51": "Bali", "52": "Nusa Tenggara Barat",
+	"53": "Nusa Tenggara Timur", "61": "Kalimantan Barat", "62": "Kalimantan Tengah",
+	"63": "Kalimantan Selatan", "64": "Kalimantan Timur", "65": "Kalimantan Utara",
+	"71": "Sulawesi Utara", "72": "Sulawesi Tengah", "73": "Sulawesi Selatan", "74": "Sulawesi Tenggara",
+	"75": "Gorontalo", "76": "Sulawesi Barat", "81": "Maluku", "82": "Maluku Utara",
+	"91": "Papua", "92": "Papua Barat", "93": "Papua Selatan", "94": "Papua Tengah",
+	"95": "Papua Pegunungan", "96": "Papua Barat Daya",
+}
+
+// NIK adalah hasil uraian Nomor Induk Kependudukan
+type NIK struct {
+	Nomor         string
+	Provinsi      string
+	KodeKabupaten string // digit 3-4
+	KodeKecamatan string // digit 5-6
+	HariLahir     int
+	BulanLahir    int
+	TahunLahir    int // dua digit terakhir tahun lahir
+	Perempuan     bool
+}
+
+// ParseNIK memeriksa panjang, kode wilayah dan tanggal lahir yang tersandi di NIK.
+// Spasi dan titik pemisah diabaikan.
+func ParseNIK(s string) (*NIK, error) {
+	nomor := strings.NewReplacer(" ", "", ".", "", "-", "").Replace(s)
+	if len(nomor) != 16 || !semuaAngka(nomor) {
+		return nil, errors.New("NIK harus 16 digit angka")
+	}
+	provinsi, ok := kodeProvinsi[nomor[0:2]]
+	if !ok {
+		return nil, fmt.Errorf("kode provinsi %s pada NIK tidak dikenal", nomor[0:2])
+	}
+	if nomor[2:4] == "00" {
+		return nil, errors.New("kode kabupaten/kota pada NIK tidak boleh 00")
+	}
+	if nomor[4:6] == "00" {
+		return nil, errors.New("kode kecamatan pada NIK tidak boleh 00")
+	}
+	if nomor[12:16] == "0000" {
+		return nil, errors.New("nomor urut pada NIK tidak boleh 0000")
+	}
+
+	hari, _ := strconv.Atoi(nomor[6:8])
+	bulan, _ := strconv.Atoi(nomor[8:10])
+	tahun, _ := strconv.Atoi(nomor[10:12])
+	nik := &NIK{
+		Nomor:         nomor,
+		Provinsi:      provinsi,
+		KodeKabupaten: nomor[2:4],
+		KodeKecamatan: nomor[4:6],
+		BulanLahir:    bulan,
+		TahunLahir:    tahun,
+	}
+	// Tanggal lahir perempuan ditambah 40
+	if hari > 40 {
+		hari -= 40
+		nik.Perempuan = true
+	}
+	nik.HariLahir = hari
+	if !tanggalAda(hari, bulan, tahun) {
+		return nil, errors.New("tanggal lahir pada NIK (digit 7-12) tidak valid")
+	}
+	return nik, nil
+}
+
+// CocokTanggalLahir memeriksa apakah tanggal lahir di NIK sama dengan t
+func (n *NIK) CocokTanggalLahir(t time.Time) bool {
+	return n.HariLahir == t.Day() && n.BulanLahir == int(t.Month()) && n.TahunLahir == t.Year()%100
+}
+
+// tanggalAda memeriksa tanggal dengan tahun dua digit; tahun dicoba sebagai 19xx maupun 20xx
+// agar 29 Februari tetap diterima
+func tanggalAda(hari, bulan, tahun2 int) bool {
+	if bulan < 1 || bulan > 12 || hari < 1 {
+		return false
+	}
+	for _, abad := range []int{1900, 2000} {
+		t := time.Date(abad+tahun2, time.Month(bulan), hari, 0, 0, 0, 0, time.UTC)
+		if t.Day() == hari && int(t.Month()) == bulan {
+			return true
+		}
+	}
+	return false
+}
+
+// NomorSIM memeriksa nomor SIM 12 digit (format lama) atau 14 digit. Empat digit pertama
+// adalah tahun dan bulan lahir (YYMM). Hasilnya ditulis dengan pemisah "-".
+func NomorSIM(s string) (string, error) {
+	nomor := strings.NewReplacer(" ", "", "-", "", ".", "").Replace(s)
+	if !semuaAngka(nomor) || (len(nomor) != 12 && len(nomor) != 14) {
+		return s, errors.New("nomor SIM harus 12 atau 14 digit angka")
+	}
+	if bulan, _ := strconv.Atoi(nomor[2:4]); bulan < 1 || bulan > 12 {
+		return s, errors.New("digit 3-4 nomor SIM harus bulan lahir (01-12)")
+	}
+	return nomor[0:4] + "-" + nomor[4:8] + "-" + nomor[8:], nil
+}
+
+// golonganSIM memetakan penulisan golongan SIM yang umum ke bentuk bakunya
+var golonganSIM = map[string]string{
+	"A": "A", "AUMUM": "A Umum",
+	"B1": "B1", "BI": "B1", "B1UMUM": "B1 Umum", "BIUMUM": "B1 Umum",
+	"B2": "B2", "BII": "B2", "B2UMUM": "B2 Umum", "BIIUMUM": "B2 Umum",
+	"C": "C", "C1": "C I", "CI": "C I", "C2": "C II", "CII": "C II",
+	"D": "D", "D1": "D I", "DI": "D I",
+}
+
+// JenisSIM memeriksa golongan SIM. Beberapa golongan boleh dipisah koma, mis. "A, C".
+func JenisSIM(s string) (string, error) {
+	var hasil []string
+	for _, bagian := range strings.Split(s, ",") {
+		kunci := strings.ToUpper(strings.Join(strings.Fields(bagian), ""))
+		if kunci == "" {
+			continue
+		}
+		baku, ok := golonganSIM[kunci]
+		if !ok {
+			return s, fmt.Errorf("golongan SIM %q tidak dikenal", strings.TrimSpace(bagian))
+		}
+		hasil = append(hasil, baku)
+	}
+	return strings.Join(hasil, ", "), nil
+}
+
+var pasporRegex = regexp.MustCompile(`^[A-Z]{1,2}[0-9]{6,7}$`)
+
+// Paspor memeriksa nomor paspor RI: satu atau dua huruf diikuti angka, total 8-9 karakter
+func Paspor(s string) (string, error) {
+	nomor := strings.ToUpper(strings.ReplaceAll(s, " ", ""))
+	if !pasporRegex.MatchString(nomor) || len(nomor) > 9 || len(nomor) < 8 {
+		return s, errors.New("nomor paspor harus 1-2 huruf diikuti angka, total 8-9 karakter (mis. C1234567)")
+	}
+	return nomor, nil
+}
+
+// kodeWilayahTNKB adalah awalan nomor polisi kendaraan umum dan pribadi per wilayah registrasi
+var kodeWilayahTNKB = map[string]bool{
+	"A": true, "B": true, "D": true, "E": true, "F": true, "G": true, "H": true, "K": true,
+	"L": true, "M": true, "N": true, "P": true, "R": true, "S": true, "T": true, "W": true, "Z": true,
+	"AA": true, "AB": true, "AD": true, "AE": true, "AG": true,
+	"BA": true, "BB": true, "BD": true, "BE": true, "BG": true, "BH": true, "BK": true,
+	"BL": true, "BM": true, "BN": true, "BP": true,
+	"DA": true, "DB": true, "DC": true, "DD": true, "DE": true, "DG": true, "DH": true,
+	"DK": true, "DL": true, "DM": true, "DN": true, "DP": true, "DR": true, "DS": true,
+	"DT": true, "DW": true, "EA": true, "EB": true, "ED": true,
+	"KB": true, "KH": true, "KT": true, "KU": true, "PA": true, "PB": true,
+}
+
+var tnkbRegex = regexp.MustCompile(`^([A-Z]{1,2}) *([1-9][0-9]{0,3}) *([A-Z]{0,3})$`)
+
+// TNKB memeriksa nomor polisi kendaraan, mis. "DN 1234 AB", dan mengembalikan penulisan bakunya
+func TNKB(s string) (string, error) {
+	nomor := strings.ToUpper(strings.Join(strings.Fields(s), " "))
+	m := tnkbRegex.FindStringSubmatch(nomor)
+	if m == nil {
+		return s, errors.New("nomor polisi harus berformat kode wilayah, angka 1-4 digit dan huruf akhiran (mis. DN 1234 AB)")
+	}
+	if !kodeWilayahTNKB[m[1]] {
+		return s, fmt.Errorf("kode wilayah nomor polisi %s tidak dikenal", m[1])
+	}
+	return strings.TrimSpace(m[1] + " " + m[2] + " " + m[3]), nil
+}
+
+func semuaAngka(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,262 @@
+package validasi
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseNIK(t *testing.T) {
+	tests := []struct {
+		nik       string
+		nomor     string
+		provinsi  string
+		hari      int
+		bulan     int
+		tahun     int
+		perempuan bool
+		galat     string // potongan pesan error; kosong bila NIK harus diterima
+	}{
+		{nik: "7371011508940001", nomor: "7371011508940001", provinsi: "Sulawesi Selatan", hari: 15, bulan: 8, tahun: 94},
+		// Tanggal lahir perempuan ditambah 40
+		{nik: "7371015508940002", nomor: "7371015508940002", provinsi: "Sulawesi Selatan", hari: 15, bulan: 8, tahun: 94, perempuan: true},
+		{nik: "3174047112050003", nomor: "3174047112050003", provinsi: "DKI Jakarta", hari: 31, bulan: 12, tahun: 5, perempuan: true},
+		{nik: "3174040101000004", nomor: "3174040101000004", provinsi: "DKI Jakarta", hari: 1, bulan: 1, tahun: 0},
+		// 29 Februari diterima bila tahun 19xx atau 20xx kabisat
+		{nik: "5171012902000005", nomor: "5171012902000005", provinsi: "Bali", hari: 29, bulan: 2, tahun: 0},
+		{nik: "5171016902960006", nomor: "5171016902960006", provinsi: "Bali", hari: 29, bulan: 2, tahun: 96, perempuan: true},
+		// Pemisah spasi, titik dan tanda hubung diabaikan
+		{nik: "7371 0115 0894 0001", nomor: "7371011508940001", provinsi: "Sulawesi Selatan", hari: 15, bulan: 8, tahun: 94},
+		{nik: "73.71.01.150894.0001", nomor: "7371011508940001", provinsi: "Sulawesi Selatan", hari: 15, bulan: 8, tahun: 94},
+		{nik: "737101-150894-0001", nomor: "7371011508940001", provinsi: "Sulawesi Selatan", hari: 15, bulan: 8, tahun: 94},
+
+		{nik: "", galat: "16 digit"},
+		{nik: "737101150894000", galat: "16 digit"},
+		{nik: "73710115089400011", galat: "16 digit"},
+		{nik: "73710115089400O1", galat: "16 digit"},
+		{nik: "7371011508940/01", galat: "16 digit"},
+		{nik: "１２３４５６７８９０１２３４５６", galat: "16 digit"},
+		{nik: "1071011508940001", galat: "kode provinsi 10"},
+		{nik: "9971011508940001", galat: "kode provinsi 99"},
+		{nik: "7300011508940001", galat: "kabupaten/kota"},
+		{nik: "7371001508940001", galat: "kecamatan"},
+		{nik: "7371011508940000", galat: "nomor urut"},
+		{nik: "7371013102990001", galat: "tanggal lahir"},
+		{nik: "7371017102990001", galat: "tanggal lahir"},
+		{nik: "7371012902010001", galat: "tanggal lahir"},
+		{nik: "7371010008940001", galat: "tanggal lahir"},
+		{nik: "7371011500940001", galat: "tanggal lahir"},
+		{nik: "7371011513940001", galat: "tanggal lahir"},
+		{nik: "7371013204940001", galat: "tanggal lahir"},
+		{nik: "7371014004940001", galat: "tanggal lahir"},
+		{nik: "7371017208940001", galat: "tanggal lahir"},
+	}
+	for _, tt := range tests {
+		nik, err := ParseNIK(tt.nik)
+		if tt.galat != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.galat) {
+				t.Errorf("ParseNIK(%q) error = %v, seharusnya memuat %q", tt.nik, err, tt.galat)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseNIK(%q) error: %v", tt.nik, err)
+			continue
+		}
+		if nik.Nomor != tt.nomor || nik.Provinsi != tt.provinsi || nik.HariLahir != tt.hari ||
+			nik.BulanLahir != tt.bulan || nik.TahunLahir != tt.tahun || nik.Perempuan != tt.perempuan {
+			t.Errorf("ParseNIK(%q) = %+v", tt.nik, nik)
+		}
+	}
+}
+
+func TestCocokTanggalLahir(t *testing.T) {
+	laki, err := ParseNIK("7371011508940001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	perempuan, err := ParseNIK("7371015508940002")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lahir := time.Date(1994, time.August, 15, 0, 0, 0, 0, time.UTC)
+	if !laki.CocokTanggalLahir(lahir) || !perempuan.CocokTanggalLahir(lahir) {
+		t.Error("tanggal lahir yang sama dianggap tidak cocok")
+	}
+	for _, lain := range []time.Time{lahir.AddDate(0, 0, 1), lahir.AddDate(0, 1, 0), lahir.AddDate(1, 0, 0)} {
+		if laki.CocokTanggalLahir(lain) {
+			t.Errorf("tanggal lahir %s dianggap cocok dengan NIK %s", lain.Format("2006-01-02"), laki.Nomor)
+		}
+	}
+}
+
+func TestNomorSIM(t *testing.T) {
+	tests := []struct {
+		nomor string
+		want  string
+		galat string
+	}{
+		{nomor: "940812345678", want: "9408-1234-5678"},
+		{nomor: "94081234567890", want: "9408-1234-567890"},
+		{nomor: "9408-1234-5678", want: "9408-1234-5678"},
+		{nomor: "9408 1234 5678", want: "9408-1234-5678"},
+		{nomor: "9408.1234.5678", want: "9408-1234-5678"},
+		{nomor: "", galat: "12 atau 14 digit"},
+		{nomor: "94081234567", galat: "12 atau 14 digit"},
+		{nomor: "9408123456789", galat: "12 atau 14 digit"},
+		{nomor: "9408123456A8", galat: "12 atau 14 digit"},
+		{nomor: "940012345678", galat: "bulan lahir"},
+		{nomor: "941312345678", galat: "bulan lahir"},
+	}
+	for _, tt := range tests {
+		got, err := NomorSIM(tt.nomor)
+		if tt.galat != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.galat) {
+				t.Errorf("NomorSIM(%q) error = %v, seharusnya memuat %q", tt.nomor, err, tt.galat)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("NomorSIM(%q) = %q, %v; seharusnya %q", tt.nomor, got, err, tt.want)
+		}
+	}
+}
+
+func TestJenisSIM(t *testing.T) {
+	tests := []struct {
+		jenis string
+		want  string
+		galat string
+	}{
+		{jenis: "A", want: "A"},
+		{jenis: "c", want: "C"},
+		{jenis: "A umum", want: "A Umum"},
+		{jenis: "BII Umum", want: "B2 Umum"},
+		{jenis: "b1", want: "B1"},
+		{jenis: "C1", want: "C I"},
+		{jenis: "c ii", want: "C II"},
+		{jenis: "A, C", want: "A, C"},
+		{jenis: "A,,C,", want: "A, C"},
+		{jenis: "", want: ""},
+		{jenis: "E", galat: `golongan SIM "E"`},
+		{jenis: "A, B3", galat: `golongan SIM "B3"`},
+	}
+	for _, tt := range tests {
+		got, err := JenisSIM(tt.jenis)
+		if tt.galat != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.galat) {
+				t.Errorf("JenisSIM(%q) error = %v, seharusnya memuat %q", tt.jenis, err, tt.galat)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("JenisSIM(%q) = %q, %v; seharusnya %q", tt.jenis, got, err, tt.want)
+		}
+	}
+}
+
+func TestPaspor(t *testing.T) {
+	tests := []struct {
+		nomor string
+		want  string
+		ok    bool
+	}{
+		{"C1234567", "C1234567", true},
+		{"c 1234567", "C1234567", true},
+		{"AB1234567", "AB1234567", true},
+		{"AB123456", "AB123456", true},
+		{"C123456", "", false},
+		{"C12345678", "", false},
+		{"ABC123456", "", false},
+		{"12345678", "", false},
+		{"C12345-7", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, err := Paspor(tt.nomor)
+		if !tt.ok {
+			if err == nil {
+				t.Errorf("Paspor(%q) = %q, seharusnya ditolak", tt.nomor, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("Paspor(%q) = %q, %v; seharusnya %q", tt.nomor, got, err, tt.want)
+		}
+	}
+}
+
+func TestTNKB(t *testing.T) {
+	tests := []struct {
+		nomor string
+		want  string
+		galat string
+	}{
+		{nomor: "DN 1234 AB", want: "DN 1234 AB"},
+		{nomor: "dn1234ab", want: "DN 1234 AB"},
+		{nomor: "  B   1  ", want: "B 1"},
+		{nomor: "DD 5 XYZ", want: "DD 5 XYZ"},
+		{nomor: "B 0123 AB", galat: "format"},
+		{nomor: "DN 12345 AB", galat: "format"},
+		{nomor: "DN 1234 ABCD", galat: "format"},
+		{nomor: "1234 AB", galat: "format"},
+		{nomor: "", galat: "format"},
+		{nomor: "XX 1234 AB", galat: "kode wilayah nomor polisi XX"},
+		{nomor: "C 1234", galat: "kode wilayah nomor polisi C"},
+	}
+	for _, tt := range tests {
+		got, err := TNKB(tt.nomor)
+		if tt.galat != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.galat) {
+				t.Errorf("TNKB(%q) error = %v, seharusnya memuat %q", tt.nomor, err, tt.galat)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("TNKB(%q) = %q, %v; seharusnya %q", tt.nomor, got, err, tt.want)
+		}
+	}
+}
+
+func TestPeriksa(t *testing.T) {
+	tests := []struct {
+		aturan string
+		nilai  string
+		want   string
+		ok     bool
+	}{
+		{AturanNIK, "7371 0115 0894 0001", "7371011508940001", true},
+		{AturanNIK, "7371013102990001", "", false},
+		{AturanSIM, "940812345678", "9408-1234-5678", true},
+		{AturanJenisSIM, "b ii umum", "B2 Umum", true},
+		{AturanPaspor, "c1234567", "C1234567", true},
+		{AturanTNKB, "dn1234ab", "DN 1234 AB", true},
+		{AturanTNKB, "XX 1", "", false},
+		// Tanpa aturan atau aturan tidak dikenal, nilai dikembalikan apa adanya
+		{"", " bebas ", " bebas ", true},
+		{"telepon", "0812", "0812", true},
+	}
+	for _, tt := range tests {
+		got, err := Periksa(tt.aturan, tt.nilai)
+		if !tt.ok {
+			if err == nil {
+				t.Errorf("Periksa(%q, %q) = %q, seharusnya ditolak", tt.aturan, tt.nilai, got)
+			} else if got != tt.nilai {
+				t.Errorf("Periksa(%q, %q) yang gagal mengembalikan %q, seharusnya nilai asli", tt.aturan, tt.nilai, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("Periksa(%q, %q) = %q, %v; seharusnya %q", tt.aturan, tt.nilai, got, err, tt.want)
+		}
+	}
+
+	for _, a := range DaftarAturan {
+		if !IsAturan(a.Nama) {
+			t.Errorf("IsAturan(%q) = false untuk aturan di DaftarAturan", a.Nama)
+		}
+	}
+	if IsAturan("telepon") || IsAturan("") {
+		t.Error("IsAturan menerima aturan yang tidak dikenal")
+	}
+}
@@ -0,0 +1,98 @@
+// Package validasi berisi aturan pemeriksaan nomor identitas Indonesia (NIK, SIM, paspor, TNKB)
+// dan penampung error per field untuk form maupun API.
+package validasi
+
+import (
+	"sort"
+	"strings"
+)
+
+// Errors memetakan nama field ke pesan kesalahannya, mis. "pelapor_nama" atau "barang.0"
+type Errors map[string]string
+
+// Add mencatat pesan untuk field. Pesan kedua untuk field yang sama digabung dengan "; ".
+func (e Errors) Add(field, pesan string) {
+	if lama, ok := e[field]; ok {
+		e[field] = lama + "; " + pesan
+		return
+	}
+	e[field] = pesan
+}
+
+// Error menggabungkan semua pesan, diurutkan berdasarkan nama field
+func (e Errors) Error() string {
+	fields := make([]string, 0, len(e))
+	for f := range e {
+		fields = append(fields, f)
+	}
+	sort.Strings(fields)
+	pesan := make([]string, 0, len(fields))
+	for _, f := range fields {
+		pesan = append(pesan, e[f])
+	}
+	return strings.Join(pesan, "; ")
+}
+
+// ErrOrNil mengembalikan nil jika tidak ada kesalahan, agar dapat langsung dipakai sebagai error
+func (e Errors) ErrOrNil() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Nama aturan yang dapat dipasang pada isian jenis barang (FieldBarang.Validasi)
+const (
+	AturanNIK      = "nik"
+	AturanSIM      = "sim"
+	AturanJenisSIM = "jenis_sim"
+	AturanPaspor   = "paspor"
+	AturanTNKB     = "tnkb"
+)
+
+// Aturan adalah satu pilihan aturan untuk halaman admin jenis barang
+type Aturan struct {
+	Nama  string
+	Label string
+}
+
+// DaftarAturan berisi semua aturan yang dikenal, sesuai urutan tampil
+var DaftarAturan = []Aturan{
+	{AturanNIK, "NIK (16 digit)"},
+	{AturanSIM, "Nomor SIM"},
+	{AturanJenisSIM, "Golongan SIM"},
+	{AturanPaspor, "Nomor paspor"},
+	{AturanTNKB, "Nomor polisi (TNKB)"},
+}
+
+// IsAturan memeriksa apakah nama aturan dikenal
+func IsAturan(nama string) bool {
+	for _, a := range DaftarAturan {
+		if a.Nama == nama {
+			return true
+		}
+	}
+	return false
+}
+
+// Periksa menjalankan aturan pada nilai dan mengembalikan bentuk bakunya,
+// mis. nomor polisi "dn1234ab" menjadi "DN 1234 AB"
+func Periksa(aturan, nilai string) (string, error) {
+	switch aturan {
+	case AturanNIK:
+		nik, err := ParseNIK(nilai)
+		if err != nil {
+			return nilai, err
+		}
+		return nik.Nomor, nil
+	case AturanSIM:
+		return NomorSIM(nilai)
+	case AturanJenisSIM:
+		return JenisSIM(nilai)
+	case AturanPaspor:
+		return Paspor(nilai)
+	case AturanTNKB:
+		return TNKB(nilai)
+	}
+	return nilai, nil
+}
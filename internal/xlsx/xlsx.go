@@ -0,0 +1,223 @@
+// Package xlsx menulis file Excel (.xlsx) sederhana secara streaming: beberapa sheet berisi teks dan
+// angka, dengan baris judul tebal. Baris langsung ditulis ke io.Writer sehingga ekspor ribuan surat
+// tidak perlu ditampung di memori.
+package xlsx
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Writer menulis satu workbook. Panggil Sheet sebelum menulis baris, lalu Close di akhir.
+type Writer struct {
+	zw     *zip.Writer
+	sheet  *bufio.Writer
+	nama   []string
+	baris  int
+	closed bool
+}
+
+// NewWriter membuat workbook baru yang ditulis ke w
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{zw: zip.NewWriter(w)}
+}
+
+var namaTerlarang = strings.NewReplacer("[", "", "]", "", ":", "", "*", "", "?", "", "/", "-", `\`, "-")
+
+// Sheet menutup sheet sebelumnya lalu memulai sheet baru. lebar adalah lebar kolom (satuan karakter)
+// mulai dari kolom A; boleh dikosongkan.
+func (x *Writer) Sheet(nama string, lebar ...float64) error {
+	if x.closed {
+		return errors.New("xlsx: workbook sudah ditutup")
+	}
+	if err := x.tutupSheet(); err != nil {
+		return err
+	}
+	nama = namaTerlarang.Replace(nama)
+	if r := []rune(nama); len(r) > 31 {
+		nama = string(r[:31])
+	}
+	if nama == "" {
+		nama = "Sheet" + strconv.Itoa(len(x.nama)+1)
+	}
+	x.nama = append(x.nama, nama)
+
+	f, err := x.zw.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", len(x.nama)))
+	if err != nil {
+		return err
+	}
+	x.sheet = bufio.NewWriter(f)
+	x.baris = 0
+	x.sheet.WriteString(xml.Header)
+	x.sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	if len(lebar) > 0 {
+		x.sheet.WriteString("<cols>")
+		for i, l := range lebar {
+			fmt.Fprintf(x.sheet, `<col min="%d" max="%d" width="%g" customWidth="1"/>`, i+1, i+1, l)
+		}
+		x.sheet.WriteString("</cols>")
+	}
+	_, err = x.sheet.WriteString("<sheetData>")
+	return err
+}
+
+// Judul menulis satu baris dengan huruf tebal
+func (x *Writer) Judul(sel ...interface{}) error {
+	return x.tulisBaris(1, sel)
+}
+
+// Baris menulis satu baris. Nilai int dan float64 ditulis sebagai angka, time.Time sebagai teks
+// tanggal (02-01-2006), selain itu sebagai teks.
+func (x *Writer) Baris(sel ...interface{}) error {
+	return x.tulisBaris(0, sel)
+}
+
+func (x *Writer) tulisBaris(gaya int, sel []interface{}) error {
+	if x.sheet == nil {
+		return errors.New("xlsx: panggil Sheet sebelum menulis baris")
+	}
+	x.baris++
+	fmt.Fprintf(x.sheet, `<row r="%d">`, x.baris)
+	for i, v := range sel {
+		ref := namaKolom(i) + strconv.Itoa(x.baris)
+		s := ""
+		if gaya != 0 {
+			s = fmt.Sprintf(` s="%d"`, gaya)
+		}
+		switch v := v.(type) {
+		case int:
+			fmt.Fprintf(x.sheet, `<c r="%s"%s><v>%d</v></c>`, ref, s, v)
+		case int64:
+			fmt.Fprintf(x.sheet, `<c r="%s"%s><v>%d</v></c>`, ref, s, v)
+		case float64:
+			fmt.Fprintf(x.sheet, `<c r="%s"%s><v>%s</v></c>`, ref, s, strconv.FormatFloat(v, 'f', -1, 64))
+		default:
+			var teks string
+			switch v := v.(type) {
+			case nil:
+			case string:
+				teks = v
+			case time.Time:
+				if !v.IsZero() {
+					teks = v.Format("02-01-2006")
+				}
+			default:
+				teks = fmt.Sprint(v)
+			}
+			if teks == "" {
+				continue
+			}
+			fmt.Fprintf(x.sheet, `<c r="%s" t="inlineStr"%s><is><t xml:space="preserve">`, ref, s)
+			xml.EscapeText(x.sheet, []byte(bersihkan(teks)))
+			x.sheet.WriteString(`</t></is></c>`)
+		}
+	}
+	_, err := x.sheet.WriteString("</row>")
+	return err
+}
+
+func (x *Writer) tutupSheet() error {
+	if x.sheet == nil {
+		return nil
+	}
+	x.sheet.WriteString("</sheetData></worksheet>")
+	err := x.sheet.Flush()
+	x.sheet = nil
+	return err
+}
+
+// Close menutup sheet terakhir lalu menulis workbook, style dan relasinya
+func (x *Writer) Close() error {
+	if x.closed {
+		return nil
+	}
+	if len(x.nama) == 0 {
+		if err := x.Sheet(""); err != nil {
+			return err
+		}
+	}
+	if err := x.tutupSheet(); err != nil {
+		return err
+	}
+	x.closed = true
+
+	var ct, wb, rels strings.Builder
+	ct.WriteString(xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	wb.WriteString(xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	rels.WriteString(xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i, nama := range x.nama {
+		n := i + 1
+		fmt.Fprintf(&ct, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, n)
+		fmt.Fprintf(&wb, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, escapeAttr(nama), n, n)
+		fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, n, n)
+	}
+	ct.WriteString(`</Types>`)
+	wb.WriteString(`</sheets></workbook>`)
+	fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/></Relationships>`, len(x.nama)+1)
+
+	files := []struct{ nama, isi string }{
+		{"[Content_Types].xml", ct.String()},
+		{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+		{"xl/workbook.xml", wb.String()},
+		{"xl/_rels/workbook.xml.rels", rels.String()},
+		{"xl/styles.xml", styles},
+	}
+	for _, f := range files {
+		w, err := x.zw.Create(f.nama)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, f.isi); err != nil {
+			return err
+		}
+	}
+	return x.zw.Close()
+}
+
+// styles berisi dua format sel: 0 biasa dan 1 tebal (untuk Judul)
+const styles = xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>` +
+	`</styleSheet>`
+
+// namaKolom mengubah indeks kolom (0 = A) menjadi nama kolom Excel: A..Z, AA..
+func namaKolom(i int) string {
+	nama := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		nama = string(rune('A'+(i-1)%26)) + nama
+	}
+	return nama
+}
+
+// bersihkan membuang karakter kontrol yang tidak boleh ada di XML (selain tab dan baris baru)
+func bersihkan(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 && r != '\t' && r != '\n' && r != '\r' {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+func escapeAttr(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
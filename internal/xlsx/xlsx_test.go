@@ -0,0 +1,226 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNamaKolom(t *testing.T) {
+	tests := map[int]string{
+		0: "A", 1: "B", 25: "Z", 26: "AA", 27: "AB", 51: "AZ", 52: "BA",
+		701: "ZZ", 702: "AAA", 16383: "XFD",
+	}
+	for i, want := range tests {
+		if got := namaKolom(i); got != want {
+			t.Errorf("namaKolom(%d) = %q, seharusnya %q", i, got, want)
+		}
+	}
+}
+
+// sel adalah satu <c> hasil tulisBaris
+type sel struct {
+	Ref   string `xml:"r,attr"`
+	Tipe  string `xml:"t,attr"`
+	Gaya  string `xml:"s,attr"`
+	Nilai string `xml:"v"`
+	Teks  string `xml:"is>t"`
+}
+
+type lembar struct {
+	Kolom []struct {
+		Min   int     `xml:"min,attr"`
+		Lebar float64 `xml:"width,attr"`
+	} `xml:"cols>col"`
+	Baris []struct {
+		R   int   `xml:"r,attr"`
+		Sel []sel `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+type bukuKerja struct {
+	Sheet []struct {
+		Nama string `xml:"name,attr"`
+		ID   int    `xml:"sheetId,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+// bukaArsip membaca semua file di dalam arsip XLSX
+func bukaArsip(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("hasil bukan arsip zip: %v", err)
+	}
+	isi := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		isi[f.Name] = string(b)
+	}
+	return isi
+}
+
+// bacaXML mengurai isi file XML dan gagal bila file tidak ada atau XML-nya rusak
+func bacaXML(t *testing.T, isi map[string]string, nama string, v interface{}) {
+	t.Helper()
+	teks, ok := isi[nama]
+	if !ok {
+		t.Fatalf("arsip tidak memuat %s", nama)
+	}
+	if err := xml.Unmarshal([]byte(teks), v); err != nil {
+		t.Fatalf("%s bukan XML yang sah: %v\n%s", nama, err, teks)
+	}
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	x := NewWriter(&buf)
+	if err := x.Baris("x"); err == nil {
+		t.Error("Baris sebelum Sheet seharusnya gagal")
+	}
+	if err := x.Sheet("Register/Surat: [2025]", 5, 30.5); err != nil {
+		t.Fatal(err)
+	}
+	if err := x.Judul("No", "Nama"); err != nil {
+		t.Fatal(err)
+	}
+	tanggal := time.Date(2025, time.August, 7, 10, 0, 0, 0, time.UTC)
+	if err := x.Baris(1, `Budi <"Tom"> & 'Jerry'`, int64(1234567890123), 2.5, tanggal, time.Time{}, nil, "", "=HYPERLINK(\"x\")", "a\x01b\tc\nd"); err != nil {
+		t.Fatal(err)
+	}
+	lebar := make([]interface{}, 28)
+	for i := range lebar {
+		lebar[i] = i
+	}
+	if err := x.Baris(lebar...); err != nil {
+		t.Fatal(err)
+	}
+	if err := x.Sheet(strings.Repeat("Panjang", 6)); err != nil {
+		t.Fatal(err)
+	}
+	if err := x.Sheet(""); err != nil {
+		t.Fatal(err)
+	}
+	if err := x.Baris("Kedua"); err != nil {
+		t.Fatal(err)
+	}
+	if err := x.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := x.Close(); err != nil {
+		t.Errorf("Close kedua kali: %v", err)
+	}
+	if err := x.Sheet("Lagi"); err == nil {
+		t.Error("Sheet setelah Close seharusnya gagal")
+	}
+
+	isi := bukaArsip(t, buf.Bytes())
+	for _, nama := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml"} {
+		var v struct{}
+		bacaXML(t, isi, nama, &v)
+	}
+
+	var wb bukuKerja
+	bacaXML(t, isi, "xl/workbook.xml", &wb)
+	namaSheet := []string{"Register-Surat 2025", strings.Repeat("Panjang", 6)[:31], "Sheet3"}
+	if len(wb.Sheet) != len(namaSheet) {
+		t.Fatalf("jumlah sheet = %d, seharusnya %d", len(wb.Sheet), len(namaSheet))
+	}
+	for i, s := range wb.Sheet {
+		if s.Nama != namaSheet[i] || s.ID != i+1 {
+			t.Errorf("sheet %d = %q (id %d), seharusnya %q", i+1, s.Nama, s.ID, namaSheet[i])
+		}
+		n := string(rune('1' + i))
+		if !strings.Contains(isi["[Content_Types].xml"], `PartName="/xl/worksheets/sheet`+n+`.xml"`) {
+			t.Errorf("[Content_Types].xml tidak mendaftarkan sheet%s.xml", n)
+		}
+		if !strings.Contains(isi["xl/_rels/workbook.xml.rels"], `Target="worksheets/sheet`+n+`.xml"`) {
+			t.Errorf("workbook.xml.rels tidak menunjuk sheet%s.xml", n)
+		}
+	}
+
+	var sh lembar
+	bacaXML(t, isi, "xl/worksheets/sheet1.xml", &sh)
+	if len(sh.Kolom) != 2 || sh.Kolom[0].Lebar != 5 || sh.Kolom[1].Min != 2 || sh.Kolom[1].Lebar != 30.5 {
+		t.Errorf("lebar kolom = %+v", sh.Kolom)
+	}
+	if len(sh.Baris) != 3 {
+		t.Fatalf("jumlah baris sheet1 = %d, seharusnya 3", len(sh.Baris))
+	}
+	for i, b := range sh.Baris {
+		if b.R != i+1 {
+			t.Errorf("baris ke-%d bernomor %d", i+1, b.R)
+		}
+	}
+
+	judul := sh.Baris[0].Sel
+	if len(judul) != 2 || judul[0] != (sel{Ref: "A1", Tipe: "inlineStr", Gaya: "1", Teks: "No"}) || judul[1].Ref != "B1" || judul[1].Gaya != "1" {
+		t.Errorf("baris judul = %+v", judul)
+	}
+
+	// Sel kosong (tanggal nol, nil, teks kosong) dilewati tanpa menggeser referensi sel berikutnya
+	want := []sel{
+		{Ref: "A2", Nilai: "1"},
+		{Ref: "B2", Tipe: "inlineStr", Teks: `Budi <"Tom"> & 'Jerry'`},
+		{Ref: "C2", Nilai: "1234567890123"},
+		{Ref: "D2", Nilai: "2.5"},
+		{Ref: "E2", Tipe: "inlineStr", Teks: "07-08-2025"},
+		{Ref: "I2", Tipe: "inlineStr", Teks: `=HYPERLINK("x")`},
+		{Ref: "J2", Tipe: "inlineStr", Teks: "ab\tc\nd"},
+	}
+	if got := sh.Baris[1].Sel; len(got) != len(want) {
+		t.Errorf("baris 2 = %+v, seharusnya %+v", got, want)
+	} else {
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("sel %s = %+v, seharusnya %+v", want[i].Ref, got[i], want[i])
+			}
+		}
+	}
+	if strings.Contains(isi["xl/worksheets/sheet1.xml"], "<f>") {
+		t.Error("teks berawalan = ditulis sebagai rumus")
+	}
+
+	panjang := sh.Baris[2].Sel
+	if len(panjang) != 28 || panjang[25].Ref != "Z3" || panjang[26].Ref != "AA3" || panjang[27] != (sel{Ref: "AB3", Nilai: "27"}) {
+		t.Errorf("referensi kolom setelah Z salah: %+v", panjang[24:])
+	}
+
+	var kosong lembar
+	bacaXML(t, isi, "xl/worksheets/sheet2.xml", &kosong)
+	if len(kosong.Baris) != 0 {
+		t.Errorf("sheet2 seharusnya kosong: %+v", kosong.Baris)
+	}
+	var ketiga lembar
+	bacaXML(t, isi, "xl/worksheets/sheet3.xml", &ketiga)
+	if len(ketiga.Baris) != 1 || ketiga.Baris[0].Sel[0] != (sel{Ref: "A1", Tipe: "inlineStr", Teks: "Kedua"}) {
+		t.Errorf("nomor baris tidak dimulai ulang di sheet baru: %+v", ketiga.Baris)
+	}
+}
+
+func TestWriterTanpaSheet(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewWriter(&buf).Close(); err != nil {
+		t.Fatal(err)
+	}
+	isi := bukaArsip(t, buf.Bytes())
+	var wb bukuKerja
+	bacaXML(t, isi, "xl/workbook.xml", &wb)
+	if len(wb.Sheet) != 1 || wb.Sheet[0].Nama != "Sheet1" {
+		t.Errorf("workbook kosong seharusnya berisi Sheet1: %+v", wb.Sheet)
+	}
+	var sh lembar
+	bacaXML(t, isi, "xl/worksheets/sheet1.xml", &sh)
+}
@@ -0,0 +1,5 @@
+-- Surat tidak lagi dihapus, melainkan dibatalkan dengan alasan
+ALTER TABLE surat ADD COLUMN status TEXT NOT NULL DEFAULT 'aktif' CHECK (status IN ('aktif', 'dibatalkan'));
+ALTER TABLE surat ADD COLUMN alasan_batal TEXT;
+ALTER TABLE surat ADD COLUMN dibatalkan_oleh TEXT;
+ALTER TABLE surat ADD COLUMN dibatalkan_pada DATETIME;
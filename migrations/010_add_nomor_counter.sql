@@ -0,0 +1,11 @@
+-- Penghitung nomor surat per periode (tahun), dinaikkan secara atomik di dalam transaksi pembuatan surat
+CREATE TABLE IF NOT EXISTS nomor_counter (
+    periode TEXT PRIMARY KEY,
+    last_nomor INTEGER NOT NULL DEFAULT 0
+);
+
+-- Salin nomor terakhir dari pengaturan lama
+INSERT OR IGNORE INTO nomor_counter (periode, last_nomor)
+SELECT CAST(last_nomor_year AS TEXT), COALESCE(last_nomor_surat, 0)
+FROM pengaturan
+WHERE id = 1 AND last_nomor_year IS NOT NULL;
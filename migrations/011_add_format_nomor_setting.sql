@@ -0,0 +1,3 @@
+-- Kode kantor untuk token {KODE} dan pilihan reset penomoran (tahunan/bulanan)
+ALTER TABLE pengaturan ADD COLUMN kode_kantor TEXT;
+ALTER TABLE pengaturan ADD COLUMN reset_nomor TEXT NOT NULL DEFAULT 'tahunan';
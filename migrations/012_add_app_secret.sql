@@ -0,0 +1,6 @@
+-- Kunci rahasia aplikasi (mis. untuk tanda tangan HMAC token verifikasi surat), dibuat otomatis saat pertama dipakai
+CREATE TABLE IF NOT EXISTS app_secret (
+    nama TEXT PRIMARY KEY,
+    nilai TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,13 @@
+-- Masa berlaku surat dapat diatur, tanggal akhir berlaku disimpan per surat,
+-- dan surat perpanjangan menunjuk ke surat asalnya
+ALTER TABLE pengaturan ADD COLUMN masa_berlaku_hari INTEGER NOT NULL DEFAULT 15;
+ALTER TABLE surat ADD COLUMN berlaku_sampai DATETIME;
+ALTER TABLE surat ADD COLUMN surat_asal_id INTEGER REFERENCES surat(id);
+
+-- Surat lama: berlaku 15 hari termasuk tanggal surat (tanggal_surat tersimpan dalam WITA)
+UPDATE surat
+SET berlaku_sampai = date(substr(tanggal_surat, 1, 10), '+14 days') || ' 00:00:00+08:00'
+WHERE berlaku_sampai IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_surat_berlaku_sampai ON surat(berlaku_sampai);
+CREATE INDEX IF NOT EXISTS idx_surat_asal ON surat(surat_asal_id);
@@ -0,0 +1,30 @@
+-- Registri jenis barang hilang. Setiap jenis punya daftar field (JSON) yang dipakai untuk form,
+-- validasi dan frasa cetak. nama disimpan apa adanya di barang.jenis_barang sehingga tidak boleh diganti.
+CREATE TABLE IF NOT EXISTS jenis_barang (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    nama TEXT NOT NULL UNIQUE,
+    fields TEXT NOT NULL DEFAULT '[]',
+    frasa_cetak TEXT NOT NULL DEFAULT '',
+    urutan INTEGER NOT NULL DEFAULT 0,
+    aktif BOOLEAN NOT NULL DEFAULT 1,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Jenis bawaan, sama dengan form dan template cetak sebelumnya
+INSERT OR IGNORE INTO jenis_barang (nama, fields, frasa_cetak, urutan) VALUES
+('KTP', '[{"Kunci":"nik","Label":"NIK","Tipe":"text","Wajib":true,"Pola":"[0-9]{16}","Pilihan":null,"MaksPanjang":16}]',
+    'NIK: {nik}, a.n. Pelapor', 10),
+('SIM', '[{"Kunci":"jenis","Label":"Jenis SIM (Cth: A, C)","Tipe":"text","Wajib":false,"Pola":"","Pilihan":null,"MaksPanjang":10},{"Kunci":"nomor","Label":"No. SIM","Tipe":"text","Wajib":true,"Pola":"","Pilihan":null,"MaksPanjang":30}]',
+    'Jenis {jenis} No: {nomor}, a.n. Pelapor', 20),
+('ATM', '[{"Kunci":"bank","Label":"Nama Bank","Tipe":"text","Wajib":true,"Pola":"","Pilihan":null,"MaksPanjang":50},{"Kunci":"nomor","Label":"No. Rekening/Kartu","Tipe":"text","Wajib":false,"Pola":"","Pilihan":null,"MaksPanjang":30}]',
+    'Bank {bank}, No. Rek/Kartu: {nomor}, a.n. Pelapor', 30),
+('BPKB', '[{"Kunci":"merek","Label":"Merek / Tipe","Tipe":"text","Wajib":false,"Pola":"","Pilihan":null,"MaksPanjang":50},{"Kunci":"nopol","Label":"No. Polisi","Tipe":"text","Wajib":true,"Pola":"","Pilihan":null,"MaksPanjang":15},{"Kunci":"norangka","Label":"No. Rangka","Tipe":"text","Wajib":false,"Pola":"","Pilihan":null,"MaksPanjang":30}]',
+    'Merek: {merek}, No. Pol: {nopol}, No. Rangka: {norangka}, a.n. Pelapor', 40),
+('STNK', '[{"Kunci":"merek","Label":"Merek / Tipe","Tipe":"text","Wajib":false,"Pola":"","Pilihan":null,"MaksPanjang":50},{"Kunci":"nopol","Label":"No. Polisi","Tipe":"text","Wajib":true,"Pola":"","Pilihan":null,"MaksPanjang":15}]',
+    'Merek: {merek}, No. Pol: {nopol}, a.n. Pelapor', 50),
+('Ijazah', '[{"Kunci":"tingkat","Label":"Tingkat","Tipe":"select","Wajib":true,"Pola":"","Pilihan":["SD","SMP","SMA","S1","S2"],"MaksPanjang":0},{"Kunci":"noseri","Label":"No. Seri Ijazah","Tipe":"text","Wajib":false,"Pola":"","Pilihan":null,"MaksPanjang":50}]',
+    'Tingkat {tingkat}, No. Seri: {noseri}, a.n. Pelapor', 60),
+('Paspor', '[{"Kunci":"nomor","Label":"No. Paspor","Tipe":"text","Wajib":true,"Pola":"","Pilihan":null,"MaksPanjang":20}]',
+    'No. Paspor: {nomor}, a.n. Pelapor', 70),
+('Lainnya', '[{"Kunci":"deskripsi","Label":"Deskripsi Lengkap","Tipe":"textarea","Wajib":true,"Pola":"","Pilihan":null,"MaksPanjang":500}]',
+    '{deskripsi}', 1000);
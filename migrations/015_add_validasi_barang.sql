@@ -0,0 +1,16 @@
+-- Pasang aturan validasi nomor identitas pada isian jenis barang bawaan. Hanya diubah bila
+-- isian pada posisi tersebut masih memakai kunci bawaan, agar perubahan admin tidak tertimpa.
+UPDATE jenis_barang SET fields = json_set(fields, '$[0].Validasi', 'nik', '$[0].Pola', '')
+WHERE nama = 'KTP' AND json_extract(fields, '$[0].Kunci') = 'nik';
+
+UPDATE jenis_barang SET fields = json_set(fields, '$[0].Validasi', 'jenis_sim', '$[0].MaksPanjang', 30)
+WHERE nama = 'SIM' AND json_extract(fields, '$[0].Kunci') = 'jenis';
+
+UPDATE jenis_barang SET fields = json_set(fields, '$[1].Validasi', 'sim')
+WHERE nama = 'SIM' AND json_extract(fields, '$[1].Kunci') = 'nomor';
+
+UPDATE jenis_barang SET fields = json_set(fields, '$[0].Validasi', 'paspor')
+WHERE nama = 'Paspor' AND json_extract(fields, '$[0].Kunci') = 'nomor';
+
+UPDATE jenis_barang SET fields = json_set(fields, '$[1].Validasi', 'tnkb')
+WHERE nama IN ('BPKB', 'STNK') AND json_extract(fields, '$[1].Kunci') = 'nopol';
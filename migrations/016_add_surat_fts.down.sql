@@ -0,0 +1,8 @@
+-- Menghapus indeks pencarian teks penuh beserta trigger sinkronisasinya
+DROP TRIGGER IF EXISTS surat_fts_ai;
+DROP TRIGGER IF EXISTS surat_fts_au;
+DROP TRIGGER IF EXISTS surat_fts_ad;
+DROP TRIGGER IF EXISTS surat_fts_barang_ai;
+DROP TRIGGER IF EXISTS surat_fts_barang_au;
+DROP TRIGGER IF EXISTS surat_fts_barang_ad;
+DROP TABLE IF EXISTS surat_fts;
@@ -0,0 +1,56 @@
+-- Indeks pencarian teks penuh (FTS5) untuk daftar surat. Satu baris per surat dengan rowid = surat.id;
+-- kolom barang berisi jenis dan semua nilai JSON barang.data (NIK, no. rekening, nopol, dst).
+-- Membutuhkan SQLite dengan FTS5: build aplikasi dengan -tags sqlite_fts5.
+CREATE VIRTUAL TABLE IF NOT EXISTS surat_fts USING fts5(
+    nomor_surat, pelapor_nama, lokasi_hilang, barang,
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+-- Isi awal dari data yang sudah ada
+INSERT INTO surat_fts (rowid, nomor_surat, pelapor_nama, lokasi_hilang, barang)
+SELECT s.id, s.nomor_surat, COALESCE(s.pelapor_nama, ''), COALESCE(s.lokasi_hilang, ''),
+    COALESCE((SELECT group_concat(b.jenis_barang || ' ' || CASE WHEN json_valid(b.data)
+        THEN COALESCE((SELECT group_concat(j.value, ' ') FROM json_each(b.data) j), '') ELSE b.data END, ' ')
+        FROM barang b WHERE b.surat_id = s.id), '')
+FROM surat s;
+
+-- Trigger menjaga indeks tetap sinkron. Setiap perubahan menghapus lalu menulis ulang baris surat terkait.
+CREATE TRIGGER IF NOT EXISTS surat_fts_ai AFTER INSERT ON surat BEGIN
+    INSERT INTO surat_fts (rowid, nomor_surat, pelapor_nama, lokasi_hilang, barang)
+    VALUES (new.id, new.nomor_surat, COALESCE(new.pelapor_nama, ''), COALESCE(new.lokasi_hilang, ''), '');
+END;
+
+CREATE TRIGGER IF NOT EXISTS surat_fts_au AFTER UPDATE OF nomor_surat, pelapor_nama, lokasi_hilang ON surat BEGIN
+    UPDATE surat_fts SET nomor_surat = new.nomor_surat, pelapor_nama = COALESCE(new.pelapor_nama, ''),
+        lokasi_hilang = COALESCE(new.lokasi_hilang, '')
+    WHERE rowid = new.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS surat_fts_ad AFTER DELETE ON surat BEGIN
+    DELETE FROM surat_fts WHERE rowid = old.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS surat_fts_barang_ai AFTER INSERT ON barang BEGIN
+    UPDATE surat_fts SET barang = COALESCE((SELECT group_concat(b.jenis_barang || ' ' || CASE WHEN json_valid(b.data)
+        THEN COALESCE((SELECT group_concat(j.value, ' ') FROM json_each(b.data) j), '') ELSE b.data END, ' ')
+        FROM barang b WHERE b.surat_id = new.surat_id), '')
+    WHERE rowid = new.surat_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS surat_fts_barang_au AFTER UPDATE ON barang BEGIN
+    UPDATE surat_fts SET barang = COALESCE((SELECT group_concat(b.jenis_barang || ' ' || CASE WHEN json_valid(b.data)
+        THEN COALESCE((SELECT group_concat(j.value, ' ') FROM json_each(b.data) j), '') ELSE b.data END, ' ')
+        FROM barang b WHERE b.surat_id = new.surat_id), '')
+    WHERE rowid = new.surat_id;
+    UPDATE surat_fts SET barang = COALESCE((SELECT group_concat(b.jenis_barang || ' ' || CASE WHEN json_valid(b.data)
+        THEN COALESCE((SELECT group_concat(j.value, ' ') FROM json_each(b.data) j), '') ELSE b.data END, ' ')
+        FROM barang b WHERE b.surat_id = old.surat_id), '')
+    WHERE rowid = old.surat_id AND old.surat_id IS NOT new.surat_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS surat_fts_barang_ad AFTER DELETE ON barang BEGIN
+    UPDATE surat_fts SET barang = COALESCE((SELECT group_concat(b.jenis_barang || ' ' || CASE WHEN json_valid(b.data)
+        THEN COALESCE((SELECT group_concat(j.value, ' ') FROM json_each(b.data) j), '') ELSE b.data END, ' ')
+        FROM barang b WHERE b.surat_id = old.surat_id), '')
+    WHERE rowid = old.surat_id;
+END;
@@ -0,0 +1,6 @@
+-- Nama pelapor yang sudah diubah dari NULL menjadi '' tidak dikembalikan
+DROP INDEX IF EXISTS idx_barang_jenis;
+DROP INDEX IF EXISTS idx_surat_penerima;
+DROP INDEX IF EXISTS idx_surat_pelapor;
+DROP INDEX IF EXISTS idx_surat_tanggal;
+ALTER TABLE surat DROP COLUMN penerima_id;
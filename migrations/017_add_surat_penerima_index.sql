@@ -0,0 +1,15 @@
+-- Petugas penerima laporan dicatat per surat agar daftar surat dapat difilter per petugas.
+-- Surat lama diisi dengan penerima yang sedang aktif di pengaturan.
+ALTER TABLE surat ADD COLUMN penerima_id INTEGER REFERENCES petugas(id) ON DELETE SET NULL;
+UPDATE surat SET penerima_id = (
+    SELECT p.penerima_id FROM pengaturan p JOIN petugas ON petugas.id = p.penerima_id WHERE p.id = 1
+);
+
+-- Indeks untuk pengurutan dan paginasi keyset daftar surat (kolom urut + id)
+CREATE INDEX IF NOT EXISTS idx_surat_tanggal ON surat (tanggal_surat, id);
+CREATE INDEX IF NOT EXISTS idx_surat_pelapor ON surat (pelapor_nama, id);
+CREATE INDEX IF NOT EXISTS idx_surat_penerima ON surat (penerima_id);
+CREATE INDEX IF NOT EXISTS idx_barang_jenis ON barang (jenis_barang, surat_id);
+
+-- Nama pelapor kosong disimpan sebagai '' agar urutan keyset tidak perlu menangani NULL
+UPDATE surat SET pelapor_nama = '' WHERE pelapor_nama IS NULL;
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_surat_pelapor_id;
+ALTER TABLE surat DROP COLUMN tanda_berulang;
+ALTER TABLE surat DROP COLUMN pelapor_id;
+ALTER TABLE pengaturan DROP COLUMN periode_laporan_berulang;
+ALTER TABLE pengaturan DROP COLUMN batas_laporan_berulang;
+DROP TABLE IF EXISTS pelapor;
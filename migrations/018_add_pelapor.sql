@@ -0,0 +1,44 @@
+-- Data induk pelapor dengan NIK sebagai kunci, agar pelapor yang sama tidak diketik ulang
+-- dan riwayat suratnya bisa dilihat. Surat tetap menyimpan salinan data pelapor seperti tercetak.
+CREATE TABLE IF NOT EXISTS pelapor (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    nik TEXT NOT NULL UNIQUE,
+    nama TEXT NOT NULL DEFAULT '',
+    ttl TEXT NOT NULL DEFAULT '',
+    agama TEXT NOT NULL DEFAULT '',
+    kelamin TEXT NOT NULL DEFAULT '',
+    pekerjaan TEXT NOT NULL DEFAULT '',
+    alamat TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_pelapor_nama ON pelapor (nama);
+
+ALTER TABLE surat ADD COLUMN pelapor_id INTEGER REFERENCES pelapor(id) ON DELETE SET NULL;
+-- Peringatan laporan berulang yang dicatat saat surat dibuat, kosong bila tidak ada
+ALTER TABLE surat ADD COLUMN tanda_berulang TEXT NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_surat_pelapor_id ON surat (pelapor_id, tanggal_surat);
+
+-- Batas laporan berulang: lebih dari N surat untuk jenis barang yang sama dalam periode hari
+ALTER TABLE pengaturan ADD COLUMN batas_laporan_berulang INTEGER NOT NULL DEFAULT 2;
+ALTER TABLE pengaturan ADD COLUMN periode_laporan_berulang INTEGER NOT NULL DEFAULT 365;
+
+-- Surat lama: NIK pada KTP yang hilang adalah NIK pelapor (tercetak "a.n. Pelapor").
+-- Data pelapor diambil dari surat terbaru untuk setiap NIK.
+INSERT OR IGNORE INTO pelapor (nik, nama, ttl, agama, kelamin, pekerjaan, alamat, created_at, updated_at)
+SELECT b.nik, COALESCE(s.pelapor_nama, ''), COALESCE(s.pelapor_ttl, ''),
+    COALESCE(s.pelapor_agama, ''), COALESCE(s.pelapor_kelamin, ''), COALESCE(s.pelapor_pekerjaan, ''),
+    COALESCE(s.pelapor_alamat, ''), s.tanggal_surat, s.tanggal_surat
+FROM surat s JOIN (
+    SELECT surat_id, CASE WHEN json_valid(data) THEN json_extract(data, '$.nik') END AS nik
+    FROM barang WHERE jenis_barang = 'KTP'
+) b ON b.surat_id = s.id
+WHERE length(b.nik) = 16
+ORDER BY s.tanggal_surat DESC, s.id DESC;
+
+UPDATE surat SET pelapor_id = (
+    SELECT p.id FROM barang b JOIN pelapor p
+        ON p.nik = CASE WHEN json_valid(b.data) THEN json_extract(b.data, '$.nik') END
+    WHERE b.surat_id = surat.id AND b.jenis_barang = 'KTP'
+    LIMIT 1
+);
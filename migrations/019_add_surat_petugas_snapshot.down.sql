@@ -0,0 +1,9 @@
+ALTER TABLE surat DROP COLUMN penerima_jabatan;
+ALTER TABLE surat DROP COLUMN penerima_nrp;
+ALTER TABLE surat DROP COLUMN penerima_pangkat;
+ALTER TABLE surat DROP COLUMN penerima_nama;
+ALTER TABLE surat DROP COLUMN pejabat_jabatan;
+ALTER TABLE surat DROP COLUMN pejabat_nrp;
+ALTER TABLE surat DROP COLUMN pejabat_pangkat;
+ALTER TABLE surat DROP COLUMN pejabat_nama;
+ALTER TABLE surat DROP COLUMN pejabat_id;
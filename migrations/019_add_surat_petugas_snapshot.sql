@@ -0,0 +1,26 @@
+-- Identitas pejabat penandatangan dan petugas penerima disalin ke surat saat terbit, agar cetak
+-- ulang dan ekspor menampilkan petugas yang benar-benar menandatangani, bukan petugas saat ini.
+ALTER TABLE surat ADD COLUMN pejabat_id INTEGER REFERENCES petugas(id) ON DELETE SET NULL;
+ALTER TABLE surat ADD COLUMN pejabat_nama TEXT NOT NULL DEFAULT '';
+ALTER TABLE surat ADD COLUMN pejabat_pangkat TEXT NOT NULL DEFAULT '';
+ALTER TABLE surat ADD COLUMN pejabat_nrp TEXT NOT NULL DEFAULT '';
+ALTER TABLE surat ADD COLUMN pejabat_jabatan TEXT NOT NULL DEFAULT '';
+ALTER TABLE surat ADD COLUMN penerima_nama TEXT NOT NULL DEFAULT '';
+ALTER TABLE surat ADD COLUMN penerima_pangkat TEXT NOT NULL DEFAULT '';
+ALTER TABLE surat ADD COLUMN penerima_nrp TEXT NOT NULL DEFAULT '';
+ALTER TABLE surat ADD COLUMN penerima_jabatan TEXT NOT NULL DEFAULT '';
+
+-- Surat lama tidak mencatat pejabatnya, jadi diisi dengan pejabat yang sedang aktif di pengaturan
+-- (sama dengan yang tercetak sampai sekarang). Penerima diambil dari penerima_id surat.
+UPDATE surat SET pejabat_id = (
+    SELECT p.pejabat_id FROM pengaturan p JOIN petugas ON petugas.id = p.pejabat_id WHERE p.id = 1
+);
+UPDATE surat SET
+    pejabat_nama = COALESCE((SELECT nama FROM petugas WHERE id = surat.pejabat_id), ''),
+    pejabat_pangkat = COALESCE((SELECT pangkat FROM petugas WHERE id = surat.pejabat_id), ''),
+    pejabat_nrp = COALESCE((SELECT nrp FROM petugas WHERE id = surat.pejabat_id), ''),
+    pejabat_jabatan = COALESCE((SELECT jabatan FROM petugas WHERE id = surat.pejabat_id), ''),
+    penerima_nama = COALESCE((SELECT nama FROM petugas WHERE id = surat.penerima_id), ''),
+    penerima_pangkat = COALESCE((SELECT pangkat FROM petugas WHERE id = surat.penerima_id), ''),
+    penerima_nrp = COALESCE((SELECT nrp FROM petugas WHERE id = surat.penerima_id), ''),
+    penerima_jabatan = COALESCE((SELECT jabatan FROM petugas WHERE id = surat.penerima_id), '');
@@ -0,0 +1,2 @@
+ALTER TABLE surat DROP COLUMN pengaturan_revisi_id;
+DROP TABLE IF EXISTS pengaturan_revisi;
@@ -0,0 +1,24 @@
+-- Revisi kop surat dan identitas kantor. Setiap perubahan pengaturan yang tercetak di surat
+-- disimpan sebagai revisi baru, dan surat menunjuk ke revisi yang aktif saat diterbitkan
+-- agar cetak ulang sama persis dengan dokumen aslinya.
+CREATE TABLE IF NOT EXISTS pengaturan_revisi (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    kop_surat_1 TEXT NOT NULL DEFAULT '',
+    kop_surat_2 TEXT NOT NULL DEFAULT '',
+    kop_surat_3 TEXT NOT NULL DEFAULT '',
+    logo_path TEXT NOT NULL DEFAULT '',
+    wilayah TEXT NOT NULL DEFAULT '',
+    nama_kantor TEXT NOT NULL DEFAULT '',
+    dibuat_oleh TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE surat ADD COLUMN pengaturan_revisi_id INTEGER REFERENCES pengaturan_revisi(id);
+
+-- Riwayat sebelum ini tidak tersimpan, jadi pengaturan saat ini menjadi revisi pertama dan
+-- dipakai oleh semua surat lama (sama dengan yang tercetak sampai sekarang)
+INSERT INTO pengaturan_revisi (kop_surat_1, kop_surat_2, kop_surat_3, logo_path, wilayah, nama_kantor)
+SELECT COALESCE(kop_surat_1, ''), COALESCE(kop_surat_2, ''), COALESCE(kop_surat_3, ''),
+    COALESCE(logo_path, ''), COALESCE(wilayah, ''), COALESCE(nama_kantor, '')
+FROM pengaturan WHERE id = 1;
+UPDATE surat SET pengaturan_revisi_id = (SELECT MAX(id) FROM pengaturan_revisi);
@@ -0,0 +1,50 @@
+-- Surat, petugas dan pengguna kantor lain tetap ada tetapi tidak lagi dibedakan kantornya;
+-- pengaturan dan penghitung nomor kantor selain kantor 1 dihapus.
+DROP INDEX IF EXISTS idx_surat_kantor_tanggal;
+ALTER TABLE users DROP COLUMN kantor_id;
+ALTER TABLE petugas DROP COLUMN kantor_id;
+ALTER TABLE pengaturan_revisi DROP COLUMN kantor_id;
+ALTER TABLE surat DROP COLUMN kantor_id;
+
+CREATE TABLE nomor_counter_lama (
+    periode TEXT PRIMARY KEY,
+    last_nomor INTEGER NOT NULL DEFAULT 0
+);
+INSERT INTO nomor_counter_lama (periode, last_nomor) SELECT periode, last_nomor FROM nomor_counter WHERE kantor_id = 1;
+DROP TABLE nomor_counter;
+ALTER TABLE nomor_counter_lama RENAME TO nomor_counter;
+
+CREATE TABLE pengaturan_lama (
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    kop_surat_1 TEXT,
+    kop_surat_2 TEXT,
+    kop_surat_3 TEXT,
+    logo_path TEXT,
+    format_nomor_surat TEXT,
+    last_nomor_surat INTEGER DEFAULT 0,
+    pejabat_nama TEXT,
+    pejabat_pangkat TEXT,
+    pejabat_nrp TEXT,
+    pejabat_jabatan TEXT,
+    penerima_nama TEXT,
+    penerima_pangkat TEXT,
+    penerima_nrp TEXT,
+    penerima_jabatan TEXT,
+    pejabat_id INTEGER,
+    penerima_id INTEGER,
+    wilayah TEXT,
+    last_nomor_year INTEGER,
+    nama_kantor TEXT,
+    kode_kantor TEXT,
+    reset_nomor TEXT NOT NULL DEFAULT 'tahunan',
+    masa_berlaku_hari INTEGER NOT NULL DEFAULT 15,
+    batas_laporan_berulang INTEGER NOT NULL DEFAULT 2,
+    periode_laporan_berulang INTEGER NOT NULL DEFAULT 365
+);
+INSERT INTO pengaturan_lama SELECT id, kop_surat_1, kop_surat_2, kop_surat_3, logo_path, format_nomor_surat, last_nomor_surat,
+    pejabat_nama, pejabat_pangkat, pejabat_nrp, pejabat_jabatan, penerima_nama, penerima_pangkat, penerima_nrp, penerima_jabatan,
+    pejabat_id, penerima_id, wilayah, last_nomor_year, nama_kantor, kode_kantor, reset_nomor, masa_berlaku_hari,
+    batas_laporan_berulang, periode_laporan_berulang
+FROM pengaturan WHERE id = 1;
+DROP TABLE pengaturan;
+ALTER TABLE pengaturan_lama RENAME TO pengaturan;
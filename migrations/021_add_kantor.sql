@@ -0,0 +1,65 @@
+-- Satu instalasi melayani beberapa kantor (Polsek). Setiap baris pengaturan kini adalah satu kantor
+-- dengan kop surat, logo, format nomor dan penghitung nomornya sendiri; baris id 1 adalah kantor
+-- yang sudah ada sebelumnya. Batasan id = 1 dihapus dengan membangun ulang tabelnya.
+CREATE TABLE pengaturan_baru (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    kop_surat_1 TEXT,
+    kop_surat_2 TEXT,
+    kop_surat_3 TEXT,
+    logo_path TEXT,
+    format_nomor_surat TEXT,
+    last_nomor_surat INTEGER DEFAULT 0,
+    pejabat_nama TEXT,
+    pejabat_pangkat TEXT,
+    pejabat_nrp TEXT,
+    pejabat_jabatan TEXT,
+    penerima_nama TEXT,
+    penerima_pangkat TEXT,
+    penerima_nrp TEXT,
+    penerima_jabatan TEXT,
+    pejabat_id INTEGER,
+    penerima_id INTEGER,
+    wilayah TEXT,
+    last_nomor_year INTEGER,
+    nama_kantor TEXT,
+    kode_kantor TEXT,
+    reset_nomor TEXT NOT NULL DEFAULT 'tahunan',
+    masa_berlaku_hari INTEGER NOT NULL DEFAULT 15,
+    batas_laporan_berulang INTEGER NOT NULL DEFAULT 2,
+    periode_laporan_berulang INTEGER NOT NULL DEFAULT 365
+);
+INSERT INTO pengaturan_baru (id, kop_surat_1, kop_surat_2, kop_surat_3, logo_path, format_nomor_surat, last_nomor_surat,
+    pejabat_nama, pejabat_pangkat, pejabat_nrp, pejabat_jabatan, penerima_nama, penerima_pangkat, penerima_nrp, penerima_jabatan,
+    pejabat_id, penerima_id, wilayah, last_nomor_year, nama_kantor, kode_kantor, reset_nomor, masa_berlaku_hari,
+    batas_laporan_berulang, periode_laporan_berulang)
+SELECT id, kop_surat_1, kop_surat_2, kop_surat_3, logo_path, format_nomor_surat, last_nomor_surat,
+    pejabat_nama, pejabat_pangkat, pejabat_nrp, pejabat_jabatan, penerima_nama, penerima_pangkat, penerima_nrp, penerima_jabatan,
+    pejabat_id, penerima_id, wilayah, last_nomor_year, nama_kantor, kode_kantor, reset_nomor, masa_berlaku_hari,
+    batas_laporan_berulang, periode_laporan_berulang
+FROM pengaturan;
+DROP TABLE pengaturan;
+ALTER TABLE pengaturan_baru RENAME TO pengaturan;
+
+-- Penghitung nomor urut dipisah per kantor
+CREATE TABLE nomor_counter_baru (
+    kantor_id INTEGER NOT NULL REFERENCES pengaturan(id) ON DELETE CASCADE,
+    periode TEXT NOT NULL,
+    last_nomor INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (kantor_id, periode)
+);
+INSERT INTO nomor_counter_baru (kantor_id, periode, last_nomor) SELECT 1, periode, last_nomor FROM nomor_counter;
+DROP TABLE nomor_counter;
+ALTER TABLE nomor_counter_baru RENAME TO nomor_counter;
+
+-- Surat dan revisi kop surat selalu milik satu kantor. Petugas dan pengguna tanpa kantor (NULL)
+-- berlaku untuk semua kantor; operator dan supervisor yang sudah ada dibatasi ke kantor 1.
+ALTER TABLE surat ADD COLUMN kantor_id INTEGER REFERENCES pengaturan(id);
+ALTER TABLE pengaturan_revisi ADD COLUMN kantor_id INTEGER REFERENCES pengaturan(id);
+ALTER TABLE petugas ADD COLUMN kantor_id INTEGER REFERENCES pengaturan(id) ON DELETE SET NULL;
+ALTER TABLE users ADD COLUMN kantor_id INTEGER REFERENCES pengaturan(id) ON DELETE SET NULL;
+
+UPDATE surat SET kantor_id = 1;
+UPDATE pengaturan_revisi SET kantor_id = 1;
+UPDATE users SET kantor_id = 1 WHERE role != 'admin';
+
+CREATE INDEX IF NOT EXISTS idx_surat_kantor_tanggal ON surat(kantor_id, tanggal_surat);
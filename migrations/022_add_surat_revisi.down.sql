@@ -0,0 +1,5 @@
+-- Isi surat terakhir tetap ada di tabel surat; riwayat revisinya dihapus.
+ALTER TABLE pengaturan DROP COLUMN batas_ubah_jam;
+ALTER TABLE surat DROP COLUMN revisi;
+DROP TABLE IF EXISTS surat_revisi_barang;
+DROP TABLE IF EXISTS surat_revisi;
@@ -0,0 +1,45 @@
+-- Revisi isi surat. Mengubah surat yang sudah terbit tidak lagi menimpa isinya begitu saja: isi
+-- baru disimpan sebagai revisi berikutnya, sedangkan data pelapor dan barang revisi sebelumnya
+-- tetap tersimpan agar perubahan setelah surat dicetak dapat ditelusuri.
+CREATE TABLE IF NOT EXISTS surat_revisi (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    surat_id INTEGER NOT NULL REFERENCES surat(id) ON DELETE CASCADE,
+    revisi INTEGER NOT NULL,
+    pelapor_nik TEXT NOT NULL DEFAULT '',
+    pelapor_nama TEXT NOT NULL DEFAULT '',
+    pelapor_ttl TEXT NOT NULL DEFAULT '',
+    pelapor_agama TEXT NOT NULL DEFAULT '',
+    pelapor_kelamin TEXT NOT NULL DEFAULT '',
+    pelapor_pekerjaan TEXT NOT NULL DEFAULT '',
+    pelapor_alamat TEXT NOT NULL DEFAULT '',
+    lokasi_hilang TEXT NOT NULL DEFAULT '',
+    alasan TEXT NOT NULL DEFAULT '',
+    dibuat_oleh TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (surat_id, revisi)
+);
+
+CREATE TABLE IF NOT EXISTS surat_revisi_barang (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    revisi_id INTEGER NOT NULL REFERENCES surat_revisi(id) ON DELETE CASCADE,
+    jenis_barang TEXT NOT NULL,
+    data TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_surat_revisi_barang ON surat_revisi_barang(revisi_id);
+
+-- Nomor revisi isi surat yang berlaku, dicetak pada surat yang sudah direvisi
+ALTER TABLE surat ADD COLUMN revisi INTEGER NOT NULL DEFAULT 1;
+
+-- Lama waktu (jam) sejak surat terbit selama surat masih boleh diubah; 0 berarti tanpa batas
+ALTER TABLE pengaturan ADD COLUMN batas_ubah_jam INTEGER NOT NULL DEFAULT 0;
+
+-- Perubahan sebelum ini tidak tersimpan, jadi isi surat saat ini menjadi revisi pertama
+INSERT INTO surat_revisi (surat_id, revisi, pelapor_nik, pelapor_nama, pelapor_ttl, pelapor_agama,
+    pelapor_kelamin, pelapor_pekerjaan, pelapor_alamat, lokasi_hilang, created_at)
+SELECT s.id, 1, COALESCE(p.nik, ''), COALESCE(s.pelapor_nama, ''), COALESCE(s.pelapor_ttl, ''),
+    COALESCE(s.pelapor_agama, ''), COALESCE(s.pelapor_kelamin, ''), COALESCE(s.pelapor_pekerjaan, ''),
+    COALESCE(s.pelapor_alamat, ''), COALESCE(s.lokasi_hilang, ''), s.tanggal_surat
+FROM surat s LEFT JOIN pelapor p ON p.id = s.pelapor_id;
+INSERT INTO surat_revisi_barang (revisi_id, jenis_barang, data)
+SELECT r.id, b.jenis_barang, b.data FROM barang b JOIN surat_revisi r ON r.surat_id = b.surat_id
+ORDER BY b.id;
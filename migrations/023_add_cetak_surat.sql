@@ -0,0 +1,14 @@
+-- Riwayat cetak surat. Setiap kali surat dicetak atau diunduh sebagai PDF dicatat siapa, kapan dan
+-- alasannya. Cetakan pertama (salinan 0) adalah surat asli; cetakan berikutnya bertanda SALINAN.
+CREATE TABLE IF NOT EXISTS cetak_surat (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    surat_id INTEGER NOT NULL REFERENCES surat(id) ON DELETE CASCADE,
+    salinan INTEGER NOT NULL,
+    format TEXT NOT NULL DEFAULT 'halaman',
+    revisi INTEGER NOT NULL DEFAULT 1,
+    alasan TEXT NOT NULL DEFAULT '',
+    dicetak_oleh TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL,
+    UNIQUE (surat_id, salinan)
+);
+CREATE INDEX IF NOT EXISTS idx_cetak_surat_created_at ON cetak_surat(created_at);
@@ -0,0 +1,2 @@
+-- Format nomor lama yang tidak valid tidak dikembalikan; migrasi ini hanya memperbaiki data.
+SELECT 1;
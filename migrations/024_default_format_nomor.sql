@@ -0,0 +1,12 @@
+-- Format nomor surat yang kosong atau tidak lolos aturan penomoran (tanpa {NO}, tanpa tahun, atau reset
+-- bulanan tanpa bulan) diganti format bawaan, agar penerbitan surat tidak gagal karena data lama.
+-- Kantor yang punya kode kantor memakai {KODE} supaya nomornya tidak bentrok dengan kantor lain.
+UPDATE pengaturan
+SET format_nomor_surat = CASE
+        WHEN TRIM(COALESCE(kode_kantor, '')) = '' THEN 'SKH/{NO}/{BLN_ROMAWI}/{THN}'
+        ELSE 'SKH/{NO}/{BLN_ROMAWI}/{KODE}/{THN}'
+    END
+WHERE TRIM(COALESCE(format_nomor_surat, '')) = ''
+   OR (format_nomor_surat NOT LIKE '%{NO}%' AND format_nomor_surat NOT LIKE '%{NO:%')
+   OR (format_nomor_surat NOT LIKE '%{THN}%' AND format_nomor_surat NOT LIKE '%{THN2}%')
+   OR (reset_nomor = 'bulanan' AND format_nomor_surat NOT LIKE '%{BLN}%' AND format_nomor_surat NOT LIKE '%{BLN_ROMAWI}%');
@@ -0,0 +1,2 @@
+-- Tanggal berlaku yang sudah dihitung ulang tidak dikembalikan ke zona waktu +08:00 yang keliru.
+SELECT 1;
@@ -0,0 +1,13 @@
+-- Migrasi 013 mengisi berlaku_sampai surat lama dengan 15 hari dan zona waktu +08:00 yang ditulis
+-- tetap. Surat lama yang tanggal suratnya tersimpan dengan zona waktu lain dihitung ulang memakai
+-- masa_berlaku_hari kantor penerbitnya dan zona waktu tanggal surat itu sendiri, sama dengan
+-- CalculateBerlakuSampai: tanggal surat adalah hari pertama, disimpan pukul 00:00. Surat berzona
+-- +08:00 dibiarkan karena tidak bisa dibedakan dari surat yang diterbitkan aplikasi dengan benar.
+UPDATE surat
+SET berlaku_sampai = date(substr(tanggal_surat, 1, 10),
+        '+' || (COALESCE((SELECT CASE WHEN p.masa_berlaku_hari >= 1 THEN p.masa_berlaku_hari END
+                          FROM pengaturan p WHERE p.id = surat.kantor_id), 15) - 1) || ' days')
+    || ' 00:00:00' || substr(tanggal_surat, -6)
+WHERE berlaku_sampai = date(substr(tanggal_surat, 1, 10), '+14 days') || ' 00:00:00+08:00'
+  AND substr(tanggal_surat, -6) GLOB '[+-][0-9][0-9]:[0-9][0-9]'
+  AND substr(tanggal_surat, -6) <> '+08:00';
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_audit_log_kantor;
+ALTER TABLE audit_log DROP COLUMN kantor_id;
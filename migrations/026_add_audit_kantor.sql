@@ -0,0 +1,18 @@
+-- Kantor pemilik data yang diaudit, agar pengguna yang dibatasi ke satu kantor hanya melihat jejak
+-- audit kantornya. NULL untuk data semua kantor: jenis barang, cadangan dan petugas semua kantor.
+ALTER TABLE audit_log ADD COLUMN kantor_id INTEGER;
+
+UPDATE audit_log SET kantor_id = (SELECT kantor_id FROM surat WHERE surat.id = audit_log.entity_id)
+WHERE entity = 'surat';
+
+UPDATE audit_log SET kantor_id = entity_id WHERE entity = 'pengaturan';
+
+-- Petugas yang sudah dihapus diambil kantornya dari nilai sebelum dihapus yang tercatat di diff
+UPDATE audit_log SET kantor_id = CASE
+        WHEN EXISTS (SELECT 1 FROM petugas WHERE petugas.id = audit_log.entity_id)
+            THEN (SELECT kantor_id FROM petugas WHERE petugas.id = audit_log.entity_id)
+        ELSE NULLIF(json_extract(diff, '$.KantorID.before'), 0)
+    END
+WHERE entity = 'petugas';
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_kantor ON audit_log(kantor_id);
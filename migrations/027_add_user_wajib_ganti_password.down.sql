@@ -0,0 +1 @@
+ALTER TABLE users DROP COLUMN wajib_ganti_password;
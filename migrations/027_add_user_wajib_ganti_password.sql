@@ -0,0 +1,3 @@
+-- Pengguna yang ditandai wajib mengganti password sebelum dapat memakai aplikasi, mis. admin awal
+-- yang password-nya dibuat acak saat instalasi.
+ALTER TABLE users ADD COLUMN wajib_ganti_password INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1 @@
+ALTER TABLE surat DROP COLUMN kode_verifikasi;
@@ -0,0 +1,5 @@
+-- Kode acak per surat yang ditandatangani token QR code verifikasi. Token tidak lagi memakai nomor
+-- surat dan nama pelapor yang masih bisa diubah lewat revisi, sehingga QR code yang sudah tercetak
+-- tetap sah setelah surat direvisi.
+ALTER TABLE surat ADD COLUMN kode_verifikasi TEXT NOT NULL DEFAULT '';
+UPDATE surat SET kode_verifikasi = lower(hex(randomblob(16)));
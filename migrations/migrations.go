@@ -0,0 +1,10 @@
+// Package migrations menyimpan skrip SQL skema database di dalam binary, sehingga aplikasi
+// tidak bergantung pada folder kerja saat dijalankan.
+package migrations
+
+import "embed"
+
+// Files berisi skrip NNN_nama.sql (naik) dan NNN_nama.down.sql (turun, opsional)
+//
+//go:embed *.sql
+var Files embed.FS
@@ -5,3 +5,8 @@ import "embed"
 // PERBAIKAN: Gunakan static/* untuk membungkus semua subfolder di dalamnya
 //go:embed templates/* static/*
 var Files embed.FS
+
+// OpenAPI adalah dokumen OpenAPI untuk /api/v1, disajikan di /api/v1/openapi.yaml
+//
+//go:embed openapi.yaml
+var OpenAPI []byte